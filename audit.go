@@ -0,0 +1,377 @@
+package config
+
+import (
+	"bufio"
+	stderr "errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/user"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Station-Manager/errors"
+	"github.com/goccy/go-json"
+)
+
+// The lock file of the history is retried every historyLockRetry for up to historyLockTimeout, and broken once
+// it is older than historyLockStale.
+const (
+	historyLockTimeout = 5 * time.Second
+	historyLockRetry   = 10 * time.Millisecond
+	historyLockStale   = time.Minute
+)
+
+const (
+	// DefaultHistoryLimit is the number of revisions kept in the history unless WithHistoryLimit says otherwise.
+	DefaultHistoryLimit = 100
+	// UnlimitedHistory, passed to WithHistoryLimit, keeps every revision.
+	UnlimitedHistory = -1
+	// Revisions hold secrets in clear text, so only the owner may read them.
+	revisionFilePermission = 0o600
+	revisionDirPermission  = 0o700
+)
+
+// Source identifies what made a change recorded in the audit log.
+type Source string
+
+// Sources of changes. WithActor sets the source of a service's changes; SourceAPI is the default.
+const (
+	SourceUI  Source = "ui"
+	SourceCLI Source = "cli"
+	SourceAPI Source = "api"
+	// SourceBaseline marks the configuration as it was before the first recorded change.
+	SourceBaseline Source = "baseline"
+)
+
+// AuditEntry records one saved change to the configuration. Revisions are numbered from 1 and the newest are
+// kept in full, so that they can be restored with Rollback.
+type AuditEntry struct {
+	Revision uint64    `json:"revision"`
+	Time     time.Time `json:"time"`
+	Actor    string    `json:"actor,omitempty"`
+	Source   Source    `json:"source"`
+	// ETag is the Snapshot.ETag of the configuration saved as this revision.
+	ETag string `json:"etag"`
+	// Note describes changes that are not made directly, such as a rollback.
+	Note string `json:"note,omitempty"`
+	// Changes are the differences from the previous revision, with secrets masked. Read back from the log,
+	// their values are decoded JSON rather than the original Go types.
+	Changes []Change `json:"changes,omitempty"`
+}
+
+// historyFiles are the files recording the history of a configuration file.
+type historyFiles struct {
+	log  string // The audit log, one AuditEntry per line
+	dir  string // The directory holding the revisions
+	lock string // Held while a change is recorded and saved
+}
+
+// historyFiles returns the files recording the history of the configuration file, next to it. Only a
+// configuration file has a history.
+func (s *Service) historyFiles() (historyFiles, bool) {
+	file, ok := s.store().(fileStore)
+	if !ok {
+		return historyFiles{}, false
+	}
+	base := strings.TrimSuffix(file.path, filepath.Ext(file.path))
+	return historyFiles{log: base + ".audit.jsonl", dir: base + ".history", lock: base + ".audit.lock"}, true
+}
+
+// lockHistory takes the lock file of the history, which serializes changes between processes sharing the
+// configuration file, and returns the function releasing it. A lock older than historyLockStale was left behind
+// by a process that died, and is broken.
+func lockHistory(path string) (func(), error) {
+	deadline := time.Now().Add(historyLockTimeout)
+	for {
+		f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+		if err == nil {
+			_ = f.Close()
+			return func() { _ = os.Remove(path) }, nil
+		}
+		if !stderr.Is(err, fs.ErrExist) {
+			return nil, err
+		}
+		if info, statErr := os.Stat(path); statErr == nil && time.Since(info.ModTime()) > historyLockStale {
+			_ = os.Remove(path)
+			continue
+		}
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("%s is held by another process", path)
+		}
+		time.Sleep(historyLockRetry)
+	}
+}
+
+// revisionFile returns the file holding the configuration saved as revision.
+func revisionFile(dir string, revision uint64) string {
+	return filepath.Join(dir, fmt.Sprintf("%d.json", revision))
+}
+
+// readAuditLog returns the entries of the audit log at path, oldest first. A missing log has no entries.
+func readAuditLog(path string) ([]AuditEntry, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		if stderr.Is(err, fs.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer func() { _ = f.Close() }()
+
+	var entries []AuditEntry
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		if len(strings.TrimSpace(scanner.Text())) == 0 {
+			continue
+		}
+		var e AuditEntry
+		if err = json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			return nil, fmt.Errorf("%s, line %d: %w", path, line, err)
+		}
+		entries = append(entries, e)
+	}
+	return entries, scanner.Err()
+}
+
+// appendAuditEntry appends e to the audit log at path as one line.
+func appendAuditEntry(path string, e AuditEntry) error {
+	data, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o640)
+	if err != nil {
+		return err
+	}
+	if _, err = f.Write(append(data, '\n')); err != nil {
+		_ = f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// recordChange records next in the history of the configuration file, replacing s.stored, as described by
+// recordRevision. It holds the history lock until done is called, once the configuration has been saved or has
+// failed to: done(true) removes the revisions beyond the history limit, and done(false) removes the revision and
+// its entry again. Nothing is recorded without a history, or for a change that leaves the content as it is, such
+// as a save rewriting the file in the current format. The caller must hold s.mu.
+func (s *Service) recordChange(next *Snapshot, note string) (func(saved bool), error) {
+	files, ok := s.historyFiles()
+	if !ok || (s.stored != nil && next.ETag == s.stored.ETag) {
+		return func(bool) {}, nil
+	}
+	unlock, err := lockHistory(files.lock)
+	if err != nil {
+		return nil, err
+	}
+	revision, discard, err := s.recordRevision(files, s.stored, next, note)
+	if err != nil {
+		unlock()
+		return nil, err
+	}
+	return func(saved bool) {
+		if saved {
+			pruneRevisions(files.dir, revision, s.historyLimit)
+		} else {
+			discard()
+		}
+		unlock()
+	}, nil
+}
+
+// recordRevision writes next as a new revision of the history and appends its audit entry. prev is the
+// configuration it replaces; if there is no history yet, prev is recorded first as the baseline, so that the
+// first change can be rolled back too. Recording the change before it is saved means a change is never saved
+// without its entry. It returns the new revision and discard, which removes the revisions and entries it wrote,
+// the baseline included, if the configuration cannot be saved. The caller must hold the history lock.
+func (s *Service) recordRevision(files historyFiles, prev, next *Snapshot, note string) (uint64, func(), error) {
+	if err := os.MkdirAll(files.dir, revisionDirPermission); err != nil {
+		return 0, nil, err
+	}
+	// Histories written by older releases were readable by the group.
+	if err := os.Chmod(files.dir, revisionDirPermission); err != nil {
+		return 0, nil, err
+	}
+	last, err := lastRevision(files.dir)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	// discard undoes everything this call writes, the baseline included, so that a change that is not saved
+	// leaves the history as it was.
+	var size int64
+	if info, err := os.Stat(files.log); err == nil {
+		size = info.Size()
+	}
+	var written []uint64
+	discard := func() {
+		for _, revision := range written {
+			_ = os.Remove(revisionFile(files.dir, revision))
+		}
+		_ = os.Truncate(files.log, size)
+	}
+
+	if last == 0 && prev != nil {
+		written = append(written, 1)
+		if err = writeRevision(files.dir, 1, prev); err != nil {
+			discard()
+			return 0, nil, err
+		}
+		baseline := AuditEntry{Revision: 1, Time: time.Now().UTC(), Source: SourceBaseline, ETag: prev.ETag}
+		if err = appendAuditEntry(files.log, baseline); err != nil {
+			discard()
+			return 0, nil, err
+		}
+		last = 1
+	}
+
+	revision := last + 1
+	written = append(written, revision)
+	if err = writeRevision(files.dir, revision, next); err != nil {
+		discard()
+		return 0, nil, err
+	}
+
+	var changes []Change
+	if prev != nil {
+		changes = DiffSnapshots(*prev, *next)
+	}
+	entry := AuditEntry{
+		Revision: revision,
+		Time:     time.Now().UTC(),
+		Actor:    s.auditActor(),
+		Source:   s.auditSource(),
+		ETag:     next.ETag,
+		Note:     note,
+		Changes:  changes,
+	}
+	if err = appendAuditEntry(files.log, entry); err != nil {
+		discard()
+		return 0, nil, err
+	}
+	return revision, discard, nil
+}
+
+// lastRevision returns the highest revision in dir, or 0 if it holds none.
+func lastRevision(dir string) (uint64, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return 0, err
+	}
+	var last uint64
+	for _, e := range entries {
+		name, ok := strings.CutSuffix(e.Name(), ".json")
+		if !ok || e.IsDir() {
+			continue
+		}
+		if n, err := strconv.ParseUint(name, 10, 64); err == nil {
+			last = max(last, n)
+		}
+	}
+	return last, nil
+}
+
+// pruneRevisions removes the revisions in dir older than the newest limit, up to revision. Zero keeps
+// DefaultHistoryLimit revisions and UnlimitedHistory every revision, as for WithHistoryLimit. Their audit entries
+// stay in the log.
+func pruneRevisions(dir string, revision uint64, limit int) {
+	if limit == 0 {
+		limit = DefaultHistoryLimit
+	}
+	if limit == UnlimitedHistory || revision <= uint64(limit) {
+		return
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return
+	}
+	for _, e := range entries {
+		name, ok := strings.CutSuffix(e.Name(), ".json")
+		if !ok || e.IsDir() {
+			continue
+		}
+		if n, err := strconv.ParseUint(name, 10, 64); err == nil && n <= revision-uint64(limit) {
+			_ = os.Remove(filepath.Join(dir, e.Name()))
+		}
+	}
+}
+
+// writeRevision writes snap to the revision file, in the format of the configuration file.
+func writeRevision(dir string, revision uint64, snap *Snapshot) error {
+	data, err := marshalConfigFile(snap.AppConfig, snap.Extensions)
+	if err != nil {
+		return err
+	}
+	return writeDataToFile(data, revisionFile(dir, revision), revisionFilePermission)
+}
+
+// auditActor returns the actor set by WithActor, or the name of the user running the process.
+func (s *Service) auditActor() string {
+	if s.actor != "" {
+		return s.actor
+	}
+	if u, err := user.Current(); err == nil && u.Username != "" {
+		return u.Username
+	}
+	return os.Getenv("USER")
+}
+
+// auditSource returns the source set by WithActor, SourceAPI by default.
+func (s *Service) auditSource() Source {
+	if s.source != "" {
+		return s.source
+	}
+	return SourceAPI
+}
+
+// History returns the recorded changes to the configuration file, oldest first. Every entry is a revision that
+// can be restored with Rollback, until it is removed by the history limit; see WithHistoryLimit. A service using
+// a Store other than a configuration file has no history.
+func (s *Service) History() ([]AuditEntry, error) {
+	const op errors.Op = "config.Service.History"
+	if !s.isInitialized.Load() {
+		return nil, errors.New(op).Msg(errMsgNotInitialized)
+	}
+	files, ok := s.historyFiles()
+	if !ok {
+		return nil, errors.New(op).Msg("only a configuration file has a history")
+	}
+
+	entries, err := readAuditLog(files.log)
+	if err != nil {
+		return nil, errors.New(op).Err(err)
+	}
+	return entries, nil
+}
+
+// Rollback restores the configuration saved as revision. The restored configuration is validated and saved
+// like any other change, so it becomes a new revision and the rollback can itself be undone.
+func (s *Service) Rollback(revision uint64) error {
+	const op errors.Op = "config.Service.Rollback"
+	if !s.isInitialized.Load() {
+		return errors.New(op).Msg(errMsgNotInitialized)
+	}
+	files, ok := s.historyFiles()
+	if !ok {
+		return errors.New(op).Msg("only a configuration file has a history")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	cfg, ext, err := fileStore{path: revisionFile(files.dir, revision)}.Load()
+	if err != nil {
+		if stderr.Is(err, fs.ErrNotExist) {
+			return errors.New(op).Err(ErrRevisionNotFound).Msgf("revision %d not found", revision)
+		}
+		return errors.New(op).Err(err)
+	}
+	if err = s.saveConfigNote(cfg, ext, fmt.Sprintf("rollback to revision %d", revision)); err != nil {
+		return errors.New(op).Err(err)
+	}
+	return nil
+}
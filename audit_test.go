@@ -0,0 +1,267 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+
+	smerrors "github.com/Station-Manager/errors"
+	"github.com/Station-Manager/types"
+)
+
+func TestHistory_andRollback(t *testing.T) {
+	workDir := t.TempDir()
+	svc, err := New(WithWorkingDir(workDir), WithActor("g4abc", SourceUI))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	if entries, err := svc.History(); err != nil || len(entries) != 0 {
+		t.Fatalf("History() = %v, %v; want no entries before the first change", entries, err)
+	}
+	initial, _ := svc.Snapshot()
+
+	if err = svc.Set("logging_config.level", "debug"); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+	if err = svc.Set("email_configs.password", "hunter2"); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+	if err = svc.Set("logging_config.level", ""); err == nil {
+		t.Fatalf("expected an invalid change to be rejected")
+	}
+
+	entries, err := svc.History()
+	if err != nil {
+		t.Fatalf("History() error = %v", err)
+	}
+	if len(entries) != 3 {
+		t.Fatalf("expected the baseline and two changes, got %d entries: %+v", len(entries), entries)
+	}
+	if entries[0].Revision != 1 || entries[0].Source != SourceBaseline || entries[0].ETag != initial.ETag {
+		t.Errorf("unexpected baseline entry: %+v", entries[0])
+	}
+	second := entries[1]
+	if second.Revision != 2 || second.Actor != "g4abc" || second.Source != SourceUI || second.Time.IsZero() {
+		t.Errorf("unexpected entry: %+v", second)
+	}
+	if len(second.Changes) != 1 || second.Changes[0].Path != "logging_config.level" || second.Changes[0].To != "debug" {
+		t.Errorf("unexpected changes: %v", second.Changes)
+	}
+	if c := entries[2].Changes; len(c) != 1 || c[0].To != RedactedValue {
+		t.Errorf("expected the password to be masked in the audit log, got %v", c)
+	}
+	data, _ := os.ReadFile(filepath.Join(workDir, "config.audit.jsonl"))
+	if len(data) == 0 || bytes.Contains(data, []byte("hunter2")) {
+		t.Errorf("expected an audit log without secrets, got %s", data)
+	}
+
+	// Roll back to the state before the first change.
+	if err = svc.Rollback(1); err != nil {
+		t.Fatalf("Rollback() error = %v", err)
+	}
+	snap, _ := svc.Snapshot()
+	if snap.ETag != initial.ETag || snap.AppConfig.LoggingConfig.Level != "info" || snap.AppConfig.EmailConfigs.Password == "hunter2" {
+		t.Errorf("expected the initial configuration after a rollback, got level %q", snap.AppConfig.LoggingConfig.Level)
+	}
+	entries, _ = svc.History()
+	last := entries[len(entries)-1]
+	if last.Revision != 4 || last.Note != "rollback to revision 1" || len(last.Changes) != 2 {
+		t.Errorf("unexpected rollback entry: %+v", last)
+	}
+
+	// The rollback is itself a revision that can be restored.
+	if err = svc.Rollback(3); err != nil {
+		t.Fatalf("Rollback() error = %v", err)
+	}
+	if level, _ := svc.Get("logging_config.level"); level != "debug" {
+		t.Errorf("expected revision 3 to be restored, got level %v", level)
+	}
+
+	err = svc.Rollback(99)
+	if !errors.Is(err, ErrRevisionNotFound) || !errors.Is(err, smerrors.ErrNotFound) {
+		t.Errorf("expected ErrRevisionNotFound, got %v", err)
+	}
+
+	// A second service on the same file continues the numbering.
+	other := &Service{WorkingDir: workDir}
+	if err = other.Initialize(); err != nil {
+		t.Fatalf("Initialize() error = %v", err)
+	}
+	if err = other.Set("logging_config.level", "warn"); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+	entries, _ = other.History()
+	if last = entries[len(entries)-1]; last.Revision != 6 || last.Source != SourceAPI {
+		t.Errorf("unexpected entry from a second service: %+v", last)
+	}
+
+	// A save that changes nothing is not recorded.
+	if err = other.Set("logging_config.level", "warn"); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+	if again, _ := other.History(); len(again) != len(entries) {
+		t.Errorf("expected no entry for a save without changes, got %+v", again[len(again)-1])
+	}
+	if _, err = os.Stat(filepath.Join(workDir, "config.audit.lock")); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("expected the history lock to be released, got %v", err)
+	}
+}
+
+func TestHistory_auditFailure(t *testing.T) {
+	workDir := t.TempDir()
+	svc, err := New(WithWorkingDir(workDir))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	cfgPath := filepath.Join(workDir, "config.json")
+	original, _ := os.ReadFile(cfgPath)
+
+	// A change that cannot be recorded is not saved, so the file never holds a change missing from the log.
+	if err = os.Mkdir(filepath.Join(workDir, "config.audit.jsonl"), 0o750); err != nil {
+		t.Fatal(err)
+	}
+	if err = svc.Set("logging_config.level", "debug"); err == nil {
+		t.Fatalf("expected an error when the audit log cannot be written")
+	}
+	if current, _ := os.ReadFile(cfgPath); !bytes.Equal(current, original) {
+		t.Errorf("expected the config file to be unchanged")
+	}
+	if snap, _ := svc.Snapshot(); snap.Version != 1 {
+		t.Errorf("expected the change not to be published, got version %d", snap.Version)
+	}
+	if revisions, _ := os.ReadDir(filepath.Join(workDir, "config.history")); len(revisions) != 0 {
+		t.Errorf("expected the revisions to be removed, got %d files", len(revisions))
+	}
+}
+
+func TestHistory_saveFailure(t *testing.T) {
+	workDir := t.TempDir()
+	svc, err := New(WithWorkingDir(workDir))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	// A first change that cannot be saved leaves no history behind, not even the baseline recorded for it.
+	cfgPath := filepath.Join(workDir, "config.json")
+	if err = os.Remove(cfgPath); err != nil {
+		t.Fatal(err)
+	}
+	if err = os.MkdirAll(filepath.Join(cfgPath, "blocked"), 0o750); err != nil {
+		t.Fatal(err)
+	}
+	if err = svc.Set("logging_config.level", "debug"); err == nil {
+		t.Fatalf("expected an error when the config file cannot be written")
+	}
+	if revisions, _ := os.ReadDir(filepath.Join(workDir, "config.history")); len(revisions) != 0 {
+		t.Errorf("expected the revisions to be removed, got %d files", len(revisions))
+	}
+	if data, _ := os.ReadFile(filepath.Join(workDir, "config.audit.jsonl")); len(data) != 0 {
+		t.Errorf("expected an empty audit log, got %s", data)
+	}
+	if entries, err := svc.History(); err != nil || len(entries) != 0 {
+		t.Errorf("History() = %v, %v; want no entries", entries, err)
+	}
+}
+
+func TestHistory_customStore(t *testing.T) {
+	svc, err := New(WithWorkingDir(t.TempDir()), WithStore(&testStore{}))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	if err = svc.SetCallsignLookupEnabled(false); err != nil {
+		t.Fatalf("SetCallsignLookupEnabled() error = %v", err)
+	}
+	if _, err = svc.History(); err == nil {
+		t.Errorf("expected History() to fail for a custom store")
+	}
+	if err = svc.Rollback(1); err == nil {
+		t.Errorf("expected Rollback() to fail for a custom store")
+	}
+}
+
+// testStore keeps the configuration in memory.
+type testStore struct {
+	cfg   types.AppConfig
+	ext   Extensions
+	saved bool
+}
+
+func (m *testStore) Load() (types.AppConfig, Extensions, error) {
+	if !m.saved {
+		return types.AppConfig{}, Extensions{}, fs.ErrNotExist
+	}
+	return m.cfg, m.ext, nil
+}
+
+func (m *testStore) Save(cfg types.AppConfig, ext Extensions) error {
+	m.cfg, m.ext, m.saved = cfg, ext, true
+	return nil
+}
+
+func TestHistory_limit(t *testing.T) {
+	workDir := t.TempDir()
+	svc, err := New(WithWorkingDir(workDir), WithHistoryLimit(2))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	for _, level := range []string{"debug", "warn", "error"} {
+		if err = svc.Set("logging_config.level", level); err != nil {
+			t.Fatalf("Set() error = %v", err)
+		}
+	}
+
+	dir := filepath.Join(workDir, "config.history")
+	revisions, _ := os.ReadDir(dir)
+	if len(revisions) != 2 {
+		t.Fatalf("expected the newest 2 revisions to be kept, got %d files", len(revisions))
+	}
+	if err = svc.Rollback(2); !errors.Is(err, ErrRevisionNotFound) {
+		t.Errorf("expected a pruned revision to be gone, got %v", err)
+	}
+	if err = svc.Rollback(3); err != nil {
+		t.Errorf("Rollback() error = %v", err)
+	}
+	if entries, _ := svc.History(); len(entries) != 5 {
+		t.Errorf("expected every entry to stay in the log, got %d", len(entries))
+	}
+
+	// Revisions hold secrets in clear text, so only the owner can read them.
+	info, err := os.Stat(filepath.Join(dir, "5.json"))
+	if err != nil {
+		t.Fatalf("Stat() error = %v", err)
+	}
+	if perm := info.Mode().Perm(); perm != 0o600 {
+		t.Errorf("expected a revision to be written with 0600, got %o", perm)
+	}
+	if info, _ = os.Stat(dir); info.Mode().Perm() != 0o700 {
+		t.Errorf("expected the history directory to be 0700, got %o", info.Mode().Perm())
+	}
+
+	if _, err = New(WithWorkingDir(t.TempDir()), WithHistoryLimit(-2)); err == nil {
+		t.Errorf("expected a negative history limit to be rejected")
+	}
+
+	// UnlimitedHistory keeps every revision, and 0 keeps the default number.
+	for _, tc := range []struct {
+		limit    int
+		revision uint64
+		kept     int
+	}{
+		{limit: UnlimitedHistory, revision: DefaultHistoryLimit + 5, kept: DefaultHistoryLimit + 5},
+		{limit: 0, revision: DefaultHistoryLimit + 5, kept: DefaultHistoryLimit},
+	} {
+		dir := t.TempDir()
+		for n := uint64(1); n <= tc.revision; n++ {
+			if err = os.WriteFile(revisionFile(dir, n), nil, 0o600); err != nil {
+				t.Fatal(err)
+			}
+		}
+		pruneRevisions(dir, tc.revision, tc.limit)
+		if files, _ := os.ReadDir(dir); len(files) != tc.kept {
+			t.Errorf("limit %d: expected %d revisions kept, got %d", tc.limit, tc.kept, len(files))
+		}
+	}
+}
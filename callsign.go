@@ -0,0 +1,123 @@
+package config
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/Station-Manager/errors"
+	"github.com/Station-Manager/types"
+)
+
+// baseCallsignRe matches a home callsign: an ITU prefix (letters, letter+digit or digit+letters), one or more
+// call area digits, and a one to four letter suffix. This accepts regular (G4ABC, 2E0ABC, 3DA0RU) and
+// special-event (GB100XYZ) callsigns.
+var baseCallsignRe = regexp.MustCompile(`^(?:[A-Z]{1,2}|[A-Z][0-9]|[0-9][A-Z]{1,2})[0-9]+[A-Z]{1,4}$`)
+
+// callsignModifierRe matches a portable prefix (VK2, DL, KH6) or suffix (P, M, MM, QRP, 1) attached to a
+// callsign with a slash.
+var callsignModifierRe = regexp.MustCompile(`^[A-Z0-9]{1,4}$`)
+
+const maxCallsignLength = 20
+
+// NormalizeCallsign trims surrounding whitespace and upper-cases the callsign.
+func NormalizeCallsign(callsign string) string {
+	return strings.ToUpper(strings.TrimSpace(callsign))
+}
+
+// BaseCallsign returns the home callsign contained in a possibly portable callsign, e.g. G4ABC for
+// VK2/G4ABC/P. In PREFIX/CALL, where both parts can be callsigns, as in VP2E/G4ABC, the longer part is the
+// home callsign. The callsign is normalized before parsing. An error is returned if the callsign is not
+// syntactically valid, or if its home callsign is ambiguous.
+func BaseCallsign(callsign string) (string, error) {
+	const op errors.Op = "config.BaseCallsign"
+
+	callsign = NormalizeCallsign(callsign)
+	if callsign == "" {
+		return "", errors.New(op).Msg("callsign cannot be empty")
+	}
+	if len(callsign) > maxCallsignLength {
+		return "", errors.New(op).Msgf("callsign %q is longer than %d characters", callsign, maxCallsignLength)
+	}
+
+	parts := strings.Split(callsign, "/")
+	if len(parts) > 3 {
+		return "", errors.New(op).Msgf("callsign %q has too many portable designators", callsign)
+	}
+
+	// The home callsign is CALL, the middle of PREFIX/CALL/SUFFIX, or for two parts the one that is a home
+	// callsign. Portable prefixes such as VP2E are home callsigns too; then the longer part is the home callsign.
+	home := len(parts) / 2
+	if len(parts) == 2 {
+		first, second := baseCallsignRe.MatchString(parts[0]), baseCallsignRe.MatchString(parts[1])
+		switch {
+		case first && second && len(parts[0]) == len(parts[1]):
+			return "", errors.New(op).Msgf("callsign %q is ambiguous: both parts are home callsigns", callsign)
+		case first && second:
+			if len(parts[0]) > len(parts[1]) {
+				home = 0
+			}
+		case first:
+			home = 0
+		}
+	}
+
+	for i, part := range parts {
+		re := callsignModifierRe
+		if i == home {
+			re = baseCallsignRe
+		}
+		if !re.MatchString(part) {
+			return "", errors.New(op).Msgf("callsign %q is not valid", callsign)
+		}
+	}
+	return parts[home], nil
+}
+
+// ValidateCallsign checks that the callsign is syntactically valid. Portable prefixes and suffixes such as
+// VK2/G4ABC/P are accepted.
+func ValidateCallsign(callsign string) error {
+	const op errors.Op = "config.ValidateCallsign"
+	if _, err := BaseCallsign(callsign); err != nil {
+		return errors.New(op).Err(err)
+	}
+	return nil
+}
+
+// validateLoggingStation normalizes and validates the callsigns in the logging station section. Empty
+// callsigns are allowed, as they are only filled in once setup has been completed.
+func validateLoggingStation(station *types.LoggingStation, opts StationOptions) error {
+	const op errors.Op = "config.validateLoggingStation"
+
+	fields := []struct {
+		name  string
+		value *string
+	}{
+		{"owner_callsign", &station.OwnerCallsign},
+		{"station_callsign", &station.StationCallsign},
+		{"operator", &station.Operator},
+	}
+
+	for _, f := range fields {
+		*f.value = NormalizeCallsign(*f.value)
+		if *f.value == "" {
+			continue
+		}
+		if err := ValidateCallsign(*f.value); err != nil {
+			return errors.New(op).Err(err).Msgf("logging_station.%s: %s", f.name, ErrorMessage(err))
+		}
+	}
+
+	if opts.AllowCallsignMismatch || station.OwnerCallsign == "" || station.StationCallsign == "" {
+		return nil
+	}
+
+	ownerBase, _ := BaseCallsign(station.OwnerCallsign)
+	stationBase, _ := BaseCallsign(station.StationCallsign)
+	if ownerBase != stationBase {
+		return errors.New(op).Msgf(
+			"logging_station.station_callsign %q does not match owner_callsign %q; set station_options.allow_callsign_mismatch to override",
+			station.StationCallsign, station.OwnerCallsign)
+	}
+
+	return nil
+}
@@ -0,0 +1,76 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/Station-Manager/types"
+)
+
+func TestBaseCallsign(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    string
+		wantErr bool
+	}{
+		{in: "G4ABC", want: "G4ABC"},
+		{in: " g4abc ", want: "G4ABC"},
+		{in: "2E0ABC", want: "2E0ABC"},
+		{in: "3DA0RU", want: "3DA0RU"},
+		{in: "GB100XYZ", want: "GB100XYZ"},
+		{in: "G4ABC/P", want: "G4ABC"},
+		{in: "VK2/G4ABC", want: "G4ABC"},
+		{in: "VK2/G4ABC/P", want: "G4ABC"},
+		{in: "W1AW/KH6", want: "W1AW"},
+		// Portable prefixes that are home callsigns themselves.
+		{in: "VP2E/G4ABC", want: "G4ABC"},
+		{in: "VP2M/K1ABC", want: "K1ABC"},
+		{in: "K1ABC/VP2M", want: "K1ABC"},
+		{in: "VP2E/G4ABC/P", want: "G4ABC"},
+		{in: "", wantErr: true},
+		{in: "ABC", wantErr: true},
+		{in: "G4ABC/", wantErr: true},
+		{in: "G4ABC/P/VK2", wantErr: true},
+		{in: "G4ABC/M0XYZ", wantErr: true},
+		{in: "G4-ABC", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := BaseCallsign(tt.in)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("BaseCallsign(%q) expected error, got %q", tt.in, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("BaseCallsign(%q) error = %v", tt.in, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("BaseCallsign(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestValidateLoggingStation(t *testing.T) {
+	station := types.LoggingStation{OwnerCallsign: "g4abc", StationCallsign: "vk2/g4abc/p", Operator: "m0xyz"}
+	if err := validateLoggingStation(&station, StationOptions{}); err != nil {
+		t.Fatalf("validateLoggingStation() error = %v", err)
+	}
+	if station.OwnerCallsign != "G4ABC" || station.StationCallsign != "VK2/G4ABC/P" || station.Operator != "M0XYZ" {
+		t.Errorf("callsigns not normalized: %+v", station)
+	}
+
+	mismatch := types.LoggingStation{OwnerCallsign: "G4ABC", StationCallsign: "GB2XYZ"}
+	if err := validateLoggingStation(&mismatch, StationOptions{}); err == nil {
+		t.Errorf("expected error for station callsign not matching owner")
+	}
+	if err := validateLoggingStation(&mismatch, StationOptions{AllowCallsignMismatch: true}); err != nil {
+		t.Errorf("expected mismatch override to be honoured, got %v", err)
+	}
+
+	invalid := types.LoggingStation{Operator: "not a call"}
+	if err := validateLoggingStation(&invalid, StationOptions{}); err == nil {
+		t.Errorf("expected error for invalid operator callsign")
+	}
+}
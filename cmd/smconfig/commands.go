@@ -0,0 +1,399 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/Station-Manager/config"
+	"github.com/Station-Manager/config/internal/jsonpath"
+	"github.com/Station-Manager/types"
+	"github.com/goccy/go-json"
+)
+
+// open loads the configuration file. It is never generated here; only init creates a file. Changes are recorded
+// in the audit log as made by the user running the tool.
+func (c *cli) open(opts ...config.Option) (*config.Service, error) {
+	opts = append([]config.Option{
+		config.WithWorkingDir(c.dir), config.WithFile(c.file), config.WithoutFileCreation(), config.WithActor("", config.SourceCLI),
+	}, opts...)
+	return config.New(opts...)
+}
+
+// print writes v to standard output as indented JSON.
+func (c *cli) print(v any) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(c.stdout, "%s\n", data)
+	return err
+}
+
+// flags returns a flag set for a subcommand that reports errors to standard error.
+func (c *cli) flags(name string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(c.stderr)
+	return fs
+}
+
+func (c *cli) init(args []string) error {
+	fs := c.flags("init")
+	profile := fs.String("profile", config.ProfileDesktop, "profile of the generated configuration: desktop or server")
+	force := fs.Bool("force", false, "replace an existing file, keeping it as a .bak file")
+	if err := fs.Parse(args); err != nil {
+		return usagef("%v", err)
+	}
+	if fs.NArg() != 0 {
+		return usagef("unexpected arguments %v", fs.Args())
+	}
+
+	var backup string
+	if _, err := os.Stat(c.file); err == nil {
+		if !*force {
+			return fmt.Errorf("%s already exists; use -force to replace it", c.file)
+		}
+		backup = c.file + ".bak"
+		if err = os.Rename(c.file, backup); err != nil {
+			return err
+		}
+	}
+
+	if _, err := config.New(config.WithWorkingDir(c.dir), config.WithFile(c.file), config.WithDefaults(*profile)); err != nil {
+		return err
+	}
+	return c.print(struct {
+		File    string `json:"file"`
+		Profile string `json:"profile"`
+		Backup  string `json:"backup,omitempty"`
+	}{c.file, *profile, backup})
+}
+
+func (c *cli) get(args []string) error {
+	if len(args) != 1 {
+		return usagef("usage: get <path>")
+	}
+	if _, err := jsonpath.Parse(args[0]); err != nil {
+		return usagef("%v", err)
+	}
+
+	svc, err := c.open(config.WithReadOnly())
+	if err != nil {
+		return err
+	}
+	value, err := svc.Get(args[0])
+	if err != nil {
+		return err
+	}
+	return c.print(value)
+}
+
+func (c *cli) set(args []string) error {
+	if len(args) != 2 {
+		return usagef("usage: set <path> <value>")
+	}
+	path, err := jsonpath.Parse(args[0])
+	if err != nil {
+		return usagef("%v", err)
+	}
+
+	svc, err := c.open()
+	if err != nil {
+		return err
+	}
+	// The service parses the value as the type of the field it replaces.
+	if err = svc.Set(args[0], args[1]); err != nil {
+		return err
+	}
+	value, err := svc.Get(args[0])
+	if err != nil {
+		return err
+	}
+	return c.print(struct {
+		Path  string `json:"path"`
+		Value any    `json:"value"`
+	}{path.String(), value})
+}
+
+func (c *cli) unset(args []string) error {
+	if len(args) != 1 {
+		return usagef("usage: unset <path>")
+	}
+	path, err := jsonpath.Parse(args[0])
+	if err != nil {
+		return usagef("%v", err)
+	}
+
+	svc, err := c.open()
+	if err != nil {
+		return err
+	}
+	if err = svc.Unset(args[0]); err != nil {
+		return err
+	}
+	return c.print(struct {
+		Path    string `json:"path"`
+		Removed bool   `json:"removed"`
+	}{path.String(), true})
+}
+
+func (c *cli) validate(args []string) error {
+	if len(args) != 0 {
+		return usagef("usage: validate")
+	}
+
+	result := struct {
+		Valid   bool   `json:"valid"`
+		File    string `json:"file"`
+		Profile string `json:"profile,omitempty"`
+		Error   string `json:"error,omitempty"`
+	}{File: c.file}
+
+	svc, err := c.open(config.WithReadOnly())
+	if err != nil {
+		result.Error = config.ErrorMessage(err)
+		if err = c.print(result); err != nil {
+			return err
+		}
+		return errInvalid{}
+	}
+	result.Valid = true
+	result.Profile, _ = svc.Profile()
+	return c.print(result)
+}
+
+func (c *cli) show(args []string) error {
+	fs := c.flags("show")
+	redacted := fs.Bool("redacted", false, "mask passwords, API keys and tokens")
+	if err := fs.Parse(args); err != nil {
+		return usagef("%v", err)
+	}
+	if fs.NArg() != 0 {
+		return usagef("unexpected arguments %v", fs.Args())
+	}
+
+	svc, err := c.open(config.WithReadOnly())
+	if err != nil {
+		return err
+	}
+	snap, err := svc.Snapshot()
+	if err != nil {
+		return err
+	}
+	if *redacted {
+		snap = snap.Redacted()
+	}
+	doc, err := document(snap)
+	if err != nil {
+		return err
+	}
+	return c.print(doc)
+}
+
+func (c *cli) diff(args []string) error {
+	fs := c.flags("diff")
+	against := fs.String("against", "", "configuration file to compare with (default: the defaults of the file's profile)")
+	if err := fs.Parse(args); err != nil {
+		return usagef("%v", err)
+	}
+	if fs.NArg() != 0 {
+		return usagef("unexpected arguments %v", fs.Args())
+	}
+
+	svc, err := c.open(config.WithReadOnly())
+	if err != nil {
+		return err
+	}
+	if *against != "" {
+		file, err := filepath.Abs(*against)
+		if err != nil {
+			return err
+		}
+		changes, err := svc.DiffFile(file)
+		if err != nil {
+			return err
+		}
+		return c.print(orEmpty(changes))
+	}
+
+	current, err := svc.Snapshot()
+	if err != nil {
+		return err
+	}
+	profile, _ := svc.Profile()
+	cfg, ext := config.DefaultConfig(profile)
+	defaults, err := config.New(config.WithWorkingDir(c.dir), config.WithStore(&staticStore{cfg: cfg, ext: ext}))
+	if err != nil {
+		return err
+	}
+	base, err := defaults.Snapshot()
+	if err != nil {
+		return err
+	}
+	changes := config.DiffSnapshots(base, current)
+	return c.print(orEmpty(changes))
+}
+
+// orEmpty returns changes, or an empty list rather than nil, so that no changes print as [].
+func orEmpty(changes []config.Change) []config.Change {
+	if changes == nil {
+		return []config.Change{}
+	}
+	return changes
+}
+
+func (c *cli) migrate(args []string) error {
+	if len(args) != 0 {
+		return usagef("usage: migrate")
+	}
+
+	before, err := os.ReadFile(c.file)
+	if err != nil {
+		return err
+	}
+	svc, err := c.open()
+	if err != nil {
+		return err
+	}
+	snap, err := svc.Snapshot()
+	if err != nil {
+		return err
+	}
+
+	backup := c.file + ".bak"
+	if err = os.WriteFile(backup, before, 0o640); err != nil {
+		return err
+	}
+	// Saving writes the loaded configuration, with defaults filled in and values normalized, in the current
+	// format.
+	if err = svc.UpdateAppConfig(snap.AppConfig); err != nil {
+		return err
+	}
+	after, err := os.ReadFile(c.file)
+	if err != nil {
+		return err
+	}
+
+	result := struct {
+		Changed bool   `json:"changed"`
+		Backup  string `json:"backup,omitempty"`
+	}{Changed: !bytes.Equal(before, after)}
+	if result.Changed {
+		result.Backup = backup
+	} else if err = os.Remove(backup); err != nil {
+		return err
+	}
+	return c.print(result)
+}
+
+func (c *cli) rig(args []string) error {
+	if len(args) == 0 || args[0] != "add" {
+		return usagef("usage: rig add -model MODEL [-name NAME] [-port PORT] (models: %s)", strings.Join(config.RigModels(), ", "))
+	}
+	fs := c.flags("rig add")
+	model := fs.String("model", "", "rig model: "+strings.Join(config.RigModels(), ", "))
+	name := fs.String("name", "", "rig name (default: the model's name)")
+	port := fs.String("port", "", "serial port (default: the model's default port)")
+	if err := fs.Parse(args[1:]); err != nil {
+		return usagef("%v", err)
+	}
+	if *model == "" || fs.NArg() != 0 {
+		return usagef("usage: rig add -model MODEL [-name NAME] [-port PORT]")
+	}
+
+	svc, err := c.open()
+	if err != nil {
+		return err
+	}
+	rig, err := svc.AddRigFromModel(*model, *name, *port)
+	if err != nil {
+		return err
+	}
+	return c.print(struct {
+		ID       int64  `json:"id"`
+		Name     string `json:"name"`
+		Model    string `json:"model"`
+		PortName string `json:"port_name"`
+	}{rig.ID, rig.Name, rig.Model, rig.SerialConfig.PortName})
+}
+
+// document returns the snapshot as a single JSON object, as it appears in config.json.
+func document(snap config.Snapshot) (map[string]any, error) {
+	doc := map[string]any{}
+	for _, part := range []any{snap.AppConfig, snap.Extensions} {
+		data, err := json.Marshal(part)
+		if err != nil {
+			return nil, err
+		}
+		dec := json.NewDecoder(bytes.NewReader(data))
+		dec.UseNumber()
+		if err = dec.Decode(&doc); err != nil {
+			return nil, err
+		}
+	}
+	return doc, nil
+}
+
+// staticStore holds a configuration in memory, for comparing with the defaults.
+type staticStore struct {
+	cfg types.AppConfig
+	ext config.Extensions
+}
+
+func (s *staticStore) Load() (types.AppConfig, config.Extensions, error) {
+	return s.cfg, s.ext, nil
+}
+
+func (s *staticStore) Save(cfg types.AppConfig, ext config.Extensions) error {
+	s.cfg, s.ext = cfg, ext
+	return nil
+}
+
+func (c *cli) history(args []string) error {
+	if len(args) != 0 {
+		return usagef("usage: history")
+	}
+
+	svc, err := c.open(config.WithReadOnly())
+	if err != nil {
+		return err
+	}
+	entries, err := svc.History()
+	if err != nil {
+		return err
+	}
+	if entries == nil {
+		entries = []config.AuditEntry{}
+	}
+	return c.print(entries)
+}
+
+func (c *cli) rollback(args []string) error {
+	if len(args) != 1 {
+		return usagef("usage: rollback <revision>")
+	}
+	revision, err := strconv.ParseUint(args[0], 10, 64)
+	if err != nil {
+		return usagef("invalid revision %q", args[0])
+	}
+
+	svc, err := c.open()
+	if err != nil {
+		return err
+	}
+	if err = svc.Rollback(revision); err != nil {
+		return err
+	}
+	entries, err := svc.History()
+	if err != nil {
+		return err
+	}
+	return c.print(struct {
+		RolledBackTo uint64 `json:"rolled_back_to"`
+		Revision     uint64 `json:"revision"`
+	}{revision, entries[len(entries)-1].Revision})
+}
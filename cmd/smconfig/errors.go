@@ -0,0 +1,17 @@
+package main
+
+import "fmt"
+
+// usageError is reported with exit status 2.
+type usageError struct{ msg string }
+
+func (e usageError) Error() string { return e.msg }
+
+func usagef(format string, args ...any) error {
+	return usageError{fmt.Sprintf(format, args...)}
+}
+
+// errInvalid is returned by validate once the result has been printed.
+type errInvalid struct{}
+
+func (errInvalid) Error() string { return "configuration is invalid" }
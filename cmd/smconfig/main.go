@@ -0,0 +1,139 @@
+// Command smconfig inspects and edits a Station Manager config.json.
+//
+// Usage:
+//
+//	smconfig [-dir DIR] [-file FILE] <command> [arguments]
+//
+// Commands:
+//
+//	init [-profile desktop|server] [-force]   generate a default configuration
+//	get <path>                                print the value at path
+//	set <path> <value>                        set the value at path
+//	unset <path>                              remove the value at path, restoring its default
+//	validate                                  check the configuration
+//	show [-redacted]                          print the whole configuration
+//	diff [-against FILE]                      compare with the profile defaults or another file
+//	migrate                                   rewrite the file in the current format
+//	rig add -model MODEL [-name NAME] [-port PORT]
+//	history                                   list the recorded revisions of the file
+//	rollback <revision>                       restore a recorded revision
+//
+// Paths use the syntax of config.Service.Get, for example rig_configs[id=1].serial_config.port_name. Values
+// given to set are parsed as the type of the field they replace; objects and lists are given as JSON.
+//
+// Results are written to standard output as JSON; errors are written to standard error. The exit status is 0
+// on success, 1 if the command failed or the configuration is invalid, and 2 for a usage error.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/Station-Manager/config"
+	"github.com/Station-Manager/utils"
+)
+
+const configFileName = "config.json"
+
+// Exit statuses.
+const (
+	exitOK    = 0
+	exitError = 1
+	exitUsage = 2
+)
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr))
+}
+
+// cli holds the global flags and output streams shared by the commands.
+type cli struct {
+	dir    string
+	file   string
+	stdout io.Writer
+	stderr io.Writer
+}
+
+type command func(c *cli, args []string) error
+
+var commands = map[string]command{
+	"init":     (*cli).init,
+	"get":      (*cli).get,
+	"set":      (*cli).set,
+	"unset":    (*cli).unset,
+	"validate": (*cli).validate,
+	"show":     (*cli).show,
+	"diff":     (*cli).diff,
+	"migrate":  (*cli).migrate,
+	"rig":      (*cli).rig,
+	"history":  (*cli).history,
+	"rollback": (*cli).rollback,
+}
+
+func run(args []string, stdout, stderr io.Writer) int {
+	c := &cli{stdout: stdout, stderr: stderr}
+
+	fs := flag.NewFlagSet("smconfig", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.StringVar(&c.dir, "dir", "", "working directory holding config.json (default $SM_WORKING_DIR or the current directory)")
+	fs.StringVar(&c.file, "file", "", "configuration file (default config.json in the working directory)")
+	fs.Usage = func() {
+		_, _ = fmt.Fprintln(stderr, "usage: smconfig [-dir DIR] [-file FILE] <init|get|set|unset|validate|show|diff|migrate|rig|history|rollback> [arguments]")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return exitUsage
+	}
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return exitUsage
+	}
+
+	cmd, ok := commands[fs.Arg(0)]
+	if !ok {
+		_, _ = fmt.Fprintf(stderr, "smconfig: unknown command %q\n", fs.Arg(0))
+		fs.Usage()
+		return exitUsage
+	}
+
+	if err := c.resolvePaths(); err != nil {
+		_, _ = fmt.Fprintf(stderr, "smconfig: %v\n", err)
+		return exitError
+	}
+
+	err := cmd(c, fs.Args()[1:])
+	switch err.(type) {
+	case nil:
+		return exitOK
+	case usageError:
+		_, _ = fmt.Fprintf(stderr, "smconfig %s: %v\n", fs.Arg(0), err)
+		return exitUsage
+	case errInvalid:
+		return exitError
+	default:
+		_, _ = fmt.Fprintf(stderr, "smconfig %s: %s\n", fs.Arg(0), config.ErrorMessage(err))
+		return exitError
+	}
+}
+
+// resolvePaths fills in the working directory and configuration file.
+func (c *cli) resolvePaths() error {
+	if c.dir == "" {
+		c.dir = os.Getenv(utils.EnvSmWorkingDir)
+	}
+	if c.dir == "" {
+		c.dir = "."
+	}
+	dir, err := filepath.Abs(c.dir)
+	if err != nil {
+		return err
+	}
+	c.dir = dir
+	if c.file == "" {
+		c.file = filepath.Join(c.dir, configFileName)
+	}
+	return nil
+}
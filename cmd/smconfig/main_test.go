@@ -0,0 +1,193 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Station-Manager/config"
+)
+
+// smconfig runs the command against dir and returns its exit status and output.
+func smconfig(t *testing.T, dir string, args ...string) (int, string, string) {
+	t.Helper()
+	var stdout, stderr bytes.Buffer
+	code := run(append([]string{"-dir", dir}, args...), &stdout, &stderr)
+	return code, stdout.String(), stderr.String()
+}
+
+func TestRun_usage(t *testing.T) {
+	dir := t.TempDir()
+	cases := [][]string{
+		{},
+		{"bogus"},
+		{"get"},
+		{"set", "logging_config.level"},
+		{"get", "rig_configs[x"},
+		{"rig", "remove"},
+		{"show", "-bogus"},
+	}
+	for _, args := range cases {
+		t.Run(strings.Join(args, " "), func(t *testing.T) {
+			if code, _, _ := smconfig(t, dir, args...); code != exitUsage {
+				t.Errorf("expected exit status %d, got %d", exitUsage, code)
+			}
+		})
+	}
+}
+
+func TestRun_editing(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, configFileName)
+
+	if code, _, stderr := smconfig(t, dir, "validate"); code != exitError || stderr != "" {
+		t.Errorf("validate without a file: exit %d, stderr %q", code, stderr)
+	}
+	if code, _, stderr := smconfig(t, dir, "init"); code != exitOK {
+		t.Fatalf("init: exit %d: %s", code, stderr)
+	}
+	if code, _, _ := smconfig(t, dir, "init"); code != exitError {
+		t.Errorf("expected init to refuse to replace an existing file")
+	}
+	if code, stdout, stderr := smconfig(t, dir, "init", "-force"); code != exitOK || !strings.Contains(stdout, ".bak") {
+		t.Errorf("init -force: exit %d, stdout %q, stderr %q", code, stdout, stderr)
+	}
+
+	if code, stdout, stderr := smconfig(t, dir, "set", "logging_config.level", "debug"); code != exitOK {
+		t.Fatalf("set: exit %d, stdout %q, stderr %q", code, stdout, stderr)
+	}
+	if code, stdout, _ := smconfig(t, dir, "get", "LoggingConfig.Level"); code != exitOK || strings.TrimSpace(stdout) != `"debug"` {
+		t.Errorf("get: exit %d, stdout %q", code, stdout)
+	}
+	if code, stdout, _ := smconfig(t, dir, "get", "rig_configs[id=1].serial_config.port_name"); code != exitOK || strings.TrimSpace(stdout) != `"/dev/ttyUSB0"` {
+		t.Errorf("get with a selector: exit %d, stdout %q", code, stdout)
+	}
+
+	before, _ := os.ReadFile(file)
+	code, _, stderr := smconfig(t, dir, "set", "logging_config.level", "")
+	if code != exitError || !strings.Contains(stderr, "logging level must be set") {
+		t.Errorf("expected an invalid change to be rejected: exit %d, stderr %q", code, stderr)
+	}
+	if after, _ := os.ReadFile(file); !bytes.Equal(before, after) {
+		t.Errorf("a rejected change modified the file")
+	}
+
+	if code, stdout, stderr := smconfig(t, dir, "rig", "add", "-model", "ftdx10", "-name", "Portable", "-port", "COM3"); code != exitOK || !strings.Contains(stdout, `"id": 2`) {
+		t.Errorf("rig add: exit %d, stdout %q, stderr %q", code, stdout, stderr)
+	}
+	if code, _, stderr := smconfig(t, dir, "unset", "logging_station"); code != exitOK {
+		t.Errorf("unset: exit %d, stderr %q", code, stderr)
+	}
+
+	code, stdout, _ := smconfig(t, dir, "validate")
+	var result struct {
+		Valid   bool   `json:"valid"`
+		Profile string `json:"profile"`
+	}
+	if err := json.Unmarshal([]byte(stdout), &result); err != nil || code != exitOK || !result.Valid || result.Profile != "desktop" {
+		t.Errorf("validate: exit %d, stdout %q", code, stdout)
+	}
+}
+
+func TestRun_showAndDiff(t *testing.T) {
+	dir := t.TempDir()
+	if code, _, stderr := smconfig(t, dir, "init"); code != exitOK {
+		t.Fatalf("init: exit %d: %s", code, stderr)
+	}
+	if code, _, stderr := smconfig(t, dir, "set", "email_configs.password", "hunter2"); code != exitOK {
+		t.Fatalf("set: exit %d: %s", code, stderr)
+	}
+
+	if _, stdout, _ := smconfig(t, dir, "show"); !strings.Contains(stdout, "hunter2") {
+		t.Errorf("expected show to print the password")
+	}
+	if _, stdout, _ := smconfig(t, dir, "show", "-redacted"); strings.Contains(stdout, "hunter2") {
+		t.Errorf("expected show -redacted to mask the password")
+	}
+
+	code, stdout, stderr := smconfig(t, dir, "diff")
+	if code != exitOK {
+		t.Fatalf("diff: exit %d: %s", code, stderr)
+	}
+	var changes []config.Change
+	if err := json.Unmarshal([]byte(stdout), &changes); err != nil {
+		t.Fatalf("diff output: %v", err)
+	}
+	if len(changes) != 1 || changes[0].Path != "email_configs.password" || changes[0].Kind != config.ChangeModified || changes[0].To == "hunter2" {
+		t.Errorf("unexpected changes: %+v", changes)
+	}
+
+	if code, stdout, _ = smconfig(t, dir, "diff", "-against", filepath.Join(dir, configFileName)); code != exitOK || strings.TrimSpace(stdout) != "[]" {
+		t.Errorf("diff against itself: exit %d, stdout %q", code, stdout)
+	}
+}
+
+func TestRun_migrate(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, configFileName)
+	if code, _, stderr := smconfig(t, dir, "init"); code != exitOK {
+		t.Fatalf("init: exit %d: %s", code, stderr)
+	}
+	// Rewrite the file compactly, as an older or hand-edited file might be.
+	data, _ := os.ReadFile(file)
+	var compact bytes.Buffer
+	if err := json.Compact(&compact, data); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(file, compact.Bytes(), 0o640); err != nil {
+		t.Fatal(err)
+	}
+
+	code, stdout, stderr := smconfig(t, dir, "migrate")
+	if code != exitOK || !strings.Contains(stdout, `"changed": true`) {
+		t.Fatalf("migrate: exit %d, stdout %q, stderr %q", code, stdout, stderr)
+	}
+	if _, err := os.Stat(file + ".bak"); err != nil {
+		t.Errorf("expected a backup of the original file: %v", err)
+	}
+
+	if code, stdout, _ = smconfig(t, dir, "migrate"); code != exitOK || !strings.Contains(stdout, `"changed": false`) {
+		t.Errorf("second migrate: exit %d, stdout %q", code, stdout)
+	}
+}
+
+func TestRun_history(t *testing.T) {
+	dir := t.TempDir()
+	if code, _, stderr := smconfig(t, dir, "init"); code != exitOK {
+		t.Fatalf("init: exit %d: %s", code, stderr)
+	}
+	if code, _, stderr := smconfig(t, dir, "set", "logging_config.level", "debug"); code != exitOK {
+		t.Fatalf("set: exit %d: %s", code, stderr)
+	}
+	if code, _, stderr := smconfig(t, dir, "unset", "logging_config.level"); code != exitError || stderr == "" {
+		t.Errorf("expected unsetting a required value to be rejected: exit %d", code)
+	}
+
+	code, stdout, stderr := smconfig(t, dir, "history")
+	if code != exitOK {
+		t.Fatalf("history: exit %d: %s", code, stderr)
+	}
+	var entries []config.AuditEntry
+	if err := json.Unmarshal([]byte(stdout), &entries); err != nil {
+		t.Fatalf("history output: %v", err)
+	}
+	if len(entries) != 2 || entries[1].Source != config.SourceCLI || entries[1].Changes[0].Path != "logging_config.level" {
+		t.Fatalf("unexpected history: %s", stdout)
+	}
+
+	if code, _, stderr = smconfig(t, dir, "rollback", "1"); code != exitOK {
+		t.Fatalf("rollback: exit %d: %s", code, stderr)
+	}
+	if _, stdout, _ = smconfig(t, dir, "get", "logging_config.level"); strings.TrimSpace(stdout) != `"info"` {
+		t.Errorf("expected the rolled back level, got %s", stdout)
+	}
+	if code, _, _ = smconfig(t, dir, "rollback", "9"); code != exitError {
+		t.Errorf("expected rolling back to an unknown revision to fail, got exit %d", code)
+	}
+	if code, _, _ = smconfig(t, dir, "rollback", "x"); code != exitUsage {
+		t.Errorf("expected a usage error for an invalid revision, got exit %d", code)
+	}
+}
@@ -0,0 +1,291 @@
+package configtest
+
+import (
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/Station-Manager/config"
+	"github.com/Station-Manager/errors"
+	"github.com/Station-Manager/types"
+)
+
+// Builder assembles a configuration for a Fake, starting from the defaults of a profile.
+//
+//	fake := configtest.NewBuilder(config.ProfileDesktop).
+//		WithRig(configtest.Rig(1).Port("/dev/ttyUSB1")).
+//		WithListener(configtest.ListenerFromTemplate(config.N1mmListenerTemplate)).
+//		WithForwarder(configtest.Forwarder(config.QrzForwardingServiceName).APIKey("key").Enabled()).
+//		MustBuild(t)
+type Builder struct {
+	cfg        types.AppConfig
+	ext        config.Extensions
+	workingDir string
+	err        error
+}
+
+// NewBuilder returns a Builder holding the default configuration of profile, config.ProfileDesktop or
+// config.ProfileServer.
+func NewBuilder(profile string) *Builder {
+	cfg, ext := config.DefaultConfig(profile)
+	return &Builder{cfg: cfg, ext: ext, workingDir: os.TempDir()}
+}
+
+// WorkingDir sets the directory that relative TLS certificate paths are resolved against. The default is
+// os.TempDir().
+func (b *Builder) WorkingDir(dir string) *Builder {
+	b.workingDir = dir
+	return b
+}
+
+// WithRig adds the rig, replacing any rig with the same ID.
+func (b *Builder) WithRig(rig *RigBuilder) *Builder {
+	for i := range b.cfg.RigConfigs {
+		if b.cfg.RigConfigs[i].ID == rig.cfg.ID {
+			b.cfg.RigConfigs[i] = rig.cfg
+			return b
+		}
+	}
+	b.cfg.RigConfigs = append(b.cfg.RigConfigs, rig.cfg)
+	return b
+}
+
+// WithListener adds the listener, replacing any listener with the same name.
+func (b *Builder) WithListener(listener *ListenerBuilder) *Builder {
+	b.keep(listener.err)
+	for i := range b.cfg.ListenerConfigs {
+		if b.cfg.ListenerConfigs[i].Name == listener.cfg.Name {
+			b.cfg.ListenerConfigs[i] = listener.cfg
+			return b
+		}
+	}
+	b.cfg.ListenerConfigs = append(b.cfg.ListenerConfigs, listener.cfg)
+	return b
+}
+
+// WithForwarder adds the forwarder, replacing any forwarder with the same name.
+func (b *Builder) WithForwarder(forwarder *ForwarderBuilder) *Builder {
+	for i := range b.cfg.ForwardingConfigs {
+		if b.cfg.ForwardingConfigs[i].Name == forwarder.cfg.Name {
+			b.cfg.ForwardingConfigs[i] = forwarder.cfg
+			return b
+		}
+	}
+	b.cfg.ForwardingConfigs = append(b.cfg.ForwardingConfigs, forwarder.cfg)
+	return b
+}
+
+// Configure calls fn to change any other part of the configuration.
+func (b *Builder) Configure(fn func(cfg *types.AppConfig, ext *config.Extensions)) *Builder {
+	fn(&b.cfg, &b.ext)
+	return b
+}
+
+// Config returns the configuration built so far.
+func (b *Builder) Config() (types.AppConfig, config.Extensions) {
+	return b.cfg, b.ext
+}
+
+// Build returns an initialized Fake holding the configuration. The error reports an unknown listener template
+// or a configuration that fails validation.
+func (b *Builder) Build() (*Fake, error) {
+	const op errors.Op = "configtest.Builder.Build"
+	if b.err != nil {
+		return nil, errors.New(op).Err(b.err).Msg("invalid fake configuration")
+	}
+	fake, err := newFake(b.cfg, b.ext, b.workingDir)
+	if err != nil {
+		return nil, errors.New(op).Err(err)
+	}
+	return fake, nil
+}
+
+// MustBuild is like Build but fails the test on error.
+func (b *Builder) MustBuild(tb testing.TB) *Fake {
+	tb.Helper()
+	fake, err := b.Build()
+	if err != nil {
+		tb.Fatalf("configtest: %v", err)
+	}
+	return fake
+}
+
+// keep records the first error reported by a nested builder.
+func (b *Builder) keep(err error) {
+	if b.err == nil {
+		b.err = err
+	}
+}
+
+// RigBuilder builds a types.RigConfig.
+type RigBuilder struct {
+	cfg types.RigConfig
+}
+
+// Rig returns a RigBuilder for a copy of the default desktop rig with the given ID.
+func Rig(id int64) *RigBuilder {
+	defaults, _ := config.DefaultConfig(config.ProfileDesktop)
+	cfg := defaults.RigConfigs[0]
+	cfg.ID = id
+	cfg.Name = fmt.Sprintf("Rig %d", id)
+	return &RigBuilder{cfg: cfg}
+}
+
+// Name sets the rig name.
+func (r *RigBuilder) Name(name string) *RigBuilder {
+	r.cfg.Name = name
+	return r
+}
+
+// Model sets the rig model.
+func (r *RigBuilder) Model(model string) *RigBuilder {
+	r.cfg.Model = model
+	return r
+}
+
+// Port sets the serial port name.
+func (r *RigBuilder) Port(portName string) *RigBuilder {
+	r.cfg.SerialConfig.PortName = portName
+	return r
+}
+
+// BaudRate sets the serial baud rate.
+func (r *RigBuilder) BaudRate(baudRate int) *RigBuilder {
+	r.cfg.SerialConfig.BaudRate = baudRate
+	return r
+}
+
+// Configure calls fn to change any other part of the rig.
+func (r *RigBuilder) Configure(fn func(cfg *types.RigConfig)) *RigBuilder {
+	fn(&r.cfg)
+	return r
+}
+
+// Config returns the rig built so far.
+func (r *RigBuilder) Config() types.RigConfig {
+	return r.cfg
+}
+
+// ListenerBuilder builds a types.ListenerConfig.
+type ListenerBuilder struct {
+	cfg types.ListenerConfig
+	err error
+}
+
+// Listener returns a ListenerBuilder for an enabled UDP listener on localhost with no handler. Set the port
+// with Port.
+func Listener(name string) *ListenerBuilder {
+	return &ListenerBuilder{cfg: types.ListenerConfig{
+		Name:       name,
+		Enabled:    true,
+		Host:       "localhost",
+		Protocol:   config.ProtocolUDP,
+		BufferSize: 4096,
+	}}
+}
+
+// ListenerFromTemplate returns a ListenerBuilder for the named built-in template. An unknown template is
+// reported by Builder.Build.
+func ListenerFromTemplate(templateName string) *ListenerBuilder {
+	cfg, err := config.ListenerTemplate(templateName)
+	return &ListenerBuilder{cfg: cfg, err: err}
+}
+
+// Name sets the listener name.
+func (l *ListenerBuilder) Name(name string) *ListenerBuilder {
+	l.cfg.Name = name
+	return l
+}
+
+// Host sets the address the listener binds.
+func (l *ListenerBuilder) Host(host string) *ListenerBuilder {
+	l.cfg.Host = host
+	return l
+}
+
+// Port sets the port the listener binds.
+func (l *ListenerBuilder) Port(port int) *ListenerBuilder {
+	l.cfg.Port = port
+	return l
+}
+
+// Protocol sets the listener protocol, such as config.ProtocolTCP.
+func (l *ListenerBuilder) Protocol(protocol string) *ListenerBuilder {
+	l.cfg.Protocol = protocol
+	return l
+}
+
+// Handler sets the packet handler and its configuration.
+func (l *ListenerBuilder) Handler(name string, handlerCfg map[string]any) *ListenerBuilder {
+	l.cfg.Handler = name
+	l.cfg.HandlerConfig = handlerCfg
+	return l
+}
+
+// Disabled disables the listener.
+func (l *ListenerBuilder) Disabled() *ListenerBuilder {
+	l.cfg.Enabled = false
+	return l
+}
+
+// Configure calls fn to change any other part of the listener.
+func (l *ListenerBuilder) Configure(fn func(cfg *types.ListenerConfig)) *ListenerBuilder {
+	fn(&l.cfg)
+	return l
+}
+
+// Config returns the listener built so far.
+func (l *ListenerBuilder) Config() types.ListenerConfig {
+	return l.cfg
+}
+
+// ForwarderBuilder builds a types.ForwarderConfig.
+type ForwarderBuilder struct {
+	cfg types.ForwarderConfig
+}
+
+// Forwarder returns a ForwarderBuilder for the provider defaults of the named forwarder, which start disabled.
+// Unknown names start empty and are rejected by validation.
+func Forwarder(name string) *ForwarderBuilder {
+	cfg, err := config.ForwarderProviderDefaults(name)
+	if err != nil {
+		cfg = types.ForwarderConfig{Name: name}
+	}
+	return &ForwarderBuilder{cfg: cfg}
+}
+
+// Enabled enables the forwarder.
+func (f *ForwarderBuilder) Enabled() *ForwarderBuilder {
+	f.cfg.Enabled = true
+	return f
+}
+
+// URL sets the upload URL.
+func (f *ForwarderBuilder) URL(url string) *ForwarderBuilder {
+	f.cfg.URL = url
+	return f
+}
+
+// APIKey sets the API key.
+func (f *ForwarderBuilder) APIKey(key string) *ForwarderBuilder {
+	f.cfg.APIKey = key
+	return f
+}
+
+// Credentials sets the username and password.
+func (f *ForwarderBuilder) Credentials(username, password string) *ForwarderBuilder {
+	f.cfg.Username = username
+	f.cfg.Password = password
+	return f
+}
+
+// Configure calls fn to change any other part of the forwarder.
+func (f *ForwarderBuilder) Configure(fn func(cfg *types.ForwarderConfig)) *ForwarderBuilder {
+	fn(&f.cfg)
+	return f
+}
+
+// Config returns the forwarder built so far.
+func (f *ForwarderBuilder) Config() types.ForwarderConfig {
+	return f.cfg
+}
@@ -0,0 +1,160 @@
+// Package configtest provides an in-memory implementation of config.Provider for tests in packages that
+// depend on the config service, so they need not write config.json to a temporary directory.
+package configtest
+
+import (
+	"context"
+	stderr "errors"
+	"io/fs"
+	"os"
+	"sync"
+
+	"github.com/Station-Manager/config"
+	"github.com/Station-Manager/errors"
+	"github.com/Station-Manager/types"
+	"github.com/goccy/go-json"
+)
+
+// ErrSimulatedFailure is returned by the change that follows a call to Fake.FailNextSave with a nil error.
+var ErrSimulatedFailure = stderr.New("simulated configuration failure")
+
+// Fake is a config.Provider that keeps its configuration in memory. It is a config.Service with an in-memory
+// store, so getters, setters, validation and snapshots behave exactly as they do in production.
+type Fake struct {
+	*config.Service
+	store *memoryStore
+}
+
+var _ config.Provider = (*Fake)(nil)
+
+// New returns an initialized Fake holding cfg and ext. The error reports a configuration that would be
+// rejected by config.Service.Initialize. Use NewBuilder to start from the defaults of a profile. Relative TLS
+// certificate paths are resolved against os.TempDir().
+func New(cfg types.AppConfig, ext config.Extensions) (*Fake, error) {
+	return newFake(cfg, ext, os.TempDir())
+}
+
+func newFake(cfg types.AppConfig, ext config.Extensions, workingDir string) (*Fake, error) {
+	const op errors.Op = "configtest.New"
+
+	store := &memoryStore{}
+	if err := store.Save(cfg, ext); err != nil {
+		return nil, errors.New(op).Err(err)
+	}
+	svc, err := config.New(config.WithWorkingDir(workingDir), config.WithStore(store))
+	if err != nil {
+		return nil, errors.New(op).Err(err)
+	}
+	return &Fake{Service: svc, store: store}, nil
+}
+
+// SimulateReload applies edit to the stored configuration, as if config.json had been changed outside the
+// application, and reloads it. As with config.Service.Reload, an edit that fails validation is reported and
+// the current configuration stays active.
+func (f *Fake) SimulateReload(edit func(cfg *types.AppConfig, ext *config.Extensions)) error {
+	const op errors.Op = "configtest.Fake.SimulateReload"
+	if err := f.store.edit(edit); err != nil {
+		return errors.New(op).Err(err)
+	}
+	if err := f.Reload(context.Background()); err != nil {
+		return errors.New(op).Err(err)
+	}
+	return nil
+}
+
+// FailNextSave makes the next change fail with err, or with ErrSimulatedFailure if err is nil. The change is
+// not applied, just as when validation or writing config.json fails.
+func (f *Fake) FailNextSave(err error) {
+	if err == nil {
+		err = ErrSimulatedFailure
+	}
+	f.store.mu.Lock()
+	defer f.store.mu.Unlock()
+	f.store.failNext = err
+}
+
+// Saves returns the number of changes that have been saved, including the configuration given to New.
+func (f *Fake) Saves() int {
+	f.store.mu.Lock()
+	defer f.store.mu.Unlock()
+	return f.store.saves
+}
+
+// memoryStore is a config.Store that holds the configuration as JSON, so that callers never share memory
+// with it and values that would not survive config.json do not survive here either.
+type memoryStore struct {
+	mu       sync.Mutex
+	cfg      []byte
+	ext      []byte
+	failNext error
+	saves    int
+}
+
+func (m *memoryStore) Load() (types.AppConfig, config.Extensions, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.decode()
+}
+
+func (m *memoryStore) Save(cfg types.AppConfig, ext config.Extensions) error {
+	const op errors.Op = "configtest.memoryStore.Save"
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if err := m.failNext; err != nil {
+		m.failNext = nil
+		return errors.New(op).Err(err)
+	}
+	if err := m.encode(cfg, ext); err != nil {
+		return errors.New(op).Err(err)
+	}
+	m.saves++
+	return nil
+}
+
+// edit decodes the stored configuration, applies fn and stores the result without counting it as a save.
+func (m *memoryStore) edit(fn func(cfg *types.AppConfig, ext *config.Extensions)) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	cfg, ext, err := m.decode()
+	if err != nil {
+		return err
+	}
+	fn(&cfg, &ext)
+	return m.encode(cfg, ext)
+}
+
+// decode returns copies of the stored values. The caller must hold m.mu.
+func (m *memoryStore) decode() (types.AppConfig, config.Extensions, error) {
+	const op errors.Op = "configtest.memoryStore.decode"
+	var cfg types.AppConfig
+	var ext config.Extensions
+
+	if m.cfg == nil {
+		return cfg, ext, errors.New(op).Err(fs.ErrNotExist).Msg("no configuration stored")
+	}
+	if err := json.Unmarshal(m.cfg, &cfg); err != nil {
+		return cfg, ext, errors.New(op).Err(err)
+	}
+	if err := json.Unmarshal(m.ext, &ext); err != nil {
+		return cfg, ext, errors.New(op).Err(err)
+	}
+	return cfg, ext, nil
+}
+
+// encode replaces the stored values. The caller must hold m.mu.
+func (m *memoryStore) encode(cfg types.AppConfig, ext config.Extensions) error {
+	const op errors.Op = "configtest.memoryStore.encode"
+
+	cfgData, err := json.Marshal(cfg)
+	if err != nil {
+		return errors.New(op).Err(err)
+	}
+	extData, err := json.Marshal(ext)
+	if err != nil {
+		return errors.New(op).Err(err)
+	}
+	m.cfg, m.ext = cfgData, extData
+	return nil
+}
@@ -0,0 +1,107 @@
+package configtest
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/Station-Manager/config"
+	"github.com/Station-Manager/types"
+)
+
+func TestBuilder(t *testing.T) {
+	fake := NewBuilder(config.ProfileDesktop).
+		WithRig(Rig(2).Model("Yaesu FT-991A").Port("/dev/ttyUSB1")).
+		WithListener(ListenerFromTemplate(config.N1mmListenerTemplate)).
+		WithListener(Listener("Custom").Port(40001).Disabled()).
+		WithForwarder(Forwarder(config.QrzForwardingServiceName).APIKey("secret").Enabled()).
+		MustBuild(t)
+
+	rig, err := fake.RigConfigByID(2)
+	if err != nil || rig.Model != "Yaesu FT-991A" || rig.SerialConfig.PortName != "/dev/ttyUSB1" {
+		t.Errorf("unexpected rig %+v, %v", rig, err)
+	}
+	listeners, _ := fake.ListenerConfigs()
+	if len(listeners) != 3 {
+		t.Errorf("expected the default listener plus two, got %d", len(listeners))
+	}
+	if _, err = fake.ForwarderConfig(config.QrzForwardingServiceName); err != nil {
+		t.Errorf("ForwarderConfig() error = %v", err)
+	}
+	if profile, _ := fake.Profile(); profile != config.ProfileDesktop {
+		t.Errorf("expected desktop profile, got %q", profile)
+	}
+
+	server := NewBuilder(config.ProfileServer).MustBuild(t)
+	if profile, _ := server.Profile(); profile != config.ProfileServer {
+		t.Errorf("expected server profile, got %q", profile)
+	}
+}
+
+func TestBuilder_errors(t *testing.T) {
+	if _, err := NewBuilder(config.ProfileDesktop).WithListener(ListenerFromTemplate("missing")).Build(); err == nil {
+		t.Errorf("expected an error for an unknown listener template")
+	}
+	if _, err := NewBuilder(config.ProfileDesktop).WithListener(Listener("No port")).Build(); err == nil {
+		t.Errorf("expected a validation error for a listener without a port")
+	}
+	if _, err := NewBuilder(config.ProfileDesktop).WithForwarder(Forwarder("missing")).Build(); err == nil {
+		t.Errorf("expected a validation error for an unknown forwarder")
+	}
+}
+
+func TestFake_simulateReload(t *testing.T) {
+	fake := NewBuilder(config.ProfileDesktop).MustBuild(t)
+
+	err := fake.SimulateReload(func(cfg *types.AppConfig, _ *config.Extensions) {
+		cfg.LoggingConfig.Level = "debug"
+	})
+	if err != nil {
+		t.Fatalf("SimulateReload() error = %v", err)
+	}
+	if logCfg, _ := fake.LoggingConfig(); logCfg.Level != "debug" {
+		t.Errorf("expected reloaded level debug, got %q", logCfg.Level)
+	}
+	if snap, _ := fake.Snapshot(); snap.Version != 2 {
+		t.Errorf("expected version 2 after a reload, got %d", snap.Version)
+	}
+
+	err = fake.SimulateReload(func(cfg *types.AppConfig, _ *config.Extensions) {
+		cfg.LoggingConfig.Level = ""
+	})
+	if err == nil {
+		t.Fatalf("expected an invalid edit to be rejected")
+	}
+	if logCfg, _ := fake.LoggingConfig(); logCfg.Level != "debug" {
+		t.Errorf("expected the previous configuration to stay active, got level %q", logCfg.Level)
+	}
+}
+
+func TestFake_failNextSave(t *testing.T) {
+	fake := NewBuilder(config.ProfileDesktop).MustBuild(t)
+	if got := fake.Saves(); got != 1 {
+		t.Errorf("expected the initial configuration to count as one save, got %d", got)
+	}
+
+	fake.FailNextSave(nil)
+	if err := fake.SetCallsignLookupEnabled(false); !errors.Is(err, ErrSimulatedFailure) {
+		t.Fatalf("expected ErrSimulatedFailure, got %v", err)
+	}
+	if snap, _ := fake.Snapshot(); snap.Version != 1 {
+		t.Errorf("expected a failed save not to publish, got version %d", snap.Version)
+	}
+
+	if err := fake.SetCallsignLookupEnabled(false); err != nil {
+		t.Fatalf("expected only the next save to fail, got %v", err)
+	}
+	if got := fake.Saves(); got != 2 {
+		t.Errorf("expected 2 saves, got %d", got)
+	}
+
+	// Validation failures from the real service are reported as well.
+	if err := fake.AddListenerConfig(types.ListenerConfig{Name: "Bad"}); err == nil {
+		t.Errorf("expected a validation error")
+	}
+	if got := fake.Saves(); got != 2 {
+		t.Errorf("expected a rejected change not to be saved, got %d saves", got)
+	}
+}
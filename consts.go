@@ -12,5 +12,28 @@ const (
 	// EnvSmDefaultDB selects the default datastore driver when generating a new config.json.
 	// Accepts: "sqlite" (default), "postgres", and common aliases like "postgresql" or "pg".
 	EnvSmDefaultDB = "SM_DEFAULT_DB"
-	userAgent      = "station-manager/0.1.0"
+	// EnvSmConfigReadOnly, when true, loads the configuration read-only, as WithReadOnly does.
+	EnvSmConfigReadOnly = "SM_CONFIG_READONLY"
+	// EnvSmConfigNoCreate, when true, fails if the configuration file is missing, as WithoutFileCreation does.
+	EnvSmConfigNoCreate = "SM_CONFIG_NO_CREATE"
+	userAgent           = "station-manager/0.1.0"
+)
+
+// Lookup service names not defined in the types module.
+const (
+	HamQthLookupServiceName  = "hamqthlookupservice"
+	CallookLookupServiceName = "callooklookupservice"
+	CtyDatLookupServiceName  = "ctydatlookupservice"
+)
+
+// placeholderValue marks a setting in a generated config.json that the user must fill in.
+const placeholderValue = "?"
+
+// Listener handler names, as used in types.ListenerConfig.Handler.
+const (
+	WsjtxHandlerName   = "wsjtx"
+	Js8callHandlerName = "js8call"
+	FldigiHandlerName  = "fldigi"
+	N1mmHandlerName    = "n1mm"
+	AdifHandlerName    = "adif"
 )
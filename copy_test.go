@@ -0,0 +1,182 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestDeepCopy(t *testing.T) {
+	type inner struct {
+		Values []int
+		When   time.Time
+	}
+	type outer struct {
+		Name  string
+		Ptr   *inner
+		Items []inner
+		Map   map[string][]string
+		Any   any
+	}
+
+	orig := outer{
+		Name:  "a",
+		Ptr:   &inner{Values: []int{1}},
+		Items: []inner{{Values: []int{2}}},
+		Map:   map[string][]string{"k": {"v"}},
+		Any:   []string{"x"},
+	}
+	cp := deepCopy(orig)
+	if !reflect.DeepEqual(orig, cp) {
+		t.Fatalf("copy differs from original: %+v", cp)
+	}
+
+	cp.Ptr.Values[0] = 9
+	cp.Items[0].Values[0] = 9
+	cp.Map["k"][0] = "changed"
+	cp.Any.([]string)[0] = "changed"
+	if orig.Ptr.Values[0] != 1 || orig.Items[0].Values[0] != 2 || orig.Map["k"][0] != "v" || orig.Any.([]string)[0] != "x" {
+		t.Errorf("modifying the copy changed the original: %+v", orig)
+	}
+
+	var nilPtr *inner
+	if deepCopy(nilPtr) != nil {
+		t.Errorf("expected nil pointer to stay nil")
+	}
+}
+
+// scribble modifies every value reachable from v, through pointers, slices and maps.
+func scribble(v reflect.Value) {
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		if !v.IsNil() {
+			scribble(v.Elem())
+		}
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			scribble(v.Index(i))
+		}
+	case reflect.Map:
+		iter := v.MapRange()
+		for iter.Next() {
+			e := reflect.New(iter.Value().Type()).Elem()
+			e.Set(iter.Value())
+			scribble(e)
+			v.SetMapIndex(iter.Key(), e)
+		}
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			if v.Field(i).CanSet() {
+				scribble(v.Field(i))
+			}
+		}
+	case reflect.String:
+		if v.CanSet() {
+			v.SetString(v.String() + "-mutated")
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if v.CanSet() {
+			v.SetInt(v.Int() + 1)
+		}
+	case reflect.Bool:
+		if v.CanSet() {
+			v.SetBool(!v.Bool())
+		}
+	default:
+	}
+}
+
+func TestGetters_returnCopies(t *testing.T) {
+	svc := &Service{WorkingDir: t.TempDir()}
+	if err := svc.Initialize(); err != nil {
+		t.Fatalf("Initialize() error = %v", err)
+	}
+
+	listeners, _ := svc.ListenerConfigs()
+	listenerName := listeners[0].Name
+	snap, _ := svc.Snapshot()
+	rigID := snap.AppConfig.RigConfigs[0].ID
+
+	// Give the extension sections some slices to share.
+	emailOpts, _ := svc.EmailOptions()
+	emailOpts.To = []string{"ops@example.com"}
+	emailOpts.Templates = []EmailTemplate{{Name: "custom", Subject: "s", Body: "b"}}
+	if err := svc.SetEmailOptions(emailOpts); err != nil {
+		t.Fatalf("SetEmailOptions() error = %v", err)
+	}
+	// Values passed to setters are copied too.
+	emailOpts.To[0] = "changed@example.com"
+	if got, _ := svc.EmailOptions(); got.To[0] != "ops@example.com" {
+		t.Errorf("modifying a value after SetEmailOptions changed the service state: %v", got.To)
+	}
+	if err := svc.SetListenerNetworkConfig(ListenerNetworkConfig{Name: listenerName, TTL: 1, ForwardTo: []string{"127.0.0.1:2238"}}); err != nil {
+		t.Fatalf("SetListenerNetworkConfig() error = %v", err)
+	}
+
+	getters := map[string]func() (any, error){
+		"DatastoreConfig":       func() (any, error) { return svc.DatastoreConfig() },
+		"LoggingConfig":         func() (any, error) { return svc.LoggingConfig() },
+		"RequiredConfigs":       func() (any, error) { return svc.RequiredConfigs() },
+		"RigConfigByID":         func() (any, error) { return svc.RigConfigByID(rigID) },
+		"LoggingStationConfigs": func() (any, error) { return svc.LoggingStationConfigs() },
+		"LookupServiceConfig":   func() (any, error) { return svc.LookupServiceConfig(HamQthLookupServiceName) },
+		"LookupServiceConfigs":  func() (any, error) { return svc.LookupServiceConfigs() },
+		"LookupProviderOptions": func() (any, error) { return svc.LookupProviderOptions(HamQthLookupServiceName) },
+		"ForwarderConfig":       func() (any, error) { return svc.ForwarderConfig(QrzForwardingServiceName) },
+		"ForwarderConfigs":      func() (any, error) { return svc.ForwarderConfigs() },
+		"ForwarderOptions":      func() (any, error) { return svc.ForwarderOptions(LotwForwardingServiceName) },
+		"EmailConfig":           func() (any, error) { return svc.EmailConfig() },
+		"EmailOptions":          func() (any, error) { return svc.EmailOptions() },
+		"OptionalConfigs":       func() (any, error) { return svc.OptionalConfigs() },
+		"ListenerConfigs":       func() (any, error) { return svc.ListenerConfigs() },
+		"ListenerNetworkConfig": func() (any, error) { return svc.ListenerNetworkConfig(listenerName) },
+		"Snapshot":              func() (any, error) { return svc.Snapshot() },
+	}
+
+	for name, get := range getters {
+		t.Run(name, func(t *testing.T) { assertReturnsCopy(t, name, get) })
+	}
+}
+
+func TestGetters_returnCopiesInServerMode(t *testing.T) {
+	t.Setenv(EnvSmDefaultDB, "postgres")
+	svc := &Service{WorkingDir: t.TempDir()}
+	if err := svc.Initialize(); err != nil {
+		t.Fatalf("Initialize() error = %v", err)
+	}
+	opts, _ := svc.ServerOptions()
+	opts.CORS.AllowedOrigins = []string{"https://log.example.com"}
+	opts.TrustedProxies = []string{"10.0.0.1"}
+	if err := svc.SetServerOptions(opts); err != nil {
+		t.Fatalf("SetServerOptions() error = %v", err)
+	}
+
+	assertReturnsCopy(t, "ServerConfig", func() (any, error) { return svc.ServerConfig() })
+	assertReturnsCopy(t, "ServerOptions", func() (any, error) { return svc.ServerOptions() })
+}
+
+// assertReturnsCopy modifies everything reachable from the value returned by get, then checks that a second
+// call returns the original value.
+func assertReturnsCopy(t *testing.T, name string, get func() (any, error)) {
+	t.Helper()
+	first, err := get()
+	if err != nil && !errors.Is(err, ErrForwarderDisabled) {
+		t.Fatalf("%s() error = %v", name, err)
+	}
+	before, err := json.Marshal(first)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ptr := reflect.New(reflect.TypeOf(first))
+	ptr.Elem().Set(reflect.ValueOf(first))
+	scribble(ptr.Elem())
+
+	second, _ := get()
+	after, _ := json.Marshal(second)
+	if string(before) != string(after) {
+		t.Errorf("mutating the value returned by %s() changed later reads:\nbefore %s\nafter  %s", name, before, after)
+	}
+}
@@ -76,9 +76,10 @@ var defaultServerConfig = types.AppConfig{
 		ShutdownTimeoutMS:      10000,
 		ShutdownTimeoutWarning: false,
 	},
-	RequiredConfigs: types.RequiredConfigs{},
+	RequiredConfigs: defaultRequiredConfigs,
 	ServerConfig: &types.ServerConfig{
 		Name:         "Station Manager",
+		Host:         "0.0.0.0",
 		Port:         3000,
 		ReadTimeout:  5,       // Seconds
 		WriteTimeout: 10,      // Seconds
@@ -112,22 +113,54 @@ var defaultRequiredConfigs = types.RequiredConfigs{
 }
 
 var defaultLookupServiceConfigs = []types.LookupConfig{
-	{
-		Name:           types.HamNutLookupServiceName,
-		URL:            "https://api.hamnut.com/v1/call-signs/prefixes",
-		Enabled:        false,
-		HttpTimeoutSec: 5, // Seconds
-		UserAgent:      userAgent,
-	},
-	{
-		Name:           types.QrzLookupServiceName,
-		URL:            "https://xmldata.qrz.com/xml/current/",
-		Username:       "?",
-		Password:       "?",
-		Enabled:        false,
-		HttpTimeoutSec: 5, // Seconds
-		UserAgent:      userAgent,
-	},
+	hamnutLookupDefaults,
+	qrzLookupDefaults,
+	hamqthLookupDefaults,
+	callookLookupDefaults,
+	ctyDatLookupDefaults,
+}
+
+var hamnutLookupDefaults = types.LookupConfig{
+	Name:           types.HamNutLookupServiceName,
+	URL:            "https://api.hamnut.com/v1/call-signs/prefixes",
+	Enabled:        false,
+	HttpTimeoutSec: 5, // Seconds
+	UserAgent:      userAgent,
+}
+
+var qrzLookupDefaults = types.LookupConfig{
+	Name:           types.QrzLookupServiceName,
+	URL:            "https://xmldata.qrz.com/xml/current/",
+	Username:       placeholderValue,
+	Password:       placeholderValue,
+	Enabled:        false,
+	HttpTimeoutSec: 5, // Seconds
+	UserAgent:      userAgent,
+}
+
+var hamqthLookupDefaults = types.LookupConfig{
+	Name:           HamQthLookupServiceName,
+	URL:            "https://www.hamqth.com/xml.php",
+	Username:       placeholderValue,
+	Password:       placeholderValue,
+	Enabled:        false,
+	HttpTimeoutSec: 5, // Seconds
+	UserAgent:      userAgent,
+	ViewUrl:        "https://www.hamqth.com/",
+}
+
+var callookLookupDefaults = types.LookupConfig{
+	Name:           CallookLookupServiceName,
+	URL:            "https://callook.info/",
+	Enabled:        false,
+	HttpTimeoutSec: 5, // Seconds
+	UserAgent:      userAgent,
+}
+
+var ctyDatLookupDefaults = types.LookupConfig{
+	Name:    CtyDatLookupServiceName,
+	URL:     "cty.dat", // Path to the file, relative to the working directory
+	Enabled: false,
 }
 
 var defaultLoggingStationDetails = types.LoggingStation{
@@ -159,12 +192,12 @@ var defaultLoggingStationDetails = types.LoggingStation{
 var defaultEmailConfigs = types.EmailConfig{
 	Name:               types.EmailServiceName,
 	Enabled:            false,
-	Username:           "?",
-	Password:           "?",
-	Host:               "?",
+	Username:           placeholderValue,
+	Password:           placeholderValue,
+	Host:               placeholderValue,
 	Port:               587,
-	From:               "?",
-	To:                 "?",
+	From:               placeholderValue,
+	To:                 placeholderValue,
 	Subject:            "",
 	Body:               "",
 	SmtpDialTimeoutSec: 10,
@@ -173,16 +206,61 @@ var defaultEmailConfigs = types.EmailConfig{
 }
 
 var defaultForwardingConfigs = []types.ForwarderConfig{
-	{
-		Name:           types.QrzForwardingServiceName,
-		Enabled:        false,
-		URL:            "",
-		APIKey:         "",
-		Username:       "",
-		Password:       "",
-		UserAgent:      userAgent,
-		HttpTimeoutSec: 5, // Seconds
-	},
+	qrzForwarderDefaults,
+	lotwForwarderDefaults,
+	eqslForwarderDefaults,
+	clublogForwarderDefaults,
+	hrdlogForwarderDefaults,
+}
+
+var qrzForwarderDefaults = types.ForwarderConfig{
+	Name:           QrzForwardingServiceName,
+	Enabled:        false,
+	URL:            "",
+	APIKey:         "",
+	Username:       "",
+	Password:       "",
+	UserAgent:      userAgent,
+	HttpTimeoutSec: 5, // Seconds
+}
+
+var lotwForwarderDefaults = types.ForwarderConfig{
+	Name:           LotwForwardingServiceName,
+	Enabled:        false,
+	URL:            "https://lotw.arrl.org/lotwuser/",
+	Username:       "", // Only needed to download confirmations
+	Password:       "",
+	UserAgent:      userAgent,
+	HttpTimeoutSec: 30, // Seconds; TQSL signing and upload is slow
+}
+
+var eqslForwarderDefaults = types.ForwarderConfig{
+	Name:           EqslForwardingServiceName,
+	Enabled:        false,
+	URL:            "https://www.eqsl.cc/qslcard/ImportADIF.cfm",
+	Username:       "",
+	Password:       "",
+	UserAgent:      userAgent,
+	HttpTimeoutSec: 10, // Seconds
+}
+
+var clublogForwarderDefaults = types.ForwarderConfig{
+	Name:           ClublogForwardingServiceName,
+	Enabled:        false,
+	URL:            "https://clublog.org/realtime.php",
+	APIKey:         "", // Club Log application key
+	Password:       "",
+	UserAgent:      userAgent,
+	HttpTimeoutSec: 10, // Seconds
+}
+
+var hrdlogForwarderDefaults = types.ForwarderConfig{
+	Name:           HrdlogForwardingServiceName,
+	Enabled:        false,
+	URL:            "https://robot.hrdlog.net/NewEntry.aspx",
+	APIKey:         "", // HRDLog.net upload code
+	UserAgent:      userAgent,
+	HttpTimeoutSec: 10, // Seconds
 }
 
 var defaultOptionalConfigs = types.OptionalConfigs{
@@ -482,7 +560,7 @@ var defaultListenerConfigs = []types.ListenerConfig{
 		Protocol:   "UDP",
 		BufferSize: 1024,
 		LogPayload: false,
-		Handler:    "wsjtx",
+		Handler:    WsjtxHandlerName,
 		HandlerConfig: map[string]any{
 			"auto_log":      false,
 			"log_decodes":   false,
@@ -490,3 +568,69 @@ var defaultListenerConfigs = []types.ListenerConfig{
 		},
 	},
 }
+
+// defaultServerExtensions has no lookup provider options, as the server profile configures no lookup providers.
+var defaultServerExtensions = Extensions{
+	StationOptions: StationOptions{
+		AllowCallsignMismatch: false,
+	},
+	EmailOptions:  defaultEmailOptions,
+	ServerOptions: &defaultServerOptions,
+}
+
+// defaultServerOptions leaves TLS off and authentication disabled, which is only suitable on a trusted LAN.
+var defaultServerOptions = ServerOptions{
+	TLS: ServerTLSOptions{
+		SelfSigned:          false,
+		SelfSignedValidDays: defaultSelfSignedDays,
+		MinVersion:          defaultTLSMinVersion,
+	},
+	CORS: CORSOptions{
+		AllowedMethods: []string{"GET", "POST", "PUT", "PATCH", "DELETE"},
+		MaxAgeSec:      600,
+	},
+	Auth: ServerAuthOptions{
+		Mode: ServerAuthNone,
+		JWT:  JWTOptions{Algorithm: defaultJWTAlgorithm, TTLSec: defaultJWTTTLSec},
+	},
+}
+
+// defaultEmailOptions matches the STARTTLS port in defaultEmailConfigs. Templates are omitted so the built-in
+// ones are used until overridden.
+var defaultEmailOptions = EmailOptions{
+	TLSMode:       EmailTLSStartTLS,
+	AuthMechanism: EmailAuthPlain,
+}
+
+var defaultExtensions = Extensions{
+	StationOptions: StationOptions{
+		AllowCallsignMismatch: false,
+	},
+	EmailOptions: defaultEmailOptions,
+	ForwarderOptions: []ForwarderOptions{
+		{
+			Name: LotwForwardingServiceName,
+			Lotw: &LotwOptions{},
+			// LoTW prefers infrequent, larger uploads
+			Schedule: &ForwarderSchedule{PollIntervalSeconds: intPtr(3600), BatchSize: intPtr(100)},
+		},
+		{Name: EqslForwardingServiceName, Eqsl: &EqslOptions{}},
+		{
+			Name:    ClublogForwardingServiceName,
+			Clublog: &ClublogOptions{},
+			// Club Log asks for batched uploads rather than one request per QSO
+			Schedule: &ForwarderSchedule{PollIntervalSeconds: intPtr(900), BatchSize: intPtr(50)},
+		},
+		{Name: HrdlogForwardingServiceName, Hrdlog: &HrdlogOptions{}},
+	},
+	LookupOptions: LookupOptions{
+		CallsignLookupEnabled: false,
+		Providers: []LookupProviderOptions{
+			lookupProviders[types.QrzLookupServiceName].options,
+			lookupProviders[HamQthLookupServiceName].options,
+			lookupProviders[CallookLookupServiceName].options,
+			lookupProviders[types.HamNutLookupServiceName].options,
+			lookupProviders[CtyDatLookupServiceName].options,
+		},
+	},
+}
@@ -0,0 +1,311 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/Station-Manager/config/internal/jsonpath"
+	"github.com/Station-Manager/errors"
+	"github.com/Station-Manager/types"
+	"github.com/goccy/go-json"
+)
+
+// ChangeKind is the kind of a Change.
+type ChangeKind string
+
+const (
+	// ChangeAdded is a value, list element or map entry that exists only in the newer configuration.
+	ChangeAdded ChangeKind = "added"
+	// ChangeRemoved is a value, list element or map entry that exists only in the older configuration.
+	ChangeRemoved ChangeKind = "removed"
+	// ChangeModified is a value that differs between the two configurations.
+	ChangeModified ChangeKind = "changed"
+)
+
+// Change is one difference between two configurations. Path uses the syntax of Get. Secrets in From and To
+// are replaced by RedactedValue, as by Snapshot.Redacted, so changes can be logged and shown.
+type Change struct {
+	Path string     `json:"path"`
+	Kind ChangeKind `json:"kind"`
+	From any        `json:"from"`
+	To   any        `json:"to"`
+}
+
+// String renders the change on one line, for logs.
+func (c Change) String() string {
+	switch c.Kind {
+	case ChangeAdded:
+		return fmt.Sprintf("%s added: %v", c.Path, c.To)
+	case ChangeRemoved:
+		return fmt.Sprintf("%s removed: %v", c.Path, c.From)
+	default:
+		return fmt.Sprintf("%s changed: %v -> %v", c.Path, c.From, c.To)
+	}
+}
+
+// Diff returns the differences from one AppConfig to another. List elements are matched by their ID, or by
+// their Name if they have no ID, so that reordering a list, or removing one element, reports only the elements
+// that changed. Lists whose elements have neither, or whose IDs or names are not unique, are compared by index.
+func Diff(from, to types.AppConfig) []Change {
+	var changes []Change
+	diffValue(&changes, nil, reflect.ValueOf(from), reflect.ValueOf(to), false)
+	return changes
+}
+
+// DiffSnapshots returns the differences from one snapshot to another, including the sections held in
+// Extensions, as described by Diff.
+func DiffSnapshots(from, to Snapshot) []Change {
+	changes := Diff(from.AppConfig, to.AppConfig)
+	diffValue(&changes, nil, reflect.ValueOf(from.Extensions), reflect.ValueOf(to.Extensions), false)
+	return changes
+}
+
+// DiffFile returns the differences from the configuration file at path, such as a backup, to the active
+// configuration. The file is loaded and validated as it would be at startup, so defaults it leaves out are not
+// reported as differences.
+func (s *Service) DiffFile(path string) ([]Change, error) {
+	const op errors.Op = "config.Service.DiffFile"
+	if !s.isInitialized.Load() {
+		return nil, errors.New(op).Msg(errMsgNotInitialized)
+	}
+
+	other, err := New(WithWorkingDir(s.WorkingDir), WithFile(path), WithReadOnly())
+	if err != nil {
+		return nil, errors.New(op).Err(err)
+	}
+	return DiffSnapshots(*other.current(), *s.current()), nil
+}
+
+// diffValue appends the differences between a and b, of the same type, at path. secret reports that they are
+// the value of a secret field.
+func diffValue(changes *[]Change, path jsonpath.Path, a, b reflect.Value, secret bool) {
+	switch a.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		switch {
+		case a.IsNil() && b.IsNil():
+		case a.IsNil():
+			*changes = append(*changes, Change{Path: path.String(), Kind: ChangeAdded, To: maskedValue(b, secret)})
+		case b.IsNil():
+			*changes = append(*changes, Change{Path: path.String(), Kind: ChangeRemoved, From: maskedValue(a, secret)})
+		case a.Elem().Type() != b.Elem().Type():
+			// Values decoded from the file, such as handler options, can have other types than the same
+			// values built in code: []any rather than []int. They are equal if they encode the same.
+			if jsonEqual(a.Interface(), b.Interface()) {
+				return
+			}
+			*changes = append(*changes, Change{Path: path.String(), Kind: ChangeModified, From: maskedValue(a, secret), To: maskedValue(b, secret)})
+		default:
+			diffValue(changes, path, a.Elem(), b.Elem(), secret)
+		}
+	case reflect.Struct:
+		t := a.Type()
+		if !hasExportedFields(t) {
+			diffLeaf(changes, path, a, b, secret)
+			return
+		}
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			name := fieldName(f)
+			if name == "" {
+				continue
+			}
+			step := jsonpath.Step{Kind: jsonpath.KeyStep, Key: name}
+			diffValue(changes, append(path[:len(path):len(path)], step), a.Field(i), b.Field(i), isSecretField(f.Name))
+		}
+	case reflect.Slice, reflect.Array:
+		if elemStruct(a.Type().Elem()) == nil {
+			diffLeaf(changes, path, a, b, secret)
+			return
+		}
+		diffList(changes, path, a, b)
+	case reflect.Map:
+		diffMap(changes, path, a, b, secret)
+	default:
+		diffLeaf(changes, path, a, b, secret)
+	}
+}
+
+// diffLeaf compares a and b as whole values.
+func diffLeaf(changes *[]Change, path jsonpath.Path, a, b reflect.Value, secret bool) {
+	if a.Kind() == reflect.Slice && a.Len() == 0 && b.Len() == 0 {
+		return
+	}
+	if !reflect.DeepEqual(a.Interface(), b.Interface()) {
+		*changes = append(*changes, Change{Path: path.String(), Kind: ChangeModified, From: maskedValue(a, secret), To: maskedValue(b, secret)})
+	}
+}
+
+// diffList compares lists of structs, matching their elements by key.
+func diffList(changes *[]Change, path jsonpath.Path, a, b reflect.Value) {
+	field, aKeys, bKeys := listKeys(a, b)
+	if field == "" {
+		n := max(a.Len(), b.Len())
+		for i := 0; i < n; i++ {
+			p := append(path[:len(path):len(path)], jsonpath.Step{Kind: jsonpath.IndexStep, Index: i})
+			switch {
+			case i >= a.Len():
+				*changes = append(*changes, Change{Path: p.String(), Kind: ChangeAdded, To: maskedValue(b.Index(i), false)})
+			case i >= b.Len():
+				*changes = append(*changes, Change{Path: p.String(), Kind: ChangeRemoved, From: maskedValue(a.Index(i), false)})
+			default:
+				diffValue(changes, p, a.Index(i), b.Index(i), false)
+			}
+		}
+		return
+	}
+
+	step := func(key string) jsonpath.Path {
+		return append(path[:len(path):len(path)], jsonpath.Step{Kind: jsonpath.MatchStep, Field: field, Value: key})
+	}
+	aIndex := make(map[string]int, len(aKeys))
+	for i, k := range aKeys {
+		aIndex[k] = i
+	}
+	bIndex := make(map[string]int, len(bKeys))
+	for i, k := range bKeys {
+		bIndex[k] = i
+	}
+	for j, k := range bKeys {
+		if i, ok := aIndex[k]; ok {
+			diffValue(changes, step(k), a.Index(i), b.Index(j), false)
+		} else {
+			*changes = append(*changes, Change{Path: step(k).String(), Kind: ChangeAdded, To: maskedValue(b.Index(j), false)})
+		}
+	}
+	for i, k := range aKeys {
+		if _, ok := bIndex[k]; !ok {
+			*changes = append(*changes, Change{Path: step(k).String(), Kind: ChangeRemoved, From: maskedValue(a.Index(i), false)})
+		}
+	}
+}
+
+// listKeys returns the name of the field identifying the elements of a and b, and the key of each element. It
+// returns an empty field name if the elements have no ID or Name field, or if the keys are not unique.
+func listKeys(a, b reflect.Value) (string, []string, []string) {
+	t := elemStruct(a.Type().Elem())
+	for _, goName := range []string{"ID", "Name"} {
+		f, ok := t.FieldByName(goName)
+		if !ok || len(f.Index) != 1 {
+			continue
+		}
+		aKeys, aOK := elementKeys(a, f.Index[0])
+		bKeys, bOK := elementKeys(b, f.Index[0])
+		if aOK && bOK {
+			return fieldName(f), aKeys, bKeys
+		}
+	}
+	return "", nil, nil
+}
+
+// elementKeys returns the value of field i of each element of list, and whether they are unique and set.
+func elementKeys(list reflect.Value, i int) ([]string, bool) {
+	keys := make([]string, list.Len())
+	seen := make(map[string]bool, list.Len())
+	for j := range keys {
+		e := reflect.Indirect(list.Index(j))
+		if !e.IsValid() || e.Field(i).IsZero() {
+			return nil, false
+		}
+		k := fmt.Sprint(e.Field(i).Interface())
+		if seen[k] {
+			return nil, false
+		}
+		seen[k], keys[j] = true, k
+	}
+	return keys, true
+}
+
+// diffMap compares maps entry by entry, in key order.
+func diffMap(changes *[]Change, path jsonpath.Path, a, b reflect.Value, secret bool) {
+	keys := map[string]reflect.Value{}
+	for _, m := range []reflect.Value{a, b} {
+		iter := m.MapRange()
+		for iter.Next() {
+			keys[fmt.Sprint(iter.Key().Interface())] = iter.Key()
+		}
+	}
+	names := make([]string, 0, len(keys))
+	for k := range keys {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		p := append(path[:len(path):len(path)], jsonpath.Step{Kind: jsonpath.KeyStep, Key: name})
+		av, bv := a.MapIndex(keys[name]), b.MapIndex(keys[name])
+		entrySecret := secret || isSecretKey(name)
+		switch {
+		case !av.IsValid():
+			*changes = append(*changes, Change{Path: p.String(), Kind: ChangeAdded, To: maskedValue(bv, entrySecret)})
+		case !bv.IsValid():
+			*changes = append(*changes, Change{Path: p.String(), Kind: ChangeRemoved, From: maskedValue(av, entrySecret)})
+		default:
+			diffValue(changes, p, av, bv, entrySecret)
+		}
+	}
+}
+
+// maskedValue returns a copy of v with its secrets redacted. secret reports that v is itself a secret.
+func maskedValue(v reflect.Value, secret bool) any {
+	if v.Kind() == reflect.Interface && !v.IsNil() {
+		v = v.Elem()
+	}
+	if secret && v.Kind() == reflect.String {
+		if isPlaceholder(v.String()) {
+			return v.String()
+		}
+		return RedactedValue
+	}
+	cp := reflect.New(v.Type()).Elem()
+	copyValue(cp, v)
+	redactValue(cp)
+	return cp.Interface()
+}
+
+// jsonEqual reports whether a and b have the same JSON encoding.
+func jsonEqual(a, b any) bool {
+	x, errA := json.Marshal(a)
+	y, errB := json.Marshal(b)
+	return errA == nil && errB == nil && bytes.Equal(x, y)
+}
+
+// fieldName returns the name of a struct field in config.json, or "" if it is not written.
+func fieldName(f reflect.StructField) string {
+	if !f.IsExported() {
+		return ""
+	}
+	name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+	switch name {
+	case "-":
+		return ""
+	case "":
+		return f.Name
+	default:
+		return name
+	}
+}
+
+// hasExportedFields reports whether t has any exported fields; structs such as time.Time that have none are
+// compared as whole values.
+func hasExportedFields(t reflect.Type) bool {
+	for i := 0; i < t.NumField(); i++ {
+		if t.Field(i).IsExported() {
+			return true
+		}
+	}
+	return false
+}
+
+// elemStruct returns t, or the type t points to, if it is a struct with exported fields, and nil otherwise.
+func elemStruct(t reflect.Type) reflect.Type {
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct || !hasExportedFields(t) {
+		return nil
+	}
+	return t
+}
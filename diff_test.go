@@ -0,0 +1,109 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Station-Manager/types"
+)
+
+func TestDiff(t *testing.T) {
+	from, _ := DefaultConfig(ProfileDesktop)
+	if changes := Diff(from, deepCopy(from)); len(changes) != 0 {
+		t.Fatalf("expected no changes between equal configurations, got %v", changes)
+	}
+
+	to := deepCopy(from)
+	to.LoggingConfig.Level = "debug"
+	to.EmailConfigs.Password = "hunter2"
+	to.RigConfigs = append(to.RigConfigs, types.RigConfig{ID: 2, Name: "Portable"})
+	to.RigConfigs[0].SerialConfig.PortName = "COM3"
+	// Reordering a list matched by name reports only the element that changed.
+	to.LookupServiceConfigs[0], to.LookupServiceConfigs[1] = to.LookupServiceConfigs[1], to.LookupServiceConfigs[0]
+	to.LookupServiceConfigs[0].Password = "secret"
+	to.ForwardingConfigs = to.ForwardingConfigs[1:]
+	to.DatastoreConfig.Options["cache_size"] = "-4000"
+
+	want := map[string]ChangeKind{
+		"datastore_config.options.cache_size":     ChangeAdded,
+		"logging_config.level":                    ChangeModified,
+		"rig_configs[ID=1].SerialConfig.PortName": ChangeModified,
+		"rig_configs[ID=2]":                       ChangeAdded,
+		"lookup_service_configs[name=" + to.LookupServiceConfigs[0].Name + "].password": ChangeModified,
+		"forwarding_configs[name=" + from.ForwardingConfigs[0].Name + "]":               ChangeRemoved,
+		"email_configs.password": ChangeModified,
+	}
+	changes := Diff(from, to)
+	if len(changes) != len(want) {
+		t.Errorf("expected %d changes, got %d: %v", len(want), len(changes), changes)
+	}
+	for _, c := range changes {
+		kind, ok := want[c.Path]
+		if !ok || kind != c.Kind {
+			t.Errorf("unexpected change %v", c)
+			continue
+		}
+		switch c.Path {
+		case "logging_config.level":
+			if c.From != "info" || c.To != "debug" {
+				t.Errorf("unexpected values in %v", c)
+			}
+		case "email_configs.password":
+			if c.To != RedactedValue {
+				t.Errorf("expected the password to be masked, got %v", c)
+			}
+		case "forwarding_configs[name=" + from.ForwardingConfigs[0].Name + "]":
+			if fwd, ok := c.From.(types.ForwarderConfig); !ok || fwd.Name != from.ForwardingConfigs[0].Name {
+				t.Errorf("expected the removed forwarder, got %#v", c.From)
+			}
+		}
+	}
+
+	// Changes to a removed element's secrets are masked in the whole value too.
+	withKey := deepCopy(to)
+	withKey.ForwardingConfigs[0].APIKey = "key"
+	for _, c := range Diff(withKey, from) {
+		if fwd, ok := c.From.(types.ForwarderConfig); ok && fwd.APIKey == "key" {
+			t.Errorf("expected the API key to be masked in %v", c)
+		}
+	}
+}
+
+func TestDiffFile(t *testing.T) {
+	workDir := t.TempDir()
+	svc := &Service{WorkingDir: workDir}
+	if _, err := svc.DiffFile(filepath.Join(workDir, "config.json")); err == nil {
+		t.Fatalf("expected error before Initialize")
+	}
+	if err := svc.Initialize(); err != nil {
+		t.Fatalf("Initialize() error = %v", err)
+	}
+
+	backup := filepath.Join(workDir, "config.json.bak")
+	data, err := os.ReadFile(filepath.Join(workDir, "config.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = os.WriteFile(backup, data, 0o640); err != nil {
+		t.Fatal(err)
+	}
+	if changes, err := svc.DiffFile(backup); err != nil || len(changes) != 0 {
+		t.Fatalf("DiffFile() = %v, %v; want no changes", changes, err)
+	}
+
+	if err = svc.Set("logging_config.level", "warn"); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+	changes, err := svc.DiffFile(backup)
+	if err != nil {
+		t.Fatalf("DiffFile() error = %v", err)
+	}
+	if len(changes) != 1 || changes[0].Path != "logging_config.level" || changes[0].From != "info" || changes[0].To != "warn" {
+		t.Errorf("unexpected changes: %v", changes)
+	}
+
+	if _, err = svc.DiffFile(filepath.Join(workDir, "missing.json")); err == nil {
+		t.Errorf("expected error for a missing file")
+	}
+}
@@ -0,0 +1,416 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"slices"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/Station-Manager/errors"
+	"github.com/Station-Manager/types"
+)
+
+// TLS modes for the SMTP connection.
+const (
+	// EmailTLSStartTLS connects in plain text and upgrades with STARTTLS, typically on port 587.
+	EmailTLSStartTLS = "starttls"
+	// EmailTLSImplicit connects over TLS from the start, typically on port 465.
+	EmailTLSImplicit = "implicit"
+	// EmailTLSNone never uses TLS. Only suitable for a relay on a trusted network.
+	EmailTLSNone = "none"
+)
+
+// SMTP authentication mechanisms.
+const (
+	EmailAuthPlain   = "plain"
+	EmailAuthLogin   = "login"
+	EmailAuthCramMD5 = "cram-md5"
+	EmailAuthNone    = "none"
+)
+
+// Names of the email templates the application sends. Each has a built-in default that is used when no
+// template with the same name is configured.
+const (
+	// EmailTemplateQsoForwardSummary summarises the QSOs uploaded by a forwarder.
+	EmailTemplateQsoForwardSummary = "qso_forward_summary"
+	// EmailTemplateErrorAlert reports an error, such as a forwarder that keeps failing.
+	EmailTemplateErrorAlert = "error_alert"
+)
+
+// EmailOptions extends types.EmailConfig with settings that do not fit that shape.
+type EmailOptions struct {
+	// To holds recipients in addition to EmailConfig.To.
+	To []string `json:"to,omitempty"`
+	// Cc holds carbon-copy recipients.
+	Cc []string `json:"cc,omitempty"`
+	// Bcc holds blind carbon-copy recipients.
+	Bcc []string `json:"bcc,omitempty"`
+	// TLSMode is one of EmailTLSStartTLS, EmailTLSImplicit or EmailTLSNone. When empty it is derived from
+	// the port: implicit for 465, otherwise starttls.
+	TLSMode string `json:"tls_mode"`
+	// AuthMechanism is one of EmailAuthPlain, EmailAuthLogin, EmailAuthCramMD5 or EmailAuthNone. Defaults to
+	// plain.
+	AuthMechanism string `json:"auth_mechanism"`
+	// Templates overrides or adds named templates.
+	Templates []EmailTemplate `json:"templates,omitempty"`
+}
+
+// EmailTemplate is a named pair of Go text/template strings, executed with EmailTemplateData.
+type EmailTemplate struct {
+	Name    string `json:"name"`
+	Subject string `json:"subject"`
+	Body    string `json:"body"`
+}
+
+// EmailTemplateData is the data model every email template is executed with. Templates may also use the
+// functions upper, lower and join (strings.Join).
+type EmailTemplateData struct {
+	// StationCallsign is the callsign of the logging station.
+	StationCallsign string
+	// Time is when the email was generated.
+	Time time.Time
+	// Forwarder is the name of the forwarder the email is about, if any.
+	Forwarder string
+	// QSOs are the QSOs the email reports on, such as those uploaded by Forwarder.
+	QSOs []EmailQso
+	// Failed is the number of QSOs that could not be forwarded.
+	Failed int
+	// Error is the error being reported, for alerts.
+	Error string
+}
+
+// EmailQso is the summary of a QSO available to email templates.
+type EmailQso struct {
+	Call   string
+	Band   string
+	Mode   string
+	Freq   string
+	TimeOn time.Time
+}
+
+// EmailRecipients is the effective list of recipients of every email.
+type EmailRecipients struct {
+	To  []string
+	Cc  []string
+	Bcc []string
+}
+
+var defaultEmailTemplates = []EmailTemplate{
+	{
+		Name:    EmailTemplateQsoForwardSummary,
+		Subject: `[{{.StationCallsign}}] {{len .QSOs}} QSO(s) forwarded to {{.Forwarder}}`,
+		Body: `{{len .QSOs}} QSO(s) were forwarded to {{.Forwarder}} at {{.Time.UTC.Format "2006-01-02 15:04"}} UTC.
+{{- if .Failed}} {{.Failed}} failed.{{end}}
+{{range .QSOs}}
+{{.TimeOn.UTC.Format "2006-01-02 15:04"}}  {{.Call}}  {{.Band}}  {{.Mode}}
+{{- end}}
+`,
+	},
+	{
+		Name:    EmailTemplateErrorAlert,
+		Subject: `[{{.StationCallsign}}] Error{{if .Forwarder}} in {{.Forwarder}}{{end}}`,
+		Body: `An error occurred at {{.Time.UTC.Format "2006-01-02 15:04"}} UTC{{if .Forwarder}} while forwarding to {{.Forwarder}}{{end}}:
+
+{{.Error}}
+`,
+	},
+}
+
+var emailTemplateFuncs = template.FuncMap{
+	"upper": strings.ToUpper,
+	"lower": strings.ToLower,
+	"join":  strings.Join,
+}
+
+// sampleEmailTemplateData exercises every field of the data model when templates are validated.
+var sampleEmailTemplateData = EmailTemplateData{
+	StationCallsign: "G4ABC",
+	Time:            time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC),
+	Forwarder:       QrzForwardingServiceName,
+	QSOs:            []EmailQso{{Call: "K1ABC", Band: "20m", Mode: "FT8", Freq: "14.074", TimeOn: time.Date(2025, 1, 1, 11, 58, 0, 0, time.UTC)}},
+	Failed:          1,
+	Error:           "connection refused",
+}
+
+// emailTemplate returns the configured template with the given name, or its built-in default.
+func emailTemplate(opts EmailOptions, name string) (EmailTemplate, bool) {
+	for _, t := range opts.Templates {
+		if t.Name == name {
+			return t, true
+		}
+	}
+	for _, t := range defaultEmailTemplates {
+		if t.Name == name {
+			return t, true
+		}
+	}
+	return EmailTemplate{}, false
+}
+
+// renderEmailTemplate executes the subject and body of tmpl with data. The rendered subject must be a single
+// line, as it becomes a mail header.
+func renderEmailTemplate(tmpl EmailTemplate, data EmailTemplateData) (string, string, error) {
+	const op errors.Op = "config.renderEmailTemplate"
+
+	var subject, body bytes.Buffer
+	for _, part := range []struct {
+		name string
+		text string
+		out  *bytes.Buffer
+	}{
+		{"subject", tmpl.Subject, &subject},
+		{"body", tmpl.Body, &body},
+	} {
+		t, err := template.New(part.name).Funcs(emailTemplateFuncs).Option("missingkey=error").Parse(part.text)
+		if err != nil {
+			return "", "", errors.New(op).Err(err).Msgf("invalid %s template: %s", part.name, err)
+		}
+		if err = t.Execute(part.out, data); err != nil {
+			return "", "", errors.New(op).Err(err).Msgf("invalid %s template: %s", part.name, err)
+		}
+	}
+
+	s := strings.TrimSpace(subject.String())
+	if strings.ContainsAny(s, "\r\n") {
+		return "", "", errors.New(op).Msg("subject must render to a single line")
+	}
+	return s, body.String(), nil
+}
+
+// SMTP limits enforced by validateEmailConfig.
+const (
+	minSmtpDialTimeoutSec = 1
+	maxSmtpDialTimeoutSec = 300
+	maxSmtpRetryCount     = 10
+	maxSmtpRetryDelaySec  = 3600
+)
+
+// applyEmailDefaults fills in the dial timeout, TLS mode, auth mechanism and port when they are not set.
+func applyEmailDefaults(opts *EmailOptions, cfg *types.EmailConfig) {
+	if cfg.SmtpDialTimeoutSec == 0 {
+		cfg.SmtpDialTimeoutSec = defaultEmailConfigs.SmtpDialTimeoutSec
+	}
+	opts.TLSMode = strings.ToLower(strings.TrimSpace(opts.TLSMode))
+	if opts.TLSMode == "" {
+		opts.TLSMode = EmailTLSStartTLS
+		if cfg.Port == 465 {
+			opts.TLSMode = EmailTLSImplicit
+		}
+	}
+	if cfg.Port == 0 {
+		cfg.Port = defaultEmailConfigs.Port
+		if opts.TLSMode == EmailTLSImplicit {
+			cfg.Port = 465
+		}
+	}
+	opts.AuthMechanism = strings.ToLower(strings.TrimSpace(opts.AuthMechanism))
+	if opts.AuthMechanism == "" {
+		opts.AuthMechanism = EmailAuthPlain
+	}
+}
+
+// validateEmailConfig checks the SMTP settings. Values that are set are always checked; when the email service
+// is enabled, the host, sender, at least one recipient and, unless auth_mechanism is none, the credentials
+// must also be configured rather than left as placeholders. Missing settings are reported with an error
+// wrapping ErrEmailNotConfigured.
+func validateEmailConfig(cfg types.EmailConfig, opts EmailOptions) error {
+	const op errors.Op = "config.validateEmailConfig"
+
+	var problems []string
+	host := strings.TrimSpace(cfg.Host)
+	if !isPlaceholder(host) && !isValidHost(host) {
+		problems = append(problems, fmt.Sprintf("host %q must be a hostname or IP address, without scheme or port", host))
+	}
+	if cfg.Port < 1 || cfg.Port > 65535 {
+		problems = append(problems, fmt.Sprintf("port %d must be between 1 and 65535", cfg.Port))
+	}
+	if v := strings.TrimSpace(cfg.From); !isPlaceholder(v) && !isValidEmail(v) {
+		problems = append(problems, fmt.Sprintf("from %q is not a valid email address", v))
+	}
+	if v := strings.TrimSpace(cfg.To); !isPlaceholder(v) && !isValidEmail(v) {
+		problems = append(problems, fmt.Sprintf("to %q is not a valid email address", v))
+	}
+	if v := cfg.SmtpDialTimeoutSec; v < minSmtpDialTimeoutSec || v > maxSmtpDialTimeoutSec {
+		problems = append(problems, fmt.Sprintf("smtp_dial_timeout_sec %d must be between %d and %d", v, minSmtpDialTimeoutSec, maxSmtpDialTimeoutSec))
+	}
+	if v := cfg.SmtpRetryCount; v < 0 || v > maxSmtpRetryCount {
+		problems = append(problems, fmt.Sprintf("smtp_retry_count %d must be between 0 and %d", v, maxSmtpRetryCount))
+	}
+	if v := cfg.SmtpRetryDelaySec; v < 0 || v > maxSmtpRetryDelaySec {
+		problems = append(problems, fmt.Sprintf("smtp_retry_delay_sec %d must be between 0 and %d", v, maxSmtpRetryDelaySec))
+	}
+	if len(problems) > 0 {
+		return errors.New(op).Msgf("invalid email config: %s", strings.Join(problems, "; "))
+	}
+
+	if !cfg.Enabled {
+		return nil
+	}
+	var missing []string
+	if isPlaceholder(cfg.Host) {
+		missing = append(missing, "host")
+	}
+	if isPlaceholder(cfg.From) {
+		missing = append(missing, "from")
+	}
+	if isPlaceholder(cfg.To) && len(opts.To) == 0 {
+		missing = append(missing, "to")
+	}
+	if opts.AuthMechanism != EmailAuthNone {
+		if isPlaceholder(cfg.Username) {
+			missing = append(missing, "username")
+		}
+		if isPlaceholder(cfg.Password) {
+			missing = append(missing, "password")
+		}
+	}
+	if len(missing) > 0 {
+		return errors.New(op).Err(ErrEmailNotConfigured).Msgf("email enabled but not configured: %s must be set", strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+// validateEmailOptions checks the recipients, TLS mode, auth mechanism and templates. Every template,
+// including EmailConfig.Subject and Body, is parsed and executed against sample data so mistakes are
+// reported at load time rather than when an email is sent. All problems found are reported together.
+func validateEmailOptions(opts EmailOptions, cfg types.EmailConfig) error {
+	const op errors.Op = "config.validateEmailOptions"
+
+	var problems []string
+	for _, list := range []struct {
+		name  string
+		addrs []string
+	}{
+		{"to", opts.To},
+		{"cc", opts.Cc},
+		{"bcc", opts.Bcc},
+	} {
+		for i, addr := range list.addrs {
+			if !isValidEmail(addr) {
+				problems = append(problems, fmt.Sprintf("email_options.%s[%d] %q is not a valid email address", list.name, i, addr))
+			}
+		}
+	}
+
+	switch opts.TLSMode {
+	case EmailTLSStartTLS, EmailTLSImplicit, EmailTLSNone:
+	default:
+		problems = append(problems, fmt.Sprintf("email_options.tls_mode %q must be one of %s, %s or %s",
+			opts.TLSMode, EmailTLSStartTLS, EmailTLSImplicit, EmailTLSNone))
+	}
+	switch opts.AuthMechanism {
+	case EmailAuthPlain, EmailAuthLogin, EmailAuthCramMD5, EmailAuthNone:
+	default:
+		problems = append(problems, fmt.Sprintf("email_options.auth_mechanism %q must be one of %s, %s, %s or %s",
+			opts.AuthMechanism, EmailAuthPlain, EmailAuthLogin, EmailAuthCramMD5, EmailAuthNone))
+	}
+
+	seen := make(map[string]bool, len(opts.Templates))
+	for i, t := range opts.Templates {
+		id := fmt.Sprintf("email_options.templates[%d] %q", i, t.Name)
+		if strings.TrimSpace(t.Name) == "" {
+			problems = append(problems, fmt.Sprintf("email_options.templates[%d]: name is required", i))
+			continue
+		}
+		if seen[t.Name] {
+			problems = append(problems, fmt.Sprintf("%s: duplicate template name", id))
+			continue
+		}
+		seen[t.Name] = true
+		if _, _, err := renderEmailTemplate(t, sampleEmailTemplateData); err != nil {
+			problems = append(problems, fmt.Sprintf("%s: %s", id, err))
+		}
+	}
+	if _, _, err := renderEmailTemplate(EmailTemplate{Subject: cfg.Subject, Body: cfg.Body}, sampleEmailTemplateData); err != nil {
+		problems = append(problems, fmt.Sprintf("email_configs subject/body: %s", err))
+	}
+
+	if len(problems) > 0 {
+		return errors.New(op).Msgf("invalid email options: %s", strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// EmailOptions returns the recipients, TLS, authentication and template settings of the email service.
+func (s *Service) EmailOptions() (EmailOptions, error) {
+	const op errors.Op = "config.Service.EmailOptions"
+	if !s.isInitialized.Load() {
+		return EmailOptions{}, errors.New(op).Msg(errMsgNotInitialized)
+	}
+
+	snap := s.current()
+	return deepCopy(snap.Extensions.EmailOptions), nil
+}
+
+// SetEmailOptions replaces the email options and persists the change.
+func (s *Service) SetEmailOptions(opts EmailOptions) error {
+	const op errors.Op = "config.Service.SetEmailOptions"
+	if !s.isInitialized.Load() {
+		return errors.New(op).Msg(errMsgNotInitialized)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	snap := s.current()
+
+	ext := snap.Extensions
+	ext.EmailOptions = opts
+	if err := s.saveConfig(snap.AppConfig, ext); err != nil {
+		return errors.New(op).Err(err)
+	}
+	return nil
+}
+
+// EmailRecipients returns the effective recipients: EmailConfig.To followed by EmailOptions.To, and the Cc and
+// Bcc lists. Duplicates and placeholder values are dropped.
+func (s *Service) EmailRecipients() (EmailRecipients, error) {
+	const op errors.Op = "config.Service.EmailRecipients"
+	if !s.isInitialized.Load() {
+		return EmailRecipients{}, errors.New(op).Msg(errMsgNotInitialized)
+	}
+
+	snap := s.current()
+
+	dedupe := func(addrs ...string) []string {
+		out := make([]string, 0, len(addrs))
+		for _, a := range addrs {
+			a = strings.TrimSpace(a)
+			if a == "" || isPlaceholder(a) || slices.Contains(out, a) {
+				continue
+			}
+			out = append(out, a)
+		}
+		return out
+	}
+
+	opts := snap.Extensions.EmailOptions
+	return EmailRecipients{
+		To:  dedupe(append([]string{snap.AppConfig.EmailConfigs.To}, opts.To...)...),
+		Cc:  dedupe(opts.Cc...),
+		Bcc: dedupe(opts.Bcc...),
+	}, nil
+}
+
+// RenderEmail executes the named template with data and returns the subject and body. Configured templates
+// take precedence over the built-in EmailTemplateQsoForwardSummary and EmailTemplateErrorAlert defaults.
+func (s *Service) RenderEmail(name string, data EmailTemplateData) (string, string, error) {
+	const op errors.Op = "config.Service.RenderEmail"
+	if !s.isInitialized.Load() {
+		return "", "", errors.New(op).Msg(errMsgNotInitialized)
+	}
+
+	snap := s.current()
+
+	tmpl, ok := emailTemplate(snap.Extensions.EmailOptions, strings.TrimSpace(name))
+	if !ok {
+		return "", "", errors.New(op).Msgf("email template not found: %s", name)
+	}
+	subject, body, err := renderEmailTemplate(tmpl, data)
+	if err != nil {
+		return "", "", errors.New(op).Err(err).Msgf("rendering email template %s: %s", name, err)
+	}
+	return subject, body, nil
+}
@@ -0,0 +1,176 @@
+package config
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Station-Manager/types"
+)
+
+func TestValidateEmailOptions(t *testing.T) {
+	opts := defaultEmailOptions
+	if err := validateEmailOptions(opts, defaultEmailConfigs); err != nil {
+		t.Fatalf("defaults should be valid, got %v", err)
+	}
+
+	bad := opts
+	bad.TLSMode = "ssl"
+	bad.Cc = []string{"not-an-email"}
+	bad.Templates = []EmailTemplate{
+		{Name: "missing-field", Subject: "{{.Nope}}"},
+		{Name: "unparsable", Body: "{{if}}"},
+		{Name: "multiline", Subject: "a{{\"\\n\"}}b"},
+	}
+	err := validateEmailOptions(bad, defaultEmailConfigs)
+	if err == nil {
+		t.Fatalf("expected error for invalid options")
+	}
+	for _, want := range []string{"tls_mode", "cc[0]", "missing-field", "unparsable", "multiline"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("expected %q to be reported, got %v", want, err)
+		}
+	}
+
+	cfg := defaultEmailConfigs
+	cfg.Subject = "{{.Unknown}}"
+	if err = validateEmailOptions(opts, cfg); err == nil {
+		t.Errorf("expected error for invalid EmailConfig subject")
+	}
+}
+
+func TestValidateEmailConfig(t *testing.T) {
+	opts := defaultEmailOptions
+	cfg := defaultEmailConfigs
+	if err := validateEmailConfig(cfg, opts); err != nil {
+		t.Fatalf("disabled email with placeholders should be valid, got %v", err)
+	}
+
+	cfg.Enabled = true
+	err := validateEmailConfig(cfg, opts)
+	if !errors.Is(err, ErrEmailNotConfigured) {
+		t.Fatalf("expected ErrEmailNotConfigured, got %v", err)
+	}
+	for _, want := range []string{"host", "from", "to", "username", "password"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("expected %q to be reported as missing, got %v", want, err)
+		}
+	}
+
+	cfg.Host, cfg.From, cfg.Username, cfg.Password = "smtp.example.com", "g4abc@example.com", "g4abc", "secret"
+	if err = validateEmailConfig(cfg, opts); !errors.Is(err, ErrEmailNotConfigured) || !strings.Contains(err.Error(), "to") {
+		t.Errorf("expected missing recipient, got %v", err)
+	}
+	opts.To = []string{"ops@example.com"}
+	if err = validateEmailConfig(cfg, opts); err != nil {
+		t.Errorf("validateEmailConfig() error = %v", err)
+	}
+
+	relay := cfg
+	relay.Username, relay.Password = placeholderValue, placeholderValue
+	if err = validateEmailConfig(relay, EmailOptions{AuthMechanism: EmailAuthNone, To: opts.To}); err != nil {
+		t.Errorf("credentials should not be required without authentication, got %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		modify func(c *types.EmailConfig)
+	}{
+		{"host with scheme", func(c *types.EmailConfig) { c.Host = "smtp://smtp.example.com" }},
+		{"host with port", func(c *types.EmailConfig) { c.Host = "smtp.example.com:587" }},
+		{"port", func(c *types.EmailConfig) { c.Port = 70000 }},
+		{"from", func(c *types.EmailConfig) { c.From = "G4ABC" }},
+		{"dial timeout", func(c *types.EmailConfig) { c.SmtpDialTimeoutSec = 600 }},
+		{"retry count", func(c *types.EmailConfig) { c.SmtpRetryCount = -1 }},
+		{"retry delay", func(c *types.EmailConfig) { c.SmtpRetryDelaySec = 7200 }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := cfg
+			tt.modify(&c)
+			err := validateEmailConfig(c, opts)
+			if err == nil {
+				t.Fatalf("expected error")
+			}
+			if errors.Is(err, ErrEmailNotConfigured) {
+				t.Errorf("invalid values should not be reported as not configured: %v", err)
+			}
+		})
+	}
+
+	ip := cfg
+	ip.Host = "192.0.2.10"
+	if err = validateEmailConfig(ip, opts); err != nil {
+		t.Errorf("IP address host should be valid, got %v", err)
+	}
+}
+
+func TestApplyEmailDefaults(t *testing.T) {
+	opts := EmailOptions{}
+	applyEmailDefaults(&opts, &types.EmailConfig{Port: 465})
+	if opts.TLSMode != EmailTLSImplicit || opts.AuthMechanism != EmailAuthPlain {
+		t.Errorf("unexpected defaults for port 465: %+v", opts)
+	}
+	opts = EmailOptions{TLSMode: " STARTTLS "}
+	applyEmailDefaults(&opts, &types.EmailConfig{Port: 465})
+	if opts.TLSMode != EmailTLSStartTLS {
+		t.Errorf("expected explicit TLS mode to be kept, got %q", opts.TLSMode)
+	}
+
+	cfg := types.EmailConfig{}
+	applyEmailDefaults(&EmailOptions{TLSMode: EmailTLSImplicit}, &cfg)
+	if cfg.Port != 465 || cfg.SmtpDialTimeoutSec != defaultEmailConfigs.SmtpDialTimeoutSec {
+		t.Errorf("unexpected SMTP defaults: %+v", cfg)
+	}
+}
+
+func TestEmailOptions_service(t *testing.T) {
+	workDir := t.TempDir()
+	svc := &Service{WorkingDir: workDir}
+	if err := svc.Initialize(); err != nil {
+		t.Fatalf("Initialize() error = %v", err)
+	}
+
+	data := EmailTemplateData{
+		StationCallsign: "G4ABC",
+		Time:            time.Date(2025, 6, 1, 10, 0, 0, 0, time.UTC),
+		Forwarder:       LotwForwardingServiceName,
+		QSOs:            []EmailQso{{Call: "K1ABC", Band: "20m", Mode: "FT8"}, {Call: "JA1XYZ", Band: "40m", Mode: "CW"}},
+	}
+	subject, body, err := svc.RenderEmail(EmailTemplateQsoForwardSummary, data)
+	if err != nil {
+		t.Fatalf("RenderEmail() error = %v", err)
+	}
+	if subject != "[G4ABC] 2 QSO(s) forwarded to lotw" || !strings.Contains(body, "JA1XYZ") {
+		t.Errorf("unexpected rendering: %q / %q", subject, body)
+	}
+	if _, _, err = svc.RenderEmail("missing", data); err == nil {
+		t.Errorf("expected error for unknown template")
+	}
+
+	opts, _ := svc.EmailOptions()
+	opts.To = []string{"ops@example.com"}
+	opts.Bcc = []string{"archive@example.com"}
+	opts.Templates = []EmailTemplate{{Name: EmailTemplateErrorAlert, Subject: "{{upper .Forwarder}} failed", Body: "{{.Error}}"}}
+	if err = svc.SetEmailOptions(opts); err != nil {
+		t.Fatalf("SetEmailOptions() error = %v", err)
+	}
+
+	reloaded := &Service{WorkingDir: workDir}
+	if err = reloaded.Initialize(); err != nil {
+		t.Fatalf("Initialize() error = %v", err)
+	}
+	rcpt, err := reloaded.EmailRecipients()
+	if err != nil {
+		t.Fatalf("EmailRecipients() error = %v", err)
+	}
+	// The default EmailConfig.To is a placeholder and is dropped.
+	if len(rcpt.To) != 1 || rcpt.To[0] != "ops@example.com" || len(rcpt.Bcc) != 1 || len(rcpt.Cc) != 0 {
+		t.Errorf("unexpected recipients: %+v", rcpt)
+	}
+	subject, body, err = reloaded.RenderEmail(EmailTemplateErrorAlert, EmailTemplateData{Forwarder: "eqsl", Error: "timeout"})
+	if err != nil || subject != "EQSL failed" || body != "timeout" {
+		t.Errorf("expected overridden template, got %q / %q, %v", subject, body, err)
+	}
+}
@@ -1,5 +1,58 @@
 package config
 
+import (
+	stderr "errors"
+	"fmt"
+
+	"github.com/Station-Manager/errors"
+)
+
 var (
 	errMsgWorkingDir = "Working directory is not set."
 )
+
+var (
+	// ErrForwarderNotFound is returned when no forwarder with the requested name is configured. It also matches
+	// errors.ErrNotFound.
+	ErrForwarderNotFound = fmt.Errorf("forwarder %w", errors.ErrNotFound)
+	// ErrForwarderDisabled is returned when the requested forwarder is configured but not enabled.
+	ErrForwarderDisabled = stderr.New("Forwarder is disabled")
+	// ErrEmailNotConfigured is returned when the email service is enabled while required SMTP settings are
+	// missing or still placeholders.
+	ErrEmailNotConfigured = stderr.New("Email is enabled but not configured")
+	// ErrServerNotConfigured is returned for server settings when the configuration is not in server mode.
+	ErrServerNotConfigured = stderr.New("Server is not configured")
+	// ErrConfigNotFound is returned when the configuration file does not exist and may not be generated, as in
+	// read-only mode. It also matches errors.ErrNotFound.
+	ErrConfigNotFound = fmt.Errorf("config file %w", errors.ErrNotFound)
+	// ErrPathNotFound is returned by Get and Set when a path names a field, list element or map entry that does
+	// not exist. It also matches errors.ErrNotFound.
+	ErrPathNotFound = fmt.Errorf("configuration path %w", errors.ErrNotFound)
+	// ErrETagMismatch is returned by PatchAppConfig when the configuration has changed since the patch was built.
+	ErrETagMismatch = stderr.New("Configuration has changed since it was read")
+	// ErrRevisionNotFound is returned by Rollback for a revision that is not in the history. It also matches
+	// errors.ErrNotFound.
+	ErrRevisionNotFound = fmt.Errorf("configuration revision %w", errors.ErrNotFound)
+	// ErrReadOnly is returned for any change, or file that would need to be written, in read-only mode.
+	ErrReadOnly = stderr.New("Configuration is read-only")
+)
+
+// ErrorMessage returns the most specific message in the error chain of err. Errors returned by the Service
+// wrap their causes, and the outer levels often carry only the generic message of errors.New, so err.Error()
+// is not always useful to show to a user.
+func ErrorMessage(err error) string {
+	if err == nil {
+		return ""
+	}
+	generic := errors.New("").Error()
+	for e := err; e != nil; e = stderr.Unwrap(e) {
+		d, ok := e.(*errors.DetailedError)
+		if !ok {
+			return e.Error()
+		}
+		if msg := d.Error(); msg != generic {
+			return msg
+		}
+	}
+	return err.Error()
+}
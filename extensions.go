@@ -0,0 +1,96 @@
+package config
+
+import (
+	"bytes"
+
+	"github.com/Station-Manager/errors"
+	"github.com/Station-Manager/types"
+	"github.com/goccy/go-json"
+)
+
+// Extensions holds configuration sections owned by the config package rather than the shared types module.
+// They are stored as additional top-level sections in config.json, next to the types.AppConfig sections.
+type Extensions struct {
+	StationOptions         StationOptions          `json:"station_options"`
+	ListenerNetworkConfigs []ListenerNetworkConfig `json:"listener_network_configs,omitempty"`
+	LookupOptions          LookupOptions           `json:"lookup_options"`
+	ForwarderOptions       []ForwarderOptions      `json:"forwarder_options,omitempty"`
+	EmailOptions           EmailOptions            `json:"email_options"`
+	ServerOptions          *ServerOptions          `json:"server_options,omitempty"`
+}
+
+// StationOptions controls how the LoggingStation section is validated.
+type StationOptions struct {
+	// AllowCallsignMismatch disables the check that StationCallsign is derived from OwnerCallsign. Set this
+	// when operating a club, contest or special-event station under a callsign that is not the owner's.
+	AllowCallsignMismatch bool `json:"allow_callsign_mismatch"`
+}
+
+// marshalConfigFile renders the AppConfig and Extensions as a single pretty-printed JSON document.
+// The two objects are marshalled separately and their members spliced into one object. A wrapper struct
+// embedding both cannot be used: goccy/go-json v0.10.6 panics with a nil pointer dereference when it encodes
+// an embedded struct whose first field is a struct followed by a slice or pointer field, as in Extensions.
+func marshalConfigFile(cfg types.AppConfig, ext Extensions) ([]byte, error) {
+	const op errors.Op = "config.marshalConfigFile"
+
+	appData, err := json.Marshal(cfg)
+	if err != nil {
+		return nil, errors.New(op).Err(err)
+	}
+	extData, err := json.Marshal(ext)
+	if err != nil {
+		return nil, errors.New(op).Err(err)
+	}
+
+	appData = bytes.TrimSpace(appData)
+	extData = bytes.TrimSpace(extData)
+	if !isJSONObject(appData) || !isJSONObject(extData) {
+		return nil, errors.New(op).Msg("the configuration sections must encode as JSON objects")
+	}
+
+	var buf bytes.Buffer
+	buf.Write(appData[:len(appData)-1])
+	if len(extData) > 2 { // More than just "{}"
+		if len(appData) > 2 {
+			buf.WriteByte(',')
+		}
+		buf.Write(extData[1 : len(extData)-1])
+	}
+	buf.WriteByte('}')
+
+	var out bytes.Buffer
+	if err = json.Indent(&out, buf.Bytes(), "", "  "); err != nil {
+		return nil, errors.New(op).Err(err)
+	}
+
+	return out.Bytes(), nil
+}
+
+// isJSONObject reports whether data, an encoded value, is a JSON object.
+func isJSONObject(data []byte) bool {
+	return len(data) >= 2 && data[0] == '{' && data[len(data)-1] == '}'
+}
+
+// unmarshalConfigFile decodes a config.json document into its AppConfig and Extensions parts. In server mode,
+// fields the document leaves out take the server profile defaults.
+func unmarshalConfigFile(data []byte, cfg *types.AppConfig, ext *Extensions) error {
+	const op errors.Op = "config.unmarshalConfigFile"
+
+	if err := json.Unmarshal(data, cfg); err != nil {
+		return errors.New(op).Err(err)
+	}
+	if profileOf(cfg) == ProfileServer {
+		// Decoding again over the server profile defaults completes sections written by older releases, such as
+		// an empty required_configs, without replacing values the document sets to zero.
+		merged := serverProfileBase(cfg.DatastoreConfig.Driver)
+		if err := json.Unmarshal(data, &merged); err != nil {
+			return errors.New(op).Err(err)
+		}
+		*cfg = merged
+	}
+	if err := json.Unmarshal(data, ext); err != nil {
+		return errors.New(op).Err(err)
+	}
+
+	return nil
+}
@@ -0,0 +1,204 @@
+package config
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/Station-Manager/errors"
+	"github.com/Station-Manager/types"
+)
+
+// Package-wide retry defaults, used when a forwarder does not override them. Poll interval and batch size
+// fall back to RequiredConfigs.QsoForwardingPollIntervalSeconds and RequiredConfigs.QsoForwardingRowLimit.
+const (
+	defaultForwarderMaxRetries     = 3
+	defaultForwarderBackoffInitial = 30   // Seconds
+	defaultForwarderBackoffMax     = 3600 // Seconds
+	defaultForwarderBackoffFactor  = 2.0
+)
+
+// ForwarderSchedule overrides the global forwarding cadence and retry policy for one forwarder. Nil fields
+// inherit the global value.
+type ForwarderSchedule struct {
+	// PollIntervalSeconds is how often queued QSOs are checked for this forwarder.
+	PollIntervalSeconds *int `json:"poll_interval_seconds,omitempty"`
+	// BatchSize is the maximum number of QSOs uploaded per poll.
+	BatchSize *int `json:"batch_size,omitempty"`
+	// MaxRetries is the number of times a failed upload is retried. Zero disables retries.
+	MaxRetries *int `json:"max_retries,omitempty"`
+	// BackoffInitialSeconds is the delay before the first retry.
+	BackoffInitialSeconds *int `json:"backoff_initial_seconds,omitempty"`
+	// BackoffMaxSeconds caps the delay between retries.
+	BackoffMaxSeconds *int `json:"backoff_max_seconds,omitempty"`
+	// BackoffMultiplier is the factor the delay grows by after each retry.
+	BackoffMultiplier *float64 `json:"backoff_multiplier,omitempty"`
+	// QuietHours are daily UTC periods during which nothing is uploaded.
+	QuietHours []QuietHours `json:"quiet_hours,omitempty"`
+}
+
+// QuietHours is a daily UTC period in "HH:MM" form. Start is inclusive and End exclusive; a period may wrap
+// past midnight, e.g. 22:00-06:00.
+type QuietHours struct {
+	Start string `json:"start"`
+	End   string `json:"end"`
+}
+
+// EffectiveForwarderSchedule is the resolved schedule of a forwarder, with every value set.
+type EffectiveForwarderSchedule struct {
+	PollInterval      time.Duration
+	BatchSize         int
+	MaxRetries        int
+	BackoffInitial    time.Duration
+	BackoffMax        time.Duration
+	BackoffMultiplier float64
+	QuietHours        []QuietHours
+}
+
+// Backoff returns the delay before the given retry attempt, starting at 1. The delay grows exponentially from
+// BackoffInitial and is capped at BackoffMax.
+func (e EffectiveForwarderSchedule) Backoff(attempt int) time.Duration {
+	if attempt < 1 {
+		attempt = 1
+	}
+	delay := float64(e.BackoffInitial) * math.Pow(e.BackoffMultiplier, float64(attempt-1))
+	if delay > float64(e.BackoffMax) {
+		return e.BackoffMax
+	}
+	return time.Duration(delay)
+}
+
+// InQuietHours reports whether t falls within any of the quiet hours.
+func (e EffectiveForwarderSchedule) InQuietHours(t time.Time) bool {
+	t = t.UTC()
+	now := t.Hour()*60 + t.Minute()
+	for _, q := range e.QuietHours {
+		start, err1 := parseClock(q.Start)
+		end, err2 := parseClock(q.End)
+		if err1 != nil || err2 != nil {
+			continue
+		}
+		if start < end && now >= start && now < end {
+			return true
+		}
+		if start > end && (now >= start || now < end) {
+			return true
+		}
+	}
+	return false
+}
+
+// parseClock parses "HH:MM" into minutes past midnight.
+func parseClock(v string) (int, error) {
+	t, err := time.Parse("15:04", strings.TrimSpace(v))
+	if err != nil {
+		return 0, err
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+// resolveForwarderSchedule merges a forwarder's overrides with the global defaults. req must already have had
+// applyForwardingDefaults applied.
+func resolveForwarderSchedule(sched *ForwarderSchedule, req types.RequiredConfigs) EffectiveForwarderSchedule {
+	eff := EffectiveForwarderSchedule{
+		PollInterval:      req.QsoForwardingPollIntervalSeconds * time.Second,
+		BatchSize:         req.QsoForwardingRowLimit,
+		MaxRetries:        defaultForwarderMaxRetries,
+		BackoffInitial:    defaultForwarderBackoffInitial * time.Second,
+		BackoffMax:        defaultForwarderBackoffMax * time.Second,
+		BackoffMultiplier: defaultForwarderBackoffFactor,
+	}
+	if sched == nil {
+		return eff
+	}
+
+	if sched.PollIntervalSeconds != nil {
+		eff.PollInterval = time.Duration(*sched.PollIntervalSeconds) * time.Second
+	}
+	if sched.BatchSize != nil {
+		eff.BatchSize = *sched.BatchSize
+	}
+	if sched.MaxRetries != nil {
+		eff.MaxRetries = *sched.MaxRetries
+	}
+	if sched.BackoffInitialSeconds != nil {
+		eff.BackoffInitial = time.Duration(*sched.BackoffInitialSeconds) * time.Second
+	}
+	if sched.BackoffMaxSeconds != nil {
+		eff.BackoffMax = time.Duration(*sched.BackoffMaxSeconds) * time.Second
+	}
+	if sched.BackoffMultiplier != nil {
+		eff.BackoffMultiplier = *sched.BackoffMultiplier
+	}
+	eff.QuietHours = append([]QuietHours(nil), sched.QuietHours...)
+
+	return eff
+}
+
+// validateForwarderSchedule checks the overrides and the resolved backoff policy.
+func validateForwarderSchedule(sched *ForwarderSchedule, req types.RequiredConfigs) []string {
+	if sched == nil {
+		return nil
+	}
+
+	var problems []string
+	if v := sched.PollIntervalSeconds; v != nil && (*v < 10 || *v > 86400) {
+		problems = append(problems, fmt.Sprintf("schedule.poll_interval_seconds %d must be between 10 and 86400", *v))
+	}
+	if v := sched.BatchSize; v != nil && (*v < 1 || *v > 1000) {
+		problems = append(problems, fmt.Sprintf("schedule.batch_size %d must be between 1 and 1000", *v))
+	}
+	if v := sched.MaxRetries; v != nil && (*v < 0 || *v > 20) {
+		problems = append(problems, fmt.Sprintf("schedule.max_retries %d must be between 0 and 20", *v))
+	}
+	if v := sched.BackoffInitialSeconds; v != nil && *v < 1 {
+		problems = append(problems, fmt.Sprintf("schedule.backoff_initial_seconds %d must be at least 1", *v))
+	}
+	if v := sched.BackoffMultiplier; v != nil && (*v < 1 || *v > 10) {
+		problems = append(problems, fmt.Sprintf("schedule.backoff_multiplier %g must be between 1 and 10", *v))
+	}
+
+	eff := resolveForwarderSchedule(sched, req)
+	if eff.BackoffMax < eff.BackoffInitial {
+		problems = append(problems, "schedule.backoff_max_seconds cannot be less than backoff_initial_seconds")
+	}
+
+	for i, q := range sched.QuietHours {
+		start, err := parseClock(q.Start)
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("schedule.quiet_hours[%d].start %q must be HH:MM", i, q.Start))
+			continue
+		}
+		end, err := parseClock(q.End)
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("schedule.quiet_hours[%d].end %q must be HH:MM", i, q.End))
+			continue
+		}
+		if start == end {
+			problems = append(problems, fmt.Sprintf("schedule.quiet_hours[%d] start and end cannot be equal", i))
+		}
+	}
+
+	return problems
+}
+
+// ForwarderSchedule returns the effective schedule of the named forwarder: its overrides merged with the
+// global forwarding settings in RequiredConfigs and the package retry defaults.
+func (s *Service) ForwarderSchedule(serviceName string) (EffectiveForwarderSchedule, error) {
+	const op errors.Op = "config.Service.ForwarderSchedule"
+	emptyRetVal := EffectiveForwarderSchedule{}
+	if !s.isInitialized.Load() {
+		return emptyRetVal, errors.New(op).Msg(errMsgNotInitialized)
+	}
+
+	snap := s.current()
+
+	serviceName = strings.TrimSpace(serviceName)
+	if !slices.ContainsFunc(snap.AppConfig.ForwardingConfigs, func(c types.ForwarderConfig) bool { return c.Name == serviceName }) {
+		return emptyRetVal, errors.New(op).Err(ErrForwarderNotFound).Msgf("service config not found for: %s", serviceName)
+	}
+	opts := forwarderOptions(snap.Extensions.ForwarderOptions, serviceName)
+	return resolveForwarderSchedule(opts.Schedule, snap.AppConfig.RequiredConfigs), nil
+}
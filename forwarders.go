@@ -0,0 +1,466 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/Station-Manager/enums/upload"
+	"github.com/Station-Manager/errors"
+	"github.com/Station-Manager/types"
+)
+
+// Forwarder names. QRZ, LoTW, eQSL and Club Log match the upload.OnlineService values used to track the
+// upload status of each QSO.
+const (
+	QrzForwardingServiceName     = types.QrzForwardingServiceName
+	LotwForwardingServiceName    = string(upload.OnlineServiceLoTW)
+	EqslForwardingServiceName    = string(upload.OnlineServiceEQSL)
+	ClublogForwardingServiceName = string(upload.OnlineServiceClub)
+	HrdlogForwardingServiceName  = "hrdlog"
+)
+
+// ForwarderOptions holds the provider-specific settings of a forwarder that do not fit the generic
+// types.ForwarderConfig shape. It is linked to a types.ForwarderConfig by Name, and only the section matching
+// the forwarder's provider may be set.
+type ForwarderOptions struct {
+	Name    string          `json:"name"`
+	Lotw    *LotwOptions    `json:"lotw,omitempty"`
+	Eqsl    *EqslOptions    `json:"eqsl,omitempty"`
+	Clublog *ClublogOptions `json:"clublog,omitempty"`
+	Hrdlog  *HrdlogOptions  `json:"hrdlog,omitempty"`
+	// Schedule overrides the global forwarding cadence and retry policy for this forwarder.
+	Schedule *ForwarderSchedule `json:"schedule,omitempty"`
+}
+
+// LotwOptions configures uploads to ARRL Logbook of The World, which are signed and sent by TQSL.
+type LotwOptions struct {
+	// TqslPath is the path of the tqsl executable.
+	TqslPath string `json:"tqsl_path"`
+	// StationLocation is the name of the TQSL station location to sign with.
+	StationLocation string `json:"station_location"`
+	// CertificatePassword unlocks the callsign certificate's private key, if it is password protected.
+	CertificatePassword string `json:"certificate_password,omitempty"`
+}
+
+// EqslOptions configures uploads to eQSL.cc. The account username and password are held in the
+// ForwarderConfig.
+type EqslOptions struct {
+	// QthNickname selects the eQSL QTH profile when the account has more than one.
+	QthNickname string `json:"qth_nickname,omitempty"`
+}
+
+// ClublogOptions configures uploads to Club Log. The application key is held in ForwarderConfig.APIKey and the
+// account password in ForwarderConfig.Password.
+type ClublogOptions struct {
+	// Email is the Club Log account email address.
+	Email string `json:"email"`
+	// Callsign is the Club Log logbook to upload to. Empty uses the station callsign.
+	Callsign string `json:"callsign,omitempty"`
+}
+
+// HrdlogOptions configures uploads to HRDLog.net. The upload code is held in ForwarderConfig.APIKey.
+type HrdlogOptions struct {
+	// Callsign is the HRDLog.net logbook to upload to.
+	Callsign string `json:"callsign"`
+}
+
+// forwarderProvider describes a logbook service QSOs can be forwarded to: its defaults and the rules for
+// an enabled forwarder.
+type forwarderProvider struct {
+	defaults types.ForwarderConfig
+	// required lists the ForwarderConfig fields, by JSON name, that must be set when enabled.
+	required []string
+	// validate checks the provider-specific options of an enabled forwarder.
+	validate func(opts ForwarderOptions) []string
+}
+
+var forwarderProviders = map[string]forwarderProvider{
+	QrzForwardingServiceName: {
+		defaults: qrzForwarderDefaults,
+		required: []string{"apikey"},
+	},
+	LotwForwardingServiceName: {
+		defaults: lotwForwarderDefaults,
+		validate: validateLotwOptions,
+	},
+	EqslForwardingServiceName: {
+		defaults: eqslForwarderDefaults,
+		required: []string{"url", "username", "password"},
+	},
+	ClublogForwardingServiceName: {
+		defaults: clublogForwarderDefaults,
+		required: []string{"url", "apikey", "password"},
+		validate: validateClublogOptions,
+	},
+	HrdlogForwardingServiceName: {
+		defaults: hrdlogForwarderDefaults,
+		required: []string{"url", "apikey"},
+		validate: validateHrdlogOptions,
+	},
+}
+
+// ForwarderProviders returns the sorted names of the supported forwarders.
+func ForwarderProviders() []string {
+	names := make([]string, 0, len(forwarderProviders))
+	for name := range forwarderProviders {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ForwarderProviderDefaults returns the default, disabled configuration for the named forwarder.
+func ForwarderProviderDefaults(name string) (types.ForwarderConfig, error) {
+	const op errors.Op = "config.ForwarderProviderDefaults"
+	p, ok := forwarderProviders[strings.TrimSpace(name)]
+	if !ok {
+		return types.ForwarderConfig{}, errors.New(op).Msgf("unknown forwarder %q (available: %s)",
+			name, strings.Join(ForwarderProviders(), ", "))
+	}
+	return p.defaults, nil
+}
+
+func validateLotwOptions(opts ForwarderOptions) []string {
+	if opts.Lotw == nil {
+		return []string{"lotw options are required"}
+	}
+	var problems []string
+	if isPlaceholder(opts.Lotw.TqslPath) {
+		problems = append(problems, "lotw.tqsl_path is not configured")
+	}
+	if isPlaceholder(opts.Lotw.StationLocation) {
+		problems = append(problems, "lotw.station_location is not configured")
+	}
+	return problems
+}
+
+func validateClublogOptions(opts ForwarderOptions) []string {
+	if opts.Clublog == nil {
+		return []string{"clublog options are required"}
+	}
+	var problems []string
+	if !isValidEmail(opts.Clublog.Email) {
+		problems = append(problems, fmt.Sprintf("clublog.email %q is not a valid email address", opts.Clublog.Email))
+	}
+	if opts.Clublog.Callsign != "" {
+		if err := ValidateCallsign(opts.Clublog.Callsign); err != nil {
+			problems = append(problems, "clublog.callsign: "+err.Error())
+		}
+	}
+	return problems
+}
+
+func validateHrdlogOptions(opts ForwarderOptions) []string {
+	if opts.Hrdlog == nil {
+		return []string{"hrdlog options are required"}
+	}
+	if err := ValidateCallsign(opts.Hrdlog.Callsign); err != nil {
+		return []string{"hrdlog.callsign: " + err.Error()}
+	}
+	return nil
+}
+
+// forwarderField returns the value of a ForwarderConfig field by its JSON name.
+func forwarderField(cfg types.ForwarderConfig, field string) string {
+	switch field {
+	case "url":
+		return cfg.URL
+	case "apikey":
+		return cfg.APIKey
+	case "username":
+		return cfg.Username
+	case "password":
+		return cfg.Password
+	}
+	return ""
+}
+
+// forwarderOptions returns the provider-specific options for the named forwarder, or options with only the
+// name set if there are none.
+func forwarderOptions(all []ForwarderOptions, name string) ForwarderOptions {
+	for _, o := range all {
+		if o.Name == name {
+			return o
+		}
+	}
+	return ForwarderOptions{Name: name}
+}
+
+// validateForwarder checks a forwarder against its provider's rules. Disabled forwarders may be incomplete.
+func validateForwarder(cfg types.ForwarderConfig, opts ForwarderOptions) []string {
+	p, ok := forwarderProviders[cfg.Name]
+	if !ok {
+		return nil
+	}
+
+	var problems []string
+	sections := []struct {
+		name string
+		set  bool
+	}{
+		{LotwForwardingServiceName, opts.Lotw != nil},
+		{EqslForwardingServiceName, opts.Eqsl != nil},
+		{ClublogForwardingServiceName, opts.Clublog != nil},
+		{HrdlogForwardingServiceName, opts.Hrdlog != nil},
+	}
+	for _, section := range sections {
+		if section.set && section.name != cfg.Name {
+			problems = append(problems, fmt.Sprintf("%s options cannot be set on this forwarder", section.name))
+		}
+	}
+	if cfg.HttpTimeoutSec < 0 {
+		problems = append(problems, "timeout_sec cannot be negative")
+	}
+	if cfg.URL != "" {
+		if u, err := url.Parse(cfg.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			problems = append(problems, fmt.Sprintf("url %q must be an http or https URL", cfg.URL))
+		}
+	}
+	if !cfg.Enabled {
+		return problems
+	}
+
+	for _, field := range p.required {
+		if isPlaceholder(forwarderField(cfg, field)) {
+			problems = append(problems, fmt.Sprintf("enabled but %s is not configured", field))
+		}
+	}
+	if p.validate != nil {
+		problems = append(problems, p.validate(opts)...)
+	}
+	return problems
+}
+
+// validateForwarders checks every forwarder and its options. All problems found are reported together.
+func validateForwarders(cfgs []types.ForwarderConfig, opts []ForwarderOptions, req types.RequiredConfigs) error {
+	const op errors.Op = "config.validateForwarders"
+
+	var problems []string
+	names := make(map[string]int, len(cfgs))
+	for i, cfg := range cfgs {
+		if _, known := forwarderProviders[cfg.Name]; !known {
+			problems = append(problems, fmt.Sprintf("forwarding_configs[%d] %q: unknown forwarder (available: %s)",
+				i, cfg.Name, strings.Join(ForwarderProviders(), ", ")))
+			continue
+		}
+		if j, dup := names[cfg.Name]; dup {
+			problems = append(problems, fmt.Sprintf("forwarding_configs[%d] %q: already configured at forwarding_configs[%d]", i, cfg.Name, j))
+			continue
+		}
+		names[cfg.Name] = i
+		for _, p := range validateForwarder(cfg, forwarderOptions(opts, cfg.Name)) {
+			problems = append(problems, fmt.Sprintf("forwarding_configs[%d] %q: %s", i, cfg.Name, p))
+		}
+	}
+
+	seen := make(map[string]bool, len(opts))
+	for i, o := range opts {
+		if seen[o.Name] {
+			problems = append(problems, fmt.Sprintf("forwarder_options[%d] %q: duplicate entry for forwarder", i, o.Name))
+		}
+		seen[o.Name] = true
+		if !slices.ContainsFunc(cfgs, func(c types.ForwarderConfig) bool { return c.Name == o.Name }) {
+			problems = append(problems, fmt.Sprintf("forwarder_options[%d] %q: no forwarding_configs entry with this name", i, o.Name))
+		}
+		for _, p := range validateForwarderSchedule(o.Schedule, req) {
+			problems = append(problems, fmt.Sprintf("forwarder_options[%d] %q: %s", i, o.Name, p))
+		}
+	}
+
+	if len(problems) > 0 {
+		return errors.New(op).Msgf("invalid forwarder configuration: %s", strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// ForwarderOptions returns the provider-specific options of the named forwarder.
+func (s *Service) ForwarderOptions(serviceName string) (ForwarderOptions, error) {
+	const op errors.Op = "config.Service.ForwarderOptions"
+	emptyRetVal := ForwarderOptions{}
+	if !s.isInitialized.Load() {
+		return emptyRetVal, errors.New(op).Msg(errMsgNotInitialized)
+	}
+
+	snap := s.current()
+
+	serviceName = strings.TrimSpace(serviceName)
+	if !slices.ContainsFunc(snap.AppConfig.ForwardingConfigs, func(c types.ForwarderConfig) bool { return c.Name == serviceName }) {
+		return emptyRetVal, errors.New(op).Err(ErrForwarderNotFound).Msgf("service config not found for: %s", serviceName)
+	}
+	return deepCopy(forwarderOptions(snap.Extensions.ForwarderOptions, serviceName)), nil
+}
+
+// SetForwarderOptions adds or replaces the provider-specific options of the forwarder named in opts and
+// persists the change.
+func (s *Service) SetForwarderOptions(opts ForwarderOptions) error {
+	const op errors.Op = "config.Service.SetForwarderOptions"
+	if !s.isInitialized.Load() {
+		return errors.New(op).Msg(errMsgNotInitialized)
+	}
+
+	// The schedule is held by pointer; copying keeps the caller's value from aliasing the saved configuration.
+	opts = deepCopy(opts)
+	opts.Name = strings.TrimSpace(opts.Name)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	snap := s.current()
+
+	ext := snap.Extensions
+	ext.ForwarderOptions = slices.Clone(snap.Extensions.ForwarderOptions)
+	if idx := slices.IndexFunc(ext.ForwarderOptions, func(o ForwarderOptions) bool { return o.Name == opts.Name }); idx >= 0 {
+		ext.ForwarderOptions[idx] = opts
+	} else {
+		ext.ForwarderOptions = append(ext.ForwarderOptions, opts)
+	}
+
+	if err := s.saveConfig(snap.AppConfig, ext); err != nil {
+		return errors.New(op).Err(err)
+	}
+	return nil
+}
+
+// AddForwarderConfig adds a forwarder and persists the change. The name must be a supported forwarder that is
+// not already configured; zero-valued fields are filled from the forwarder's defaults.
+func (s *Service) AddForwarderConfig(cfg types.ForwarderConfig) error {
+	const op errors.Op = "config.Service.AddForwarderConfig"
+	if !s.isInitialized.Load() {
+		return errors.New(op).Msg(errMsgNotInitialized)
+	}
+
+	cfg.Name = strings.TrimSpace(cfg.Name)
+	p, ok := forwarderProviders[cfg.Name]
+	if !ok {
+		return errors.New(op).Msgf("unknown forwarder %q (available: %s)", cfg.Name, strings.Join(ForwarderProviders(), ", "))
+	}
+	if cfg.URL == "" {
+		cfg.URL = p.defaults.URL
+	}
+	if cfg.UserAgent == "" {
+		cfg.UserAgent = p.defaults.UserAgent
+	}
+	if cfg.HttpTimeoutSec == 0 {
+		cfg.HttpTimeoutSec = p.defaults.HttpTimeoutSec
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	snap := s.current()
+
+	if slices.ContainsFunc(snap.AppConfig.ForwardingConfigs, func(c types.ForwarderConfig) bool { return c.Name == cfg.Name }) {
+		return errors.New(op).Msgf("forwarder %q is already configured", cfg.Name)
+	}
+
+	updated := snap.AppConfig
+	updated.ForwardingConfigs = append(slices.Clone(snap.AppConfig.ForwardingConfigs), cfg)
+	if err := s.saveConfig(updated, snap.Extensions); err != nil {
+		return errors.New(op).Err(err)
+	}
+	return nil
+}
+
+// UpdateForwarderConfig replaces the configuration of an existing forwarder and persists the change.
+func (s *Service) UpdateForwarderConfig(cfg types.ForwarderConfig) error {
+	const op errors.Op = "config.Service.UpdateForwarderConfig"
+	if !s.isInitialized.Load() {
+		return errors.New(op).Msg(errMsgNotInitialized)
+	}
+
+	cfg.Name = strings.TrimSpace(cfg.Name)
+	if err := s.modifyForwarderConfig(cfg.Name, func(c *types.ForwarderConfig) { *c = cfg }); err != nil {
+		return errors.New(op).Err(err)
+	}
+	return nil
+}
+
+// EnableForwarder enables the named forwarder and persists the change. It fails if the forwarder's required
+// settings are not configured.
+func (s *Service) EnableForwarder(serviceName string) error {
+	const op errors.Op = "config.Service.EnableForwarder"
+	if !s.isInitialized.Load() {
+		return errors.New(op).Msg(errMsgNotInitialized)
+	}
+	if err := s.modifyForwarderConfig(strings.TrimSpace(serviceName), func(c *types.ForwarderConfig) { c.Enabled = true }); err != nil {
+		return errors.New(op).Err(err)
+	}
+	return nil
+}
+
+// DisableForwarder disables the named forwarder and persists the change.
+func (s *Service) DisableForwarder(serviceName string) error {
+	const op errors.Op = "config.Service.DisableForwarder"
+	if !s.isInitialized.Load() {
+		return errors.New(op).Msg(errMsgNotInitialized)
+	}
+	if err := s.modifyForwarderConfig(strings.TrimSpace(serviceName), func(c *types.ForwarderConfig) { c.Enabled = false }); err != nil {
+		return errors.New(op).Err(err)
+	}
+	return nil
+}
+
+// DeleteForwarderConfig removes the named forwarder, and its options, and persists the change.
+func (s *Service) DeleteForwarderConfig(serviceName string) error {
+	const op errors.Op = "config.Service.DeleteForwarderConfig"
+	if !s.isInitialized.Load() {
+		return errors.New(op).Msg(errMsgNotInitialized)
+	}
+
+	serviceName = strings.TrimSpace(serviceName)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	snap := s.current()
+
+	idx := slices.IndexFunc(snap.AppConfig.ForwardingConfigs, func(c types.ForwarderConfig) bool { return c.Name == serviceName })
+	if idx < 0 {
+		return errors.New(op).Err(ErrForwarderNotFound).Msgf("service config not found for: %s", serviceName)
+	}
+
+	updated := snap.AppConfig
+	updated.ForwardingConfigs = slices.Delete(slices.Clone(snap.AppConfig.ForwardingConfigs), idx, idx+1)
+	ext := snap.Extensions
+	ext.ForwarderOptions = slices.DeleteFunc(slices.Clone(snap.Extensions.ForwarderOptions),
+		func(o ForwarderOptions) bool { return o.Name == serviceName })
+
+	if err := s.saveConfig(updated, ext); err != nil {
+		return errors.New(op).Err(err)
+	}
+	return nil
+}
+
+// modifyForwarderConfig applies fn to a copy of the named forwarder configuration and saves the result.
+func (s *Service) modifyForwarderConfig(serviceName string, fn func(*types.ForwarderConfig)) error {
+	const op errors.Op = "config.Service.modifyForwarderConfig"
+
+	if serviceName == "" {
+		return errors.New(op).Msg("service name cannot be empty")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	snap := s.current()
+
+	idx := slices.IndexFunc(snap.AppConfig.ForwardingConfigs, func(c types.ForwarderConfig) bool { return c.Name == serviceName })
+	if idx < 0 {
+		return errors.New(op).Err(ErrForwarderNotFound).Msgf("service config not found for: %s", serviceName)
+	}
+
+	updated := snap.AppConfig
+	updated.ForwardingConfigs = slices.Clone(snap.AppConfig.ForwardingConfigs)
+	fn(&updated.ForwardingConfigs[idx])
+	if updated.ForwardingConfigs[idx].Name != serviceName {
+		return errors.New(op).Msg("forwarder name cannot be changed")
+	}
+
+	if err := s.saveConfig(updated, snap.Extensions); err != nil {
+		return errors.New(op).Err(err)
+	}
+	return nil
+}
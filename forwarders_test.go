@@ -0,0 +1,213 @@
+package config
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	smerrors "github.com/Station-Manager/errors"
+	"github.com/Station-Manager/types"
+)
+
+func TestValidateForwarder(t *testing.T) {
+	clublog, err := ForwarderProviderDefaults(ClublogForwardingServiceName)
+	if err != nil {
+		t.Fatalf("ForwarderProviderDefaults() error = %v", err)
+	}
+	if p := validateForwarder(clublog, ForwarderOptions{Name: clublog.Name}); len(p) != 0 {
+		t.Errorf("disabled forwarder should be valid, got %v", p)
+	}
+
+	clublog.Enabled = true
+	if p := validateForwarder(clublog, ForwarderOptions{Name: clublog.Name}); len(p) == 0 {
+		t.Errorf("expected problems for enabled forwarder without credentials")
+	}
+
+	clublog.APIKey, clublog.Password = "appkey", "secret"
+	opts := ForwarderOptions{Name: clublog.Name, Clublog: &ClublogOptions{Email: "not-an-email"}}
+	if p := validateForwarder(clublog, opts); len(p) != 1 {
+		t.Errorf("expected one problem for invalid email, got %v", p)
+	}
+	opts.Clublog.Email = "g4abc@example.com"
+	if p := validateForwarder(clublog, opts); len(p) != 0 {
+		t.Errorf("validateForwarder() = %v", p)
+	}
+
+	opts.Lotw = &LotwOptions{}
+	if p := validateForwarder(clublog, opts); len(p) != 1 {
+		t.Errorf("expected problem for options of another provider, got %v", p)
+	}
+}
+
+func TestForwarderCRUD(t *testing.T) {
+	workDir := t.TempDir()
+	svc := &Service{WorkingDir: workDir}
+	if err := svc.Initialize(); err != nil {
+		t.Fatalf("Initialize() error = %v", err)
+	}
+
+	if err := svc.EnableForwarder(LotwForwardingServiceName); err == nil {
+		t.Fatalf("expected error enabling LoTW without TQSL settings")
+	}
+	err := svc.SetForwarderOptions(ForwarderOptions{
+		Name: LotwForwardingServiceName,
+		Lotw: &LotwOptions{TqslPath: "/usr/bin/tqsl", StationLocation: "Home"},
+	})
+	if err != nil {
+		t.Fatalf("SetForwarderOptions() error = %v", err)
+	}
+	if err = svc.EnableForwarder(LotwForwardingServiceName); err != nil {
+		t.Fatalf("EnableForwarder() error = %v", err)
+	}
+
+	if err = svc.DeleteForwarderConfig(HrdlogForwardingServiceName); err != nil {
+		t.Fatalf("DeleteForwarderConfig() error = %v", err)
+	}
+	if _, err = svc.ForwarderOptions(HrdlogForwardingServiceName); err == nil {
+		t.Errorf("expected error for options of a deleted forwarder")
+	}
+	if err = svc.AddForwarderConfig(types.ForwarderConfig{Name: HrdlogForwardingServiceName, APIKey: "code"}); err != nil {
+		t.Fatalf("AddForwarderConfig() error = %v", err)
+	}
+	if err = svc.AddForwarderConfig(types.ForwarderConfig{Name: "unknown"}); err == nil {
+		t.Errorf("expected error adding an unknown forwarder")
+	}
+
+	reloaded := &Service{WorkingDir: workDir}
+	if err = reloaded.Initialize(); err != nil {
+		t.Fatalf("Initialize() error = %v", err)
+	}
+	lotw, err := reloaded.ForwarderConfig(LotwForwardingServiceName)
+	if err != nil || !lotw.Enabled {
+		t.Errorf("expected LoTW to be enabled after reload, got %+v, %v", lotw, err)
+	}
+	hrdlog, err := reloaded.ForwarderConfig(HrdlogForwardingServiceName)
+	if !errors.Is(err, ErrForwarderDisabled) || hrdlog.URL != hrdlogForwarderDefaults.URL {
+		t.Errorf("expected disabled HRDLog with defaults applied, got %+v, %v", hrdlog, err)
+	}
+}
+
+func TestForwarderLookup_disabledVsMissing(t *testing.T) {
+	svc := &Service{WorkingDir: t.TempDir()}
+	if err := svc.Initialize(); err != nil {
+		t.Fatalf("Initialize() error = %v", err)
+	}
+
+	if _, err := svc.ForwarderConfig("missing"); !errors.Is(err, ErrForwarderNotFound) || errors.Is(err, ErrForwarderDisabled) {
+		t.Errorf("expected ErrForwarderNotFound, got %v", err)
+	}
+	if _, err := svc.ForwarderConfig("missing"); !errors.Is(err, smerrors.ErrNotFound) {
+		t.Errorf("expected ErrForwarderNotFound to match errors.ErrNotFound, got %v", err)
+	}
+	if err := svc.DisableForwarder("missing"); !errors.Is(err, ErrForwarderNotFound) {
+		t.Errorf("expected DisableForwarder to wrap ErrForwarderNotFound, got %v", err)
+	}
+	if _, err := svc.ForwarderConfig(EqslForwardingServiceName); !errors.Is(err, ErrForwarderDisabled) {
+		t.Errorf("expected ErrForwarderDisabled, got %v", err)
+	}
+
+	enabled, err := svc.EnabledForwarders()
+	if err != nil {
+		t.Fatalf("EnabledForwarders() error = %v", err)
+	}
+	if len(enabled) != 0 {
+		t.Errorf("expected no enabled forwarders by default, got %+v", enabled)
+	}
+
+	if err = svc.SetForwarderOptions(ForwarderOptions{
+		Name: LotwForwardingServiceName,
+		Lotw: &LotwOptions{TqslPath: "/usr/bin/tqsl", StationLocation: "Home"},
+	}); err != nil {
+		t.Fatalf("SetForwarderOptions() error = %v", err)
+	}
+	if err = svc.EnableForwarder(LotwForwardingServiceName); err != nil {
+		t.Fatalf("EnableForwarder() error = %v", err)
+	}
+	enabled, _ = svc.EnabledForwarders()
+	if len(enabled) != 1 || enabled[0].Name != LotwForwardingServiceName {
+		t.Errorf("expected only LoTW enabled, got %+v", enabled)
+	}
+	if _, err = svc.ForwarderConfig(LotwForwardingServiceName); err != nil {
+		t.Errorf("ForwarderConfig() error = %v", err)
+	}
+}
+
+func TestValidateForwarders_names(t *testing.T) {
+	req := types.RequiredConfigs{QsoForwardingPollIntervalSeconds: 120, QsoForwardingRowLimit: 10}
+	eqsl := eqslForwarderDefaults
+
+	if err := validateForwarders([]types.ForwarderConfig{eqsl}, nil, req); err != nil {
+		t.Errorf("validateForwarders() error = %v", err)
+	}
+	if err := validateForwarders([]types.ForwarderConfig{eqsl, eqsl}, nil, req); err == nil {
+		t.Errorf("expected error for duplicate forwarder names")
+	}
+	if err := validateForwarders([]types.ForwarderConfig{{Name: "unknown"}}, nil, req); err == nil {
+		t.Errorf("expected error for unknown forwarder name")
+	}
+}
+
+func TestForwarderSchedule(t *testing.T) {
+	svc := &Service{WorkingDir: t.TempDir()}
+	if err := svc.Initialize(); err != nil {
+		t.Fatalf("Initialize() error = %v", err)
+	}
+	req, _ := svc.RequiredConfigs()
+
+	// QRZ has no overrides and inherits the global values.
+	qrz, err := svc.ForwarderSchedule(QrzForwardingServiceName)
+	if err != nil {
+		t.Fatalf("ForwarderSchedule() error = %v", err)
+	}
+	if qrz.PollInterval != req.QsoForwardingPollIntervalSeconds*time.Second || qrz.BatchSize != req.QsoForwardingRowLimit {
+		t.Errorf("expected global defaults, got %+v", qrz)
+	}
+	if qrz.MaxRetries != defaultForwarderMaxRetries {
+		t.Errorf("expected default max retries, got %d", qrz.MaxRetries)
+	}
+
+	lotw, _ := svc.ForwarderSchedule(LotwForwardingServiceName)
+	if lotw.PollInterval != time.Hour || lotw.BatchSize != 100 {
+		t.Errorf("expected LoTW overrides, got %+v", lotw)
+	}
+
+	opts, _ := svc.ForwarderOptions(ClublogForwardingServiceName)
+	opts.Schedule.MaxRetries = intPtr(0)
+	opts.Schedule.QuietHours = []QuietHours{{Start: "22:00", End: "06:00"}}
+	if err = svc.SetForwarderOptions(opts); err != nil {
+		t.Fatalf("SetForwarderOptions() error = %v", err)
+	}
+	clublog, _ := svc.ForwarderSchedule(ClublogForwardingServiceName)
+	if clublog.MaxRetries != 0 {
+		t.Errorf("expected retries to be disabled, got %d", clublog.MaxRetries)
+	}
+	if !clublog.InQuietHours(time.Date(2025, 1, 1, 23, 30, 0, 0, time.UTC)) ||
+		!clublog.InQuietHours(time.Date(2025, 1, 1, 5, 59, 0, 0, time.UTC)) ||
+		clublog.InQuietHours(time.Date(2025, 1, 1, 6, 0, 0, 0, time.UTC)) {
+		t.Errorf("quiet hours wrapping midnight not honoured")
+	}
+
+	// opts still shares nothing with the saved configuration, and a rejected change leaves it as it was.
+	opts.Schedule.QuietHours = []QuietHours{{Start: "25:00", End: "06:00"}}
+	if err = svc.SetForwarderOptions(opts); err == nil {
+		t.Errorf("expected error for invalid quiet hours")
+	}
+	after, err := svc.ForwarderSchedule(ClublogForwardingServiceName)
+	if err != nil {
+		t.Fatalf("ForwarderSchedule() error = %v", err)
+	}
+	if !reflect.DeepEqual(after, clublog) {
+		t.Errorf("rejected change altered the schedule: got %+v, want %+v", after, clublog)
+	}
+}
+
+func TestEffectiveForwarderSchedule_Backoff(t *testing.T) {
+	eff := EffectiveForwarderSchedule{BackoffInitial: 10 * time.Second, BackoffMax: time.Minute, BackoffMultiplier: 2}
+	want := []time.Duration{10 * time.Second, 20 * time.Second, 40 * time.Second, time.Minute, time.Minute}
+	for i, w := range want {
+		if got := eff.Backoff(i + 1); got != w {
+			t.Errorf("Backoff(%d) = %v, want %v", i+1, got, w)
+		}
+	}
+}
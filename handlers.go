@@ -0,0 +1,183 @@
+package config
+
+import (
+	"bytes"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/Station-Manager/errors"
+	"github.com/Station-Manager/types"
+	"github.com/goccy/go-json"
+)
+
+// HandlerConfig is implemented by the typed configuration of a listener packet handler. The fields are decoded
+// from types.ListenerConfig.HandlerConfig using their JSON tags.
+type HandlerConfig interface {
+	Validate() error
+}
+
+// ClientHandlerConfig is implemented by the configuration of handlers that connect to the application they
+// read from, rather than binding the listener's address and waiting for packets. The host and port of such a
+// listener are the application's, so they are not checked for conflicts with other listeners or the server.
+type ClientHandlerConfig interface {
+	HandlerConfig
+	// ClientMode reports whether the handler connects to the listener's address instead of binding it.
+	ClientMode() bool
+}
+
+// HandlerDefaultsFunc returns a new handler configuration, as a pointer to a struct populated with defaults.
+type HandlerDefaultsFunc func() HandlerConfig
+
+var handlerRegistry = struct {
+	sync.RWMutex
+	defaults map[string]HandlerDefaultsFunc
+}{defaults: make(map[string]HandlerDefaultsFunc)}
+
+func init() {
+	MustRegisterHandler(WsjtxHandlerName, func() HandlerConfig { return defaultWsjtxHandlerConfig() })
+	MustRegisterHandler(Js8callHandlerName, func() HandlerConfig { return defaultJs8callHandlerConfig() })
+	MustRegisterHandler(FldigiHandlerName, func() HandlerConfig { return defaultFldigiHandlerConfig() })
+	MustRegisterHandler(N1mmHandlerName, func() HandlerConfig { return defaultN1mmHandlerConfig() })
+	MustRegisterHandler(AdifHandlerName, func() HandlerConfig { return defaultAdifHandlerConfig() })
+}
+
+// RegisterHandler registers the typed configuration for a listener handler. The defaults function must return a
+// pointer to a struct; it is called every time a handler configuration is decoded, so it must not return shared
+// state. Registering the same handler name twice is an error.
+func RegisterHandler(name string, defaults HandlerDefaultsFunc) error {
+	const op errors.Op = "config.RegisterHandler"
+
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return errors.New(op).Msg("handler name cannot be empty")
+	}
+	if defaults == nil {
+		return errors.New(op).Msgf("defaults function for handler %q cannot be nil", name)
+	}
+	if cfg := defaults(); cfg == nil || reflect.TypeOf(cfg).Kind() != reflect.Pointer {
+		return errors.New(op).Msgf("defaults function for handler %q must return a pointer", name)
+	}
+
+	handlerRegistry.Lock()
+	defer handlerRegistry.Unlock()
+	if _, exists := handlerRegistry.defaults[name]; exists {
+		return errors.New(op).Msgf("handler %q is already registered", name)
+	}
+	handlerRegistry.defaults[name] = defaults
+
+	return nil
+}
+
+// MustRegisterHandler is like RegisterHandler but panics on error. It is intended for use in init functions.
+func MustRegisterHandler(name string, defaults HandlerDefaultsFunc) {
+	if err := RegisterHandler(name, defaults); err != nil {
+		panic(err)
+	}
+}
+
+// RegisteredHandlers returns the sorted names of all registered listener handlers.
+func RegisteredHandlers() []string {
+	handlerRegistry.RLock()
+	defer handlerRegistry.RUnlock()
+
+	names := make([]string, 0, len(handlerRegistry.defaults))
+	for name := range handlerRegistry.defaults {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// handlerConfigMap converts a typed handler configuration to the map form stored in types.ListenerConfig.
+func handlerConfigMap(cfg HandlerConfig) (map[string]any, error) {
+	const op errors.Op = "config.handlerConfigMap"
+
+	data, err := json.Marshal(cfg)
+	if err != nil {
+		return nil, errors.New(op).Err(err)
+	}
+	out := make(map[string]any)
+	if err = json.Unmarshal(data, &out); err != nil {
+		return nil, errors.New(op).Err(err)
+	}
+	return out, nil
+}
+
+// isClientHandler reports whether the named handler connects to the listener's address instead of binding it.
+func isClientHandler(name string) bool {
+	defaults, ok := handlerDefaults(name)
+	if !ok {
+		return false
+	}
+	client, ok := defaults().(ClientHandlerConfig)
+	return ok && client.ClientMode()
+}
+
+func handlerDefaults(name string) (HandlerDefaultsFunc, bool) {
+	handlerRegistry.RLock()
+	defer handlerRegistry.RUnlock()
+	fn, ok := handlerRegistry.defaults[name]
+	return fn, ok
+}
+
+// decodeHandlerConfig populates dst with the registered defaults for the handler, overlays the raw handler
+// configuration and validates the result. dst must be a pointer of the same type the handler's defaults
+// function returns.
+func decodeHandlerConfig(handler string, raw map[string]any, dst HandlerConfig) error {
+	const op errors.Op = "config.decodeHandlerConfig"
+
+	defaults, ok := handlerDefaults(handler)
+	if !ok {
+		return errors.New(op).Msgf("unknown listener handler: %q", handler)
+	}
+
+	def := defaults()
+	if reflect.TypeOf(def) != reflect.TypeOf(dst) {
+		return errors.New(op).Msgf("handler %q uses config type %T, not %T", handler, def, dst)
+	}
+	reflect.ValueOf(dst).Elem().Set(reflect.ValueOf(def).Elem())
+
+	if len(raw) > 0 {
+		data, err := json.Marshal(raw)
+		if err != nil {
+			return errors.New(op).Err(err)
+		}
+		dec := json.NewDecoder(bytes.NewReader(data))
+		dec.DisallowUnknownFields()
+		if err = dec.Decode(dst); err != nil {
+			return errors.New(op).Err(err).Msgf("invalid handler_config for handler %q: %s", handler, ErrorMessage(err))
+		}
+	}
+
+	if err := dst.Validate(); err != nil {
+		return errors.New(op).Err(err).Msgf("invalid handler_config for handler %q: %s", handler, ErrorMessage(err))
+	}
+
+	return nil
+}
+
+// validateListenerHandler checks that the listener's handler is registered and its configuration decodes and
+// validates. Listeners without a handler only log packets and need no configuration.
+func validateListenerHandler(cfg types.ListenerConfig) error {
+	const op errors.Op = "config.validateListenerHandler"
+
+	if cfg.Handler == "" {
+		if len(cfg.HandlerConfig) > 0 {
+			return errors.New(op).Msgf("listener %q has handler_config but no handler", cfg.Name)
+		}
+		return nil
+	}
+
+	defaults, ok := handlerDefaults(cfg.Handler)
+	if !ok {
+		return errors.New(op).Msgf("listener %q uses unknown handler %q (registered: %s)",
+			cfg.Name, cfg.Handler, strings.Join(RegisteredHandlers(), ", "))
+	}
+	if err := decodeHandlerConfig(cfg.Handler, cfg.HandlerConfig, defaults()); err != nil {
+		return errors.New(op).Err(err).Msgf("listener %q: %s", cfg.Name, ErrorMessage(err))
+	}
+
+	return nil
+}
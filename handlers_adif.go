@@ -0,0 +1,31 @@
+package config
+
+import (
+	"github.com/Station-Manager/errors"
+)
+
+// AdifHandlerConfig is the typed handler configuration for the "adif" listener handler, which accepts ADIF
+// records sent over UDP or TCP by loggers such as Log4OM.
+type AdifHandlerConfig struct {
+	// AutoLog logs received ADIF records without user confirmation.
+	AutoLog bool `json:"auto_log"`
+	// DedupeWindowSec drops a record identical to one received within this many seconds. This guards against
+	// several programs re-broadcasting the same QSO. Zero disables de-duplication.
+	DedupeWindowSec int `json:"dedupe_window_sec"`
+}
+
+func defaultAdifHandlerConfig() *AdifHandlerConfig {
+	return &AdifHandlerConfig{
+		AutoLog:         false,
+		DedupeWindowSec: 60,
+	}
+}
+
+// Validate implements HandlerConfig.
+func (c *AdifHandlerConfig) Validate() error {
+	const op errors.Op = "config.AdifHandlerConfig.Validate"
+	if c.DedupeWindowSec < 0 || c.DedupeWindowSec > 3600 {
+		return errors.New(op).Msgf("dedupe_window_sec must be between 0 and 3600, got %d", c.DedupeWindowSec)
+	}
+	return nil
+}
@@ -0,0 +1,35 @@
+package config
+
+import (
+	"github.com/Station-Manager/errors"
+)
+
+// FldigiHandlerConfig is the typed handler configuration for the "fldigi" listener handler, which polls the
+// fldigi XML-RPC interface.
+type FldigiHandlerConfig struct {
+	// AutoLog logs the QSO from fldigi's log panel when fldigi signals it has been saved.
+	AutoLog bool `json:"auto_log"`
+	// PollIntervalMS is how often the XML-RPC interface is polled. The unit is milliseconds.
+	PollIntervalMS int `json:"poll_interval_ms"`
+}
+
+func defaultFldigiHandlerConfig() *FldigiHandlerConfig {
+	return &FldigiHandlerConfig{
+		AutoLog:        false,
+		PollIntervalMS: 1000,
+	}
+}
+
+// ClientMode implements ClientHandlerConfig: the handler connects to fldigi's XML-RPC server.
+func (c *FldigiHandlerConfig) ClientMode() bool {
+	return true
+}
+
+// Validate implements HandlerConfig.
+func (c *FldigiHandlerConfig) Validate() error {
+	const op errors.Op = "config.FldigiHandlerConfig.Validate"
+	if c.PollIntervalMS < 100 || c.PollIntervalMS > 60000 {
+		return errors.New(op).Msgf("poll_interval_ms must be between 100 and 60000, got %d", c.PollIntervalMS)
+	}
+	return nil
+}
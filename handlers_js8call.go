@@ -0,0 +1,52 @@
+package config
+
+import (
+	"github.com/Station-Manager/errors"
+)
+
+// JS8Call UDP API message types relevant to logging.
+const (
+	Js8callMsgLogQSO        = "LOG.QSO"
+	Js8callMsgRxDirected    = "RX.DIRECTED"
+	Js8callMsgRxActivity    = "RX.ACTIVITY"
+	Js8callMsgRxSpot        = "RX.SPOT"
+	Js8callMsgStationStatus = "STATION.STATUS"
+)
+
+var js8callMessageTypes = map[string]bool{
+	Js8callMsgLogQSO:        true,
+	Js8callMsgRxDirected:    true,
+	Js8callMsgRxActivity:    true,
+	Js8callMsgRxSpot:        true,
+	Js8callMsgStationStatus: true,
+}
+
+// Js8callHandlerConfig is the typed handler configuration for the "js8call" listener handler.
+type Js8callHandlerConfig struct {
+	// AutoLog logs QSOs reported by JS8Call without user confirmation.
+	AutoLog bool `json:"auto_log"`
+	// MessageTypes is the set of JS8Call API message types the handler processes; all others are dropped.
+	MessageTypes []string `json:"message_types"`
+}
+
+func defaultJs8callHandlerConfig() *Js8callHandlerConfig {
+	return &Js8callHandlerConfig{
+		AutoLog:      false,
+		MessageTypes: []string{Js8callMsgLogQSO},
+	}
+}
+
+// Validate implements HandlerConfig.
+func (c *Js8callHandlerConfig) Validate() error {
+	const op errors.Op = "config.Js8callHandlerConfig.Validate"
+
+	if len(c.MessageTypes) == 0 {
+		return errors.New(op).Msg("message_types cannot be empty")
+	}
+	for _, mt := range c.MessageTypes {
+		if !js8callMessageTypes[mt] {
+			return errors.New(op).Msgf("message_types: unknown JS8Call message type %q", mt)
+		}
+	}
+	return nil
+}
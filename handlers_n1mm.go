@@ -0,0 +1,50 @@
+package config
+
+import (
+	"github.com/Station-Manager/errors"
+)
+
+// N1MM+ UDP broadcast message types (the XML root element names).
+const (
+	N1mmMsgContactInfo    = "contactinfo"
+	N1mmMsgContactReplace = "contactreplace"
+	N1mmMsgContactDelete  = "contactdelete"
+	N1mmMsgRadioInfo      = "RadioInfo"
+)
+
+var n1mmMessageTypes = map[string]bool{
+	N1mmMsgContactInfo:    true,
+	N1mmMsgContactReplace: true,
+	N1mmMsgContactDelete:  true,
+	N1mmMsgRadioInfo:      true,
+}
+
+// N1mmHandlerConfig is the typed handler configuration for the "n1mm" listener handler.
+type N1mmHandlerConfig struct {
+	// AutoLog logs contacts broadcast by N1MM+ without user confirmation.
+	AutoLog bool `json:"auto_log"`
+	// MessageTypes is the set of N1MM+ broadcast types the handler processes; all others are dropped.
+	MessageTypes []string `json:"message_types"`
+}
+
+func defaultN1mmHandlerConfig() *N1mmHandlerConfig {
+	return &N1mmHandlerConfig{
+		AutoLog:      false,
+		MessageTypes: []string{N1mmMsgContactInfo, N1mmMsgContactReplace, N1mmMsgContactDelete},
+	}
+}
+
+// Validate implements HandlerConfig.
+func (c *N1mmHandlerConfig) Validate() error {
+	const op errors.Op = "config.N1mmHandlerConfig.Validate"
+
+	if len(c.MessageTypes) == 0 {
+		return errors.New(op).Msg("message_types cannot be empty")
+	}
+	for _, mt := range c.MessageTypes {
+		if !n1mmMessageTypes[mt] {
+			return errors.New(op).Msgf("message_types: unknown N1MM+ message type %q", mt)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,55 @@
+package config
+
+import (
+	"testing"
+)
+
+// TestListenerHandlerConfig_roundTrip ensures the wsjtx handler config survives the JSON round trip through
+// config.json, where []int{12} is decoded back as []any{float64(12)}.
+func TestListenerHandlerConfig_roundTrip(t *testing.T) {
+	svc := &Service{WorkingDir: t.TempDir()}
+	if err := svc.Initialize(); err != nil {
+		t.Fatalf("Initialize() error = %v", err)
+	}
+
+	var cfg WsjtxHandlerConfig
+	if err := svc.ListenerHandlerConfig("WSJT-X", &cfg); err != nil {
+		t.Fatalf("ListenerHandlerConfig() error = %v", err)
+	}
+	if len(cfg.MessageTypes) != 1 || cfg.MessageTypes[0] != WsjtxMsgLoggedADIF {
+		t.Errorf("expected message_types [%d], got %v", WsjtxMsgLoggedADIF, cfg.MessageTypes)
+	}
+
+	if err := svc.ListenerHandlerConfig("missing", &cfg); err == nil {
+		t.Errorf("expected error for unknown listener")
+	}
+}
+
+func TestDecodeHandlerConfig(t *testing.T) {
+	var cfg WsjtxHandlerConfig
+
+	// Missing fields fall back to defaults
+	if err := decodeHandlerConfig(WsjtxHandlerName, map[string]any{"auto_log": true}, &cfg); err != nil {
+		t.Fatalf("decodeHandlerConfig() error = %v", err)
+	}
+	if !cfg.AutoLog || len(cfg.MessageTypes) != 1 {
+		t.Errorf("expected defaults to be merged, got %+v", cfg)
+	}
+
+	bad := []map[string]any{
+		{"message_types": []any{float64(99)}},
+		{"message_types": []any{}},
+		{"log_decodes": true},
+		{"autolog": true},
+		{"auto_log": "yes"},
+	}
+	for _, raw := range bad {
+		if err := decodeHandlerConfig(WsjtxHandlerName, raw, &cfg); err == nil {
+			t.Errorf("expected error for %v", raw)
+		}
+	}
+
+	if err := decodeHandlerConfig("unknown", nil, &cfg); err == nil {
+		t.Errorf("expected error for unregistered handler")
+	}
+}
@@ -0,0 +1,72 @@
+package config
+
+import (
+	"github.com/Station-Manager/errors"
+)
+
+// WSJT-X UDP message types, as defined in NetworkMessage.hpp of the WSJT-X sources.
+const (
+	WsjtxMsgHeartbeat           = 0
+	WsjtxMsgStatus              = 1
+	WsjtxMsgDecode              = 2
+	WsjtxMsgClear               = 3
+	WsjtxMsgReply               = 4
+	WsjtxMsgQSOLogged           = 5
+	WsjtxMsgClose               = 6
+	WsjtxMsgReplay              = 7
+	WsjtxMsgHaltTx              = 8
+	WsjtxMsgFreeText            = 9
+	WsjtxMsgWSPRDecode          = 10
+	WsjtxMsgLocation            = 11
+	WsjtxMsgLoggedADIF          = 12
+	WsjtxMsgHighlightCallsign   = 13
+	WsjtxMsgSwitchConfiguration = 14
+	WsjtxMsgConfigure           = 15
+)
+
+// WsjtxHandlerConfig is the typed handler configuration for the "wsjtx" listener handler.
+type WsjtxHandlerConfig struct {
+	// AutoLog logs QSOs reported by WSJT-X without user confirmation.
+	AutoLog bool `json:"auto_log"`
+	// LogDecodes logs every decode message received. This is very noisy and intended for debugging.
+	LogDecodes bool `json:"log_decodes"`
+	// MessageTypes is the set of WSJT-X message types the handler processes; all others are dropped.
+	MessageTypes []int `json:"message_types"`
+}
+
+func defaultWsjtxHandlerConfig() *WsjtxHandlerConfig {
+	return &WsjtxHandlerConfig{
+		AutoLog:      false,
+		LogDecodes:   false,
+		MessageTypes: []int{WsjtxMsgLoggedADIF},
+	}
+}
+
+// Validate implements HandlerConfig.
+func (c *WsjtxHandlerConfig) Validate() error {
+	const op errors.Op = "config.WsjtxHandlerConfig.Validate"
+
+	if len(c.MessageTypes) == 0 {
+		return errors.New(op).Msg("message_types cannot be empty")
+	}
+
+	seen := make(map[int]bool, len(c.MessageTypes))
+	for _, mt := range c.MessageTypes {
+		if mt < WsjtxMsgHeartbeat || mt > WsjtxMsgConfigure {
+			return errors.New(op).Msgf("message_types: unknown WSJT-X message type %d", mt)
+		}
+		if seen[mt] {
+			return errors.New(op).Msgf("message_types: duplicate WSJT-X message type %d", mt)
+		}
+		seen[mt] = true
+	}
+
+	if c.AutoLog && !seen[WsjtxMsgQSOLogged] && !seen[WsjtxMsgLoggedADIF] {
+		return errors.New(op).Msgf("auto_log requires message type %d or %d", WsjtxMsgQSOLogged, WsjtxMsgLoggedADIF)
+	}
+	if c.LogDecodes && !seen[WsjtxMsgDecode] {
+		return errors.New(op).Msgf("log_decodes requires message type %d", WsjtxMsgDecode)
+	}
+
+	return nil
+}
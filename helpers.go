@@ -1,15 +1,61 @@
 package config
 
 import (
-	"github.com/Station-Manager/errors"
+	"net"
+	"net/mail"
 	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/Station-Manager/errors"
 )
 
-func writeDataToFile(data []byte, path string) error {
+// writeDataToFile replaces the file at path with data, with the permissions perm.
+func writeDataToFile(data []byte, path string, perm os.FileMode) error {
 	const op errors.Op = "config.writeDataToFile"
-	// Use restrictive file permissions by default: owner read/write, group read
-	if err := os.WriteFile(path, data, 0o640); err != nil {
+	// Write to a temporary file in the same directory and rename it over path, so that readers, and a crash
+	// part way through, see either the old or the new file and never a partial one.
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return errors.New(op).Err(err)
+	}
+	defer func() { _ = os.Remove(tmp.Name()) }()
+
+	if err = tmp.Chmod(perm); err == nil {
+		if _, err = tmp.Write(data); err == nil {
+			err = tmp.Sync()
+		}
+	}
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), path)
+	}
+	if err != nil {
 		return errors.New(op).Err(err)
 	}
 	return nil
 }
+
+// isValidEmail reports whether v is a bare email address, such as user@example.com.
+func isValidEmail(v string) bool {
+	v = strings.TrimSpace(v)
+	addr, err := mail.ParseAddress(v)
+	return err == nil && addr.Address == v && strings.Contains(v[strings.LastIndex(v, "@")+1:], ".")
+}
+
+var hostnameRe = regexp.MustCompile(`^(?i)[a-z0-9]([a-z0-9-]{0,61}[a-z0-9])?(\.[a-z0-9]([a-z0-9-]{0,61}[a-z0-9])?)*$`)
+
+// isValidHost reports whether v is a bare hostname or IP address, without a scheme or port.
+func isValidHost(v string) bool {
+	if net.ParseIP(strings.Trim(v, "[]")) != nil {
+		return true
+	}
+	return len(v) <= 253 && hostnameRe.MatchString(v)
+}
+
+func intPtr(v int) *int {
+	return &v
+}
@@ -1,57 +1,141 @@
 package config
 
 import (
-	"github.com/Station-Manager/errors"
-	"github.com/Station-Manager/utils"
-	"github.com/goccy/go-json"
+	stderr "errors"
+	"io/fs"
 	"os"
 	"path/filepath"
 	"strings"
+
+	"github.com/Station-Manager/errors"
+	"github.com/Station-Manager/types"
 )
 
-func (s *Service) loadConfigFile() error {
+// store returns the Store configured on the service, or config.json in the working directory.
+func (s *Service) store() Store {
+	if s.Store != nil {
+		return s.Store
+	}
+	return fileStore{path: filepath.Join(s.WorkingDir, configFileName)}
+}
+
+// loadConfigFile reads the stored configuration. If there is none, the default configuration is generated and
+// stored first when create is set; otherwise an error is returned.
+func (s *Service) loadConfigFile(create bool) (types.AppConfig, Extensions, error) {
 	const op errors.Op = "config.Service.loadConfigFile"
 
-	filePath := filepath.Join(s.WorkingDir, configFileName)
-	exists, err := utils.PathExists(filePath)
-	if err != nil {
-		return errors.New(op).Err(err)
+	cfg, ext, err := s.store().Load()
+	if err == nil {
+		return cfg, ext, nil
 	}
-
-	if !exists {
-		if err = s.generateDefaultConfig(); err != nil {
-			return errors.New(op).Err(err)
-		}
+	if !stderr.Is(err, fs.ErrNotExist) {
+		return cfg, ext, errors.New(op).Err(err)
 	}
-
-	data, err := os.ReadFile(filePath)
-	if err != nil {
-		return errors.New(op).Err(err)
+	if !create {
+		return cfg, ext, errors.New(op).Err(ErrConfigNotFound).Msgf("config file not found: %s", err)
 	}
 
-	if err = json.Unmarshal(data, &s.AppConfig); err != nil {
-		return errors.New(op).Err(err)
+	if err = s.generateDefaultConfig(); err != nil {
+		return cfg, ext, errors.New(op).Err(err)
+	}
+	if cfg, ext, err = s.store().Load(); err != nil {
+		return cfg, ext, errors.New(op).Err(err)
 	}
 
-	return nil
+	return cfg, ext, nil
 }
 
 func (s *Service) generateDefaultConfig() error {
 	const op errors.Op = "config.Service.generateDefaultConfig"
 
-	// Decide which datastore config to embed based on env
-	selected := defaultDesktopConfig // start with sqlite default
-	if dbSel := strings.ToLower(strings.TrimSpace(os.Getenv(EnvSmDefaultDB))); dbSel != "" {
+	// Decide which profile to generate based on the WithDefaults option or env; desktop (sqlite) unless
+	// postgres is requested
+	profile := ProfileDesktop
+	if s.profile != "" {
+		profile = s.profile
+	} else if dbSel := strings.ToLower(strings.TrimSpace(os.Getenv(EnvSmDefaultDB))); dbSel != "" {
 		if dbSel == "postgres" || dbSel == "postgresql" || dbSel == "pg" {
-			selected = defaultServerConfig
+			profile = ProfileServer
 		}
 	}
+	selected, selectedExt := defaultProfile(profile)
+
+	if err := s.store().Save(selected, selectedExt); err != nil {
+		return errors.New(op).Err(err)
+	}
+	return nil
+}
+
+// DefaultConfig returns a copy of the default configuration of the named profile, ProfileDesktop or
+// ProfileServer. Unknown names return the desktop profile.
+func DefaultConfig(profile string) (types.AppConfig, Extensions) {
+	cfg, ext := defaultProfile(profile)
+	return deepCopy(cfg), deepCopy(ext)
+}
 
-	// Pretty-print selected configuration for readability
-	data, err := json.MarshalIndent(selected, "", "  ")
+// defaultProfile returns the default configuration of the named profile. Unknown names return the desktop
+// profile.
+func defaultProfile(profile string) (types.AppConfig, Extensions) {
+	if profile == ProfileServer {
+		return defaultServerConfig, defaultServerExtensions
+	}
+	return defaultDesktopConfig, defaultExtensions
+}
+
+// saveConfig validates copies of cfg and ext, saves them to the store and makes them the active configuration. A
+// configuration file also gets a new revision in its history. The caller must hold s.mu.
+func (s *Service) saveConfig(cfg types.AppConfig, ext Extensions) error {
+	return s.saveConfigNote(cfg, ext, "")
+}
+
+// saveConfigNote is saveConfig with a note for the audit entry of the change.
+func (s *Service) saveConfigNote(cfg types.AppConfig, ext Extensions, note string) error {
+	const op errors.Op = "config.Service.saveConfig"
+	if s.readOnly {
+		return errors.New(op).Err(ErrReadOnly).Msg("changes cannot be saved in read-only mode")
+	}
+
+	// Validation normalizes and fills defaults in place; copying first keeps that from reaching the active
+	// configuration, or values still held by the caller, if the change is rejected.
+	cfg, ext = deepCopy(cfg), deepCopy(ext)
+
+	// Network settings are linked to listeners by name; they follow listeners that are renamed or removed.
+	prev := s.current()
+	if prev != nil {
+		ext.ListenerNetworkConfigs = reconcileListenerNetworks(prev.AppConfig.ListenerConfigs, cfg.ListenerConfigs, ext.ListenerNetworkConfigs)
+	}
+
+	// Overrides are applied to what is published, never saved: sections the change leaves as published go back
+	// to their stored values, and the overrides are then applied again on top of the change.
+	overrides := s.sectionOverrides()
+	if s.stored != nil {
+		restoreOverridden(&cfg, &ext, s.stored, prev, overrides)
+	}
+	stored := &Snapshot{AppConfig: deepCopy(cfg), Extensions: deepCopy(ext)}
+	if err := applyOverrides(&cfg, &ext, overrides); err != nil {
+		return errors.New(op).Err(err)
+	}
+
+	if err := validateAppConfig(&cfg, &ext); err != nil {
+		return errors.New(op).Err(err)
+	}
+	if err := checkServerTLS(s.WorkingDir, cfg.ServerConfig, ext.ServerOptions); err != nil {
+		return errors.New(op).Err(err)
+	}
+	next := storedSnapshot(cfg, ext, stored, nil, overrides)
+
+	// The change is recorded in the history before it is saved, so that it is never saved without its entry.
+	done, err := s.recordChange(next, note)
+	if err != nil {
+		return errors.New(op).Err(err).Msg("cannot record the change in the history")
+	}
+	err = s.store().Save(next.AppConfig, next.Extensions)
+	done(err == nil)
 	if err != nil {
 		return errors.New(op).Err(err)
 	}
 
-	return writeDataToFile(data, filepath.Join(s.WorkingDir, configFileName))
+	s.stored = next
+	s.publish(cfg, ext)
+	return nil
 }
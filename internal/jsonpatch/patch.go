@@ -0,0 +1,374 @@
+// Package jsonpatch applies JSON Merge Patches (RFC 7396) and JSON Patches (RFC 6902) to decoded JSON
+// documents: values built from map[string]any, []any, strings, numbers, booleans and nil, as produced by
+// decoding into an any. Numbers may be float64 or json.Number.
+package jsonpatch
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/goccy/go-json"
+)
+
+// Merge returns doc with the merge patch applied, as described by RFC 7396: members of an object patch replace
+// those of doc, recursively, and null members remove them. Any other patch replaces doc. doc is modified in
+// place where possible.
+func Merge(doc, patch any) any {
+	p, ok := patch.(map[string]any)
+	if !ok {
+		return patch
+	}
+	d, ok := doc.(map[string]any)
+	if !ok {
+		d = map[string]any{}
+	}
+	for k, v := range p {
+		if v == nil {
+			delete(d, k)
+			continue
+		}
+		d[k] = Merge(d[k], v)
+	}
+	return d
+}
+
+// Operation is one operation of a JSON Patch.
+type Operation struct {
+	Op    string `json:"op"`
+	Path  string `json:"path"`
+	From  string `json:"from,omitempty"`
+	Value any    `json:"value"`
+
+	hasValue bool
+}
+
+// Decode decodes a JSON Patch document, an array of operations. Numbers are decoded as json.Number.
+func Decode(data []byte) ([]Operation, error) {
+	if !json.Valid(data) {
+		return nil, fmt.Errorf("not valid JSON")
+	}
+	var raw []map[string]json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("a JSON Patch must be an array of operations: %w", err)
+	}
+
+	ops := make([]Operation, len(raw))
+	for i, r := range raw {
+		op := &ops[i]
+		for name, dst := range map[string]*string{"op": &op.Op, "path": &op.Path, "from": &op.From} {
+			if v, ok := r[name]; ok {
+				if err := json.Unmarshal(v, dst); err != nil {
+					return nil, fmt.Errorf("operation %d: %q must be a string", i, name)
+				}
+			}
+		}
+		if v, ok := r["value"]; ok {
+			dec := json.NewDecoder(bytes.NewReader(v))
+			dec.UseNumber()
+			if err := dec.Decode(&op.Value); err != nil {
+				return nil, fmt.Errorf("operation %d: %w", i, err)
+			}
+			op.hasValue = true
+		}
+		if _, ok := r["path"]; !ok {
+			return nil, fmt.Errorf("operation %d: missing \"path\"", i)
+		}
+	}
+	return ops, nil
+}
+
+// Apply returns doc with the operations applied in order, as described by RFC 6902. If an operation fails,
+// including a failed test, the error names it and doc may have been partly changed, so callers should apply
+// patches to a copy.
+func Apply(doc any, ops []Operation) (any, error) {
+	for i, op := range ops {
+		var err error
+		if doc, err = apply(doc, op); err != nil {
+			return nil, fmt.Errorf("operation %d (%s %s): %w", i, op.Op, op.Path, err)
+		}
+	}
+	return doc, nil
+}
+
+func apply(doc any, op Operation) (any, error) {
+	path, err := parsePointer(op.Path)
+	if err != nil {
+		return nil, err
+	}
+	needsValue := op.Op == "add" || op.Op == "replace" || op.Op == "test"
+	if needsValue && !op.hasValue {
+		return nil, fmt.Errorf("missing \"value\"")
+	}
+
+	switch op.Op {
+	case "add":
+		return add(doc, path, op.Value)
+	case "remove":
+		doc, _, err = remove(doc, path)
+		return doc, err
+	case "replace":
+		if doc, _, err = remove(doc, path); err != nil {
+			return nil, err
+		}
+		return add(doc, path, op.Value)
+	case "move", "copy":
+		from, err := parsePointer(op.From)
+		if err != nil {
+			return nil, fmt.Errorf("from: %w", err)
+		}
+		var value any
+		if op.Op == "move" {
+			if isPrefix(from, path) && len(from) < len(path) {
+				return nil, fmt.Errorf("cannot move a value into itself")
+			}
+			if doc, value, err = remove(doc, from); err != nil {
+				return nil, fmt.Errorf("from: %w", err)
+			}
+		} else {
+			if value, err = get(doc, from); err != nil {
+				return nil, fmt.Errorf("from: %w", err)
+			}
+			value = clone(value)
+		}
+		return add(doc, path, value)
+	case "test":
+		value, err := get(doc, path)
+		if err != nil {
+			return nil, err
+		}
+		if !Equal(value, op.Value) {
+			return nil, fmt.Errorf("test failed")
+		}
+		return doc, nil
+	default:
+		return nil, fmt.Errorf("unknown operation %q", op.Op)
+	}
+}
+
+// parsePointer splits a JSON Pointer (RFC 6901) into its unescaped reference tokens.
+func parsePointer(ptr string) ([]string, error) {
+	if ptr == "" {
+		return nil, nil
+	}
+	if !strings.HasPrefix(ptr, "/") {
+		return nil, fmt.Errorf("JSON pointer %q must start with '/'", ptr)
+	}
+	tokens := strings.Split(ptr[1:], "/")
+	for i, t := range tokens {
+		tokens[i] = strings.NewReplacer("~1", "/", "~0", "~").Replace(t)
+	}
+	return tokens, nil
+}
+
+func isPrefix(prefix, path []string) bool {
+	if len(prefix) > len(path) {
+		return false
+	}
+	for i := range prefix {
+		if prefix[i] != path[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// get returns the value at path.
+func get(doc any, path []string) (any, error) {
+	cur := doc
+	for i, token := range path {
+		switch c := cur.(type) {
+		case map[string]any:
+			v, ok := c[token]
+			if !ok {
+				return nil, fmt.Errorf("%s does not exist", pointer(path[:i+1]))
+			}
+			cur = v
+		case []any:
+			idx, err := arrayIndex(token, len(c), false)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", pointer(path[:i+1]), err)
+			}
+			cur = c[idx]
+		default:
+			return nil, fmt.Errorf("%s is not an object or array", pointer(path[:i]))
+		}
+	}
+	return cur, nil
+}
+
+// add inserts value at path: it sets an object member or inserts an array element. Arrays are replaced in
+// their parent, as inserting may reallocate them.
+func add(doc any, path []string, value any) (any, error) {
+	if len(path) == 0 {
+		return value, nil
+	}
+	parentPath, last := path[:len(path)-1], path[len(path)-1]
+	parent, err := get(doc, parentPath)
+	if err != nil {
+		return nil, err
+	}
+	switch p := parent.(type) {
+	case map[string]any:
+		p[last] = value
+		return doc, nil
+	case []any:
+		idx, err := arrayIndex(last, len(p), true)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", pointer(path), err)
+		}
+		p = append(p, nil)
+		copy(p[idx+1:], p[idx:])
+		p[idx] = value
+		return replace(doc, parentPath, p)
+	default:
+		return nil, fmt.Errorf("%s is not an object or array", pointer(parentPath))
+	}
+}
+
+// remove removes the value at path and returns it.
+func remove(doc any, path []string) (any, any, error) {
+	if len(path) == 0 {
+		return nil, doc, nil
+	}
+	parentPath, last := path[:len(path)-1], path[len(path)-1]
+	parent, err := get(doc, parentPath)
+	if err != nil {
+		return nil, nil, err
+	}
+	switch p := parent.(type) {
+	case map[string]any:
+		v, ok := p[last]
+		if !ok {
+			return nil, nil, fmt.Errorf("%s does not exist", pointer(path))
+		}
+		delete(p, last)
+		return doc, v, nil
+	case []any:
+		idx, err := arrayIndex(last, len(p), false)
+		if err != nil {
+			return nil, nil, fmt.Errorf("%s: %w", pointer(path), err)
+		}
+		v := p[idx]
+		doc, err = replace(doc, parentPath, append(p[:idx:idx], p[idx+1:]...))
+		return doc, v, err
+	default:
+		return nil, nil, fmt.Errorf("%s is not an object or array", pointer(parentPath))
+	}
+}
+
+// replace stores value at path, which must exist.
+func replace(doc any, path []string, value any) (any, error) {
+	if len(path) == 0 {
+		return value, nil
+	}
+	parent, err := get(doc, path[:len(path)-1])
+	if err != nil {
+		return nil, err
+	}
+	switch p := parent.(type) {
+	case map[string]any:
+		p[path[len(path)-1]] = value
+	case []any:
+		idx, err := arrayIndex(path[len(path)-1], len(p), false)
+		if err != nil {
+			return nil, err
+		}
+		p[idx] = value
+	}
+	return doc, nil
+}
+
+// arrayIndex parses an array index token. "-" and an index equal to the length are allowed when adding.
+func arrayIndex(token string, length int, adding bool) (int, error) {
+	if adding && token == "-" {
+		return length, nil
+	}
+	idx, err := strconv.Atoi(token)
+	if err != nil || idx < 0 || (len(token) > 1 && token[0] == '0') {
+		return 0, fmt.Errorf("invalid array index %q", token)
+	}
+	if idx > length || (!adding && idx == length) {
+		return 0, fmt.Errorf("index %d is out of range", idx)
+	}
+	return idx, nil
+}
+
+// pointer renders path as a JSON Pointer.
+func pointer(path []string) string {
+	var b strings.Builder
+	for _, t := range path {
+		b.WriteByte('/')
+		b.WriteString(strings.NewReplacer("~", "~0", "/", "~1").Replace(t))
+	}
+	return b.String()
+}
+
+// clone returns a deep copy of a decoded JSON value.
+func clone(v any) any {
+	switch t := v.(type) {
+	case map[string]any:
+		m := make(map[string]any, len(t))
+		for k, e := range t {
+			m[k] = clone(e)
+		}
+		return m
+	case []any:
+		s := make([]any, len(t))
+		for i, e := range t {
+			s[i] = clone(e)
+		}
+		return s
+	default:
+		return v
+	}
+}
+
+// Equal reports whether two decoded JSON values are equal. Numbers are compared by value, so 1 equals 1.0.
+func Equal(a, b any) bool {
+	if x, ok := number(a); ok {
+		y, ok := number(b)
+		return ok && x == y
+	}
+	switch x := a.(type) {
+	case map[string]any:
+		y, ok := b.(map[string]any)
+		if !ok || len(x) != len(y) {
+			return false
+		}
+		for k, v := range x {
+			w, ok := y[k]
+			if !ok || !Equal(v, w) {
+				return false
+			}
+		}
+		return true
+	case []any:
+		y, ok := b.([]any)
+		if !ok || len(x) != len(y) {
+			return false
+		}
+		for i := range x {
+			if !Equal(x[i], y[i]) {
+				return false
+			}
+		}
+		return true
+	default:
+		return a == b
+	}
+}
+
+// number returns v as a float64 if it is a decoded JSON number.
+func number(v any) (float64, bool) {
+	switch n := v.(type) {
+	case float64:
+		return n, true
+	case interface{ Float64() (float64, error) }:
+		f, err := n.Float64()
+		return f, err == nil
+	default:
+		return 0, false
+	}
+}
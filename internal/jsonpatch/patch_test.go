@@ -0,0 +1,105 @@
+package jsonpatch
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func decode(t *testing.T, s string) any {
+	t.Helper()
+	var v any
+	if err := json.Unmarshal([]byte(s), &v); err != nil {
+		t.Fatalf("decode %s: %v", s, err)
+	}
+	return v
+}
+
+func TestMerge(t *testing.T) {
+	// Examples from RFC 7396, appendix A.
+	tests := []struct{ doc, patch, want string }{
+		{`{"a":"b"}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"b"}`, `{"b":"c"}`, `{"a":"b","b":"c"}`},
+		{`{"a":"b"}`, `{"a":null}`, `{}`},
+		{`{"a":"b","b":"c"}`, `{"a":null}`, `{"b":"c"}`},
+		{`{"a":["b"]}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"c"}`, `{"a":["b"]}`, `{"a":["b"]}`},
+		{`{"a":{"b":"c"}}`, `{"a":{"b":"d","c":null}}`, `{"a":{"b":"d"}}`},
+		{`{"a":[{"b":"c"}]}`, `{"a":[1]}`, `{"a":[1]}`},
+		{`["a","b"]`, `["c","d"]`, `["c","d"]`},
+		{`{"a":"b"}`, `["c"]`, `["c"]`},
+		{`{"a":"foo"}`, `null`, `null`},
+		{`{"e":null}`, `{"a":1}`, `{"e":null,"a":1}`},
+		{`[1,2]`, `{"a":"b","c":null}`, `{"a":"b"}`},
+		{`{}`, `{"a":{"bb":{"ccc":null}}}`, `{"a":{"bb":{}}}`},
+	}
+	for _, tt := range tests {
+		got := Merge(decode(t, tt.doc), decode(t, tt.patch))
+		if !Equal(got, decode(t, tt.want)) {
+			t.Errorf("Merge(%s, %s) = %v, want %s", tt.doc, tt.patch, got, tt.want)
+		}
+	}
+}
+
+func TestApply(t *testing.T) {
+	// Examples from RFC 6902, appendix A.
+	tests := []struct{ doc, patch, want string }{
+		{`{"foo":"bar"}`, `[{"op":"add","path":"/baz","value":"qux"}]`, `{"baz":"qux","foo":"bar"}`},
+		{`{"foo":["bar","baz"]}`, `[{"op":"add","path":"/foo/1","value":"qux"}]`, `{"foo":["bar","qux","baz"]}`},
+		{`{"baz":"qux","foo":"bar"}`, `[{"op":"remove","path":"/baz"}]`, `{"foo":"bar"}`},
+		{`{"foo":["bar","qux","baz"]}`, `[{"op":"remove","path":"/foo/1"}]`, `{"foo":["bar","baz"]}`},
+		{`{"baz":"qux","foo":"bar"}`, `[{"op":"replace","path":"/baz","value":"boo"}]`, `{"baz":"boo","foo":"bar"}`},
+		{`{"foo":{"bar":"baz","waldo":"fred"},"qux":{"corge":"grault"}}`, `[{"op":"move","from":"/foo/waldo","path":"/qux/thud"}]`,
+			`{"foo":{"bar":"baz"},"qux":{"corge":"grault","thud":"fred"}}`},
+		{`{"foo":["all","grass","cows","eat"]}`, `[{"op":"move","from":"/foo/1","path":"/foo/3"}]`, `{"foo":["all","cows","eat","grass"]}`},
+		{`{"baz":"qux","foo":["a",2,"c"]}`, `[{"op":"test","path":"/baz","value":"qux"},{"op":"test","path":"/foo/1","value":2.0}]`,
+			`{"baz":"qux","foo":["a",2,"c"]}`},
+		{`{"foo":"bar"}`, `[{"op":"add","path":"/child","value":{"grandchild":{}}}]`, `{"foo":"bar","child":{"grandchild":{}}}`},
+		{`{"foo":["bar"]}`, `[{"op":"add","path":"/foo/-","value":["abc","def"]}]`, `{"foo":["bar",["abc","def"]]}`},
+		{`{"foo":null}`, `[{"op":"test","path":"/foo","value":null}]`, `{"foo":null}`},
+		{`{"/":9,"~1":10}`, `[{"op":"test","path":"/~01","value":10}]`, `{"/":9,"~1":10}`},
+		{`{"foo":{"bar":1}}`, `[{"op":"copy","from":"/foo","path":"/baz"},{"op":"replace","path":"/baz/bar","value":2}]`,
+			`{"foo":{"bar":1},"baz":{"bar":2}}`},
+	}
+	for _, tt := range tests {
+		ops, err := Decode([]byte(tt.patch))
+		if err != nil {
+			t.Errorf("Decode(%s) error = %v", tt.patch, err)
+			continue
+		}
+		got, err := Apply(decode(t, tt.doc), ops)
+		if err != nil {
+			t.Errorf("Apply(%s, %s) error = %v", tt.doc, tt.patch, err)
+			continue
+		}
+		if !Equal(got, decode(t, tt.want)) {
+			t.Errorf("Apply(%s, %s) = %v, want %s", tt.doc, tt.patch, got, tt.want)
+		}
+	}
+
+	failures := []struct{ doc, patch string }{
+		{`{"foo":"bar"}`, `[{"op":"add","path":"/baz/bat","value":"qux"}]`},
+		{`{"baz":"qux"}`, `[{"op":"test","path":"/baz","value":"bar"}]`},
+		{`{"foo":["bar"]}`, `[{"op":"add","path":"/foo/2","value":"x"}]`},
+		{`{"foo":["bar"]}`, `[{"op":"remove","path":"/foo/01"}]`},
+		{`{"foo":"bar"}`, `[{"op":"remove","path":"/missing"}]`},
+		{`{"foo":"bar"}`, `[{"op":"replace","path":"/foo"}]`},
+		{`{"foo":{"bar":1}}`, `[{"op":"move","from":"/foo","path":"/foo/bar/baz"}]`},
+		{`{"foo":"bar"}`, `[{"op":"frobnicate","path":"/foo"}]`},
+		{`{"foo":"bar"}`, `[{"op":"add","path":"foo","value":1}]`},
+	}
+	for _, tt := range failures {
+		ops, err := Decode([]byte(tt.patch))
+		if err == nil {
+			_, err = Apply(decode(t, tt.doc), ops)
+		}
+		if err == nil {
+			t.Errorf("Apply(%s, %s): expected error", tt.doc, tt.patch)
+		}
+	}
+
+	for _, bad := range []string{`{"op":"add"}`, `[{"op":"add","value":1}]`, `[{"op":1,"path":"/a"}]`} {
+		if _, err := Decode([]byte(bad)); err == nil {
+			t.Errorf("Decode(%s): expected error", bad)
+		}
+	}
+}
@@ -0,0 +1,179 @@
+// Package jsonpath parses the configuration path syntax used by the config service and its command-line tool,
+// and applies paths to decoded JSON documents.
+//
+// A path is a dot-separated list of keys, each optionally followed by one or more selectors in brackets:
+//
+//	logging_config.level
+//	rig_configs[0].name
+//	rig_configs[id=1].serial_config.port_name
+//	listener_configs[name=WSJT-X].port
+//	listener_configs[name="ADIF UDP"].enabled
+//
+// A numeric selector picks a slice element by index; a field=value selector picks the element whose field
+// equals value. Keys and selector fields are matched ignoring case, underscores and hyphens, so port_name
+// matches both "port_name" and "PortName".
+package jsonpath
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Kind is the kind of a Step.
+type Kind int
+
+const (
+	// KeyStep selects an object member by key.
+	KeyStep Kind = iota
+	// IndexStep selects a slice element by index.
+	IndexStep
+	// MatchStep selects the slice element whose Field equals Value.
+	MatchStep
+)
+
+// Step is one element of a parsed path.
+type Step struct {
+	Kind  Kind
+	Key   string // KeyStep
+	Index int    // IndexStep
+	Field string // MatchStep
+	Value string // MatchStep
+}
+
+// String renders the step as it appears in a path, without the leading dot of a key.
+func (s Step) String() string {
+	switch s.Kind {
+	case IndexStep:
+		return fmt.Sprintf("[%d]", s.Index)
+	case MatchStep:
+		if strings.ContainsAny(s.Value, ".[]\" ") {
+			return fmt.Sprintf("[%s=%q]", s.Field, s.Value)
+		}
+		return fmt.Sprintf("[%s=%s]", s.Field, s.Value)
+	default:
+		return s.Key
+	}
+}
+
+// Path is a parsed path.
+type Path []Step
+
+// String renders the path in the syntax accepted by Parse.
+func (p Path) String() string {
+	var b strings.Builder
+	for i, s := range p {
+		if s.Kind == KeyStep && i > 0 {
+			b.WriteByte('.')
+		}
+		b.WriteString(s.String())
+	}
+	return b.String()
+}
+
+// Parse parses path. The path must start with a key.
+func Parse(path string) (Path, error) {
+	var steps Path
+	rest := strings.TrimSpace(path)
+	if rest == "" {
+		return nil, fmt.Errorf("path is empty")
+	}
+
+	expectKey := true
+	for rest != "" {
+		switch {
+		case rest[0] == '[':
+			if expectKey && len(steps) == 0 {
+				return nil, fmt.Errorf("path %q must start with a key", path)
+			}
+			end, value, err := scanSelector(rest)
+			if err != nil {
+				return nil, fmt.Errorf("path %q: %w", path, err)
+			}
+			step, err := parseSelector(value)
+			if err != nil {
+				return nil, fmt.Errorf("path %q: %w", path, err)
+			}
+			steps = append(steps, step)
+			rest = rest[end:]
+			expectKey = false
+		case rest[0] == '.':
+			if expectKey {
+				return nil, fmt.Errorf("path %q has an empty key", path)
+			}
+			rest = rest[1:]
+			expectKey = true
+			if rest == "" {
+				return nil, fmt.Errorf("path %q ends with a dot", path)
+			}
+		default:
+			if !expectKey {
+				return nil, fmt.Errorf("path %q: expected '.' or '[' before %q", path, rest)
+			}
+			end := strings.IndexAny(rest, ".[")
+			if end < 0 {
+				end = len(rest)
+			}
+			key := strings.TrimSpace(rest[:end])
+			if key == "" {
+				return nil, fmt.Errorf("path %q has an empty key", path)
+			}
+			steps = append(steps, Step{Kind: KeyStep, Key: key})
+			rest = rest[end:]
+			expectKey = false
+		}
+	}
+	return steps, nil
+}
+
+// scanSelector returns the end of the bracketed selector at the start of s and its content. A quoted value may
+// contain brackets and dots.
+func scanSelector(s string) (int, string, error) {
+	inQuote := false
+	for i := 1; i < len(s); i++ {
+		switch {
+		case s[i] == '\\' && inQuote:
+			i++
+		case s[i] == '"':
+			inQuote = !inQuote
+		case s[i] == ']' && !inQuote:
+			return i + 1, s[1:i], nil
+		}
+	}
+	return 0, "", fmt.Errorf("unterminated selector %q", s)
+}
+
+// parseSelector parses the content of a selector: an index or field=value.
+func parseSelector(sel string) (Step, error) {
+	sel = strings.TrimSpace(sel)
+	field, value, ok := strings.Cut(sel, "=")
+	if !ok {
+		idx, err := strconv.Atoi(sel)
+		if err != nil || idx < 0 {
+			return Step{}, fmt.Errorf("selector [%s] must be an index or field=value", sel)
+		}
+		return Step{Kind: IndexStep, Index: idx}, nil
+	}
+
+	field, value = strings.TrimSpace(field), strings.TrimSpace(value)
+	if field == "" {
+		return Step{}, fmt.Errorf("selector [%s] has no field", sel)
+	}
+	if strings.HasPrefix(value, `"`) {
+		unquoted, err := strconv.Unquote(value)
+		if err != nil {
+			return Step{}, fmt.Errorf("selector [%s] has an invalid quoted value", sel)
+		}
+		value = unquoted
+	}
+	return Step{Kind: MatchStep, Field: field, Value: value}, nil
+}
+
+// KeyMatches reports whether the path key matches name, ignoring case, underscores and hyphens.
+func KeyMatches(key, name string) bool {
+	return normalize(key) == normalize(name)
+}
+
+func normalize(s string) string {
+	return strings.ToLower(strings.NewReplacer("_", "", "-", "").Replace(s))
+}
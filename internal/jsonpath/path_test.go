@@ -0,0 +1,93 @@
+package jsonpath
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		path string
+		want Path
+	}{
+		{"logging_config.level", Path{{Kind: KeyStep, Key: "logging_config"}, {Kind: KeyStep, Key: "level"}}},
+		{"rig_configs[0].name", Path{{Kind: KeyStep, Key: "rig_configs"}, {Kind: IndexStep, Index: 0}, {Kind: KeyStep, Key: "name"}}},
+		{"rig_configs[id=1].serial_config.port_name", Path{
+			{Kind: KeyStep, Key: "rig_configs"}, {Kind: MatchStep, Field: "id", Value: "1"},
+			{Kind: KeyStep, Key: "serial_config"}, {Kind: KeyStep, Key: "port_name"},
+		}},
+		{`listener_configs[name="ADIF.UDP [1]"].port`, Path{
+			{Kind: KeyStep, Key: "listener_configs"}, {Kind: MatchStep, Field: "name", Value: "ADIF.UDP [1]"}, {Kind: KeyStep, Key: "port"},
+		}},
+	}
+	for _, tt := range tests {
+		got, err := Parse(tt.path)
+		if err != nil {
+			t.Errorf("Parse(%q) error = %v", tt.path, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Parse(%q) = %+v, want %+v", tt.path, got, tt.want)
+		}
+		if again, _ := Parse(got.String()); !reflect.DeepEqual(again, got) {
+			t.Errorf("Parse(%q) does not round-trip: %q", tt.path, got.String())
+		}
+	}
+
+	for _, bad := range []string{"", "[0]", "a..b", "a.", "a[", "a[x]", "a[-1]", "a[=1]", `a[name="x]`, "a[0]b"} {
+		if _, err := Parse(bad); err == nil {
+			t.Errorf("Parse(%q) expected error", bad)
+		}
+	}
+}
+
+func TestTree(t *testing.T) {
+	var doc map[string]any
+	if err := json.Unmarshal([]byte(`{
+		"logging_config": {"level": "info"},
+		"rig_configs": [{"ID": 1, "Name": "A", "SerialConfig": {"PortName": "/dev/ttyUSB0"}}, {"ID": 2, "Name": "B"}]
+	}`), &doc); err != nil {
+		t.Fatal(err)
+	}
+	mustParse := func(p string) Path {
+		path, err := Parse(p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+
+	if v, err := Get(doc, mustParse("rig_configs[id=1].serial_config.port_name")); err != nil || v != "/dev/ttyUSB0" {
+		t.Errorf("Get() = %v, %v", v, err)
+	}
+	if _, err := Get(doc, mustParse("rig_configs[id=9].name")); err == nil {
+		t.Errorf("expected error for a missing element")
+	}
+
+	if err := Set(doc, mustParse("rig_configs[name=B].serial_config.port_name"), "COM3"); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+	if v, _ := Get(doc, mustParse("rig_configs[1].SerialConfig.PortName")); v != "COM3" {
+		t.Errorf("expected a created member, got %v", v)
+	}
+	if err := Set(doc, mustParse("logging_config.level"), "debug"); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+	if lc := doc["logging_config"].(map[string]any); lc["level"] != "debug" || len(lc) != 1 {
+		t.Errorf("expected the existing member to be replaced, got %v", lc)
+	}
+	if err := Set(doc, mustParse("rig_configs[5].name"), "x"); err == nil {
+		t.Errorf("expected error for a missing element")
+	}
+
+	if err := Delete(doc, mustParse("rig_configs[id=1]")); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	if rigs := doc["rig_configs"].([]any); len(rigs) != 1 {
+		t.Errorf("expected one rig left, got %d", len(rigs))
+	}
+	if err := Delete(doc, mustParse("email_configs.password")); err != nil {
+		t.Errorf("expected deleting a missing member to succeed, got %v", err)
+	}
+}
@@ -0,0 +1,182 @@
+package jsonpath
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+)
+
+// Get returns the value at path in doc, a document decoded into map[string]any and []any values.
+func Get(doc any, path Path) (any, error) {
+	cur := doc
+	for i, step := range path {
+		next, err := child(cur, step)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path[:i+1], err)
+		}
+		cur = next
+	}
+	return cur, nil
+}
+
+// Set replaces the value at path in doc with value. Missing object members along the path are created; slice
+// elements must exist. The root of doc must be an object.
+func Set(doc any, path Path, value any) error {
+	if len(path) == 0 {
+		return fmt.Errorf("path is empty")
+	}
+	parent := doc
+	for i, step := range path[:len(path)-1] {
+		next, err := child(parent, step)
+		if obj, ok := parent.(map[string]any); ok && next == nil && (err == nil || isMissing(err)) {
+			next, err = map[string]any{}, nil
+			obj[memberKey(obj, step.Key)] = next
+		}
+		if err != nil {
+			return fmt.Errorf("%s: %w", path[:i+1], err)
+		}
+		parent = next
+	}
+
+	last := path[len(path)-1]
+	switch p := parent.(type) {
+	case map[string]any:
+		if last.Kind != KeyStep {
+			return fmt.Errorf("%s: %s is an object, not a list", path, path[:len(path)-1])
+		}
+		p[memberKey(p, last.Key)] = value
+		return nil
+	case []any:
+		idx, err := elementIndex(p, last)
+		if err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		p[idx] = value
+		return nil
+	default:
+		return fmt.Errorf("%s: %s is not an object or list", path, path[:len(path)-1])
+	}
+}
+
+// Delete removes the value at path from doc. Removing a member that does not exist is not an error. Slice
+// elements are removed from the slice, so the parent slice is replaced.
+func Delete(doc any, path Path) error {
+	if len(path) == 0 {
+		return fmt.Errorf("path is empty")
+	}
+	parentPath, last := path[:len(path)-1], path[len(path)-1]
+	parent, err := Get(doc, parentPath)
+	if err != nil {
+		if isMissing(err) {
+			return nil
+		}
+		return err
+	}
+
+	switch p := parent.(type) {
+	case map[string]any:
+		if last.Kind != KeyStep {
+			return fmt.Errorf("%s: %s is an object, not a list", path, parentPath)
+		}
+		delete(p, memberKey(p, last.Key))
+		return nil
+	case []any:
+		idx, err := elementIndex(p, last)
+		if err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		if len(parentPath) == 0 {
+			return fmt.Errorf("%s: the document root cannot be a list", path)
+		}
+		return Set(doc, parentPath, append(p[:idx:idx], p[idx+1:]...))
+	default:
+		return fmt.Errorf("%s: %s is not an object or list", path, parentPath)
+	}
+}
+
+// errMissing reports a member or element that does not exist.
+type errMissing struct{ what string }
+
+func (e errMissing) Error() string { return e.what + " not found" }
+
+func isMissing(err error) bool {
+	return errors.As(err, new(errMissing))
+}
+
+// child returns the value selected by step within cur.
+func child(cur any, step Step) (any, error) {
+	switch c := cur.(type) {
+	case map[string]any:
+		if step.Kind != KeyStep {
+			return nil, fmt.Errorf("selector %s applied to an object", step)
+		}
+		v, ok := c[memberKey(c, step.Key)]
+		if !ok {
+			return nil, errMissing{fmt.Sprintf("key %q", step.Key)}
+		}
+		return v, nil
+	case []any:
+		idx, err := elementIndex(c, step)
+		if err != nil {
+			return nil, err
+		}
+		return c[idx], nil
+	case nil:
+		return nil, errMissing{fmt.Sprintf("%s (parent is null)", step)}
+	default:
+		return nil, fmt.Errorf("cannot select %s from a %T", step, cur)
+	}
+}
+
+// memberKey returns the key of the member of obj matching key, or key itself if there is none.
+func memberKey(obj map[string]any, key string) string {
+	if _, ok := obj[key]; ok {
+		return key
+	}
+	for k := range obj {
+		if KeyMatches(key, k) {
+			return k
+		}
+	}
+	return key
+}
+
+// elementIndex returns the index of the element of list selected by step.
+func elementIndex(list []any, step Step) (int, error) {
+	switch step.Kind {
+	case IndexStep:
+		if step.Index >= len(list) {
+			return 0, errMissing{fmt.Sprintf("index %d of %d elements", step.Index, len(list))}
+		}
+		return step.Index, nil
+	case MatchStep:
+		for i, e := range list {
+			obj, ok := e.(map[string]any)
+			if !ok {
+				continue
+			}
+			if v, ok := obj[memberKey(obj, step.Field)]; ok && scalarString(v) == step.Value {
+				return i, nil
+			}
+		}
+		return 0, errMissing{fmt.Sprintf("element %s", step)}
+	default:
+		return 0, fmt.Errorf("key %q applied to a list", step.Key)
+	}
+}
+
+// scalarString renders a decoded JSON scalar for comparison with a selector value.
+func scalarString(v any) string {
+	switch t := v.(type) {
+	case string:
+		return t
+	case float64:
+		return strconv.FormatFloat(t, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(t)
+	case nil:
+		return "null"
+	default:
+		return fmt.Sprint(t)
+	}
+}
@@ -0,0 +1,118 @@
+package config
+
+import (
+	"context"
+	"os"
+	"time"
+
+	"github.com/Station-Manager/errors"
+)
+
+// minWatchInterval keeps Watch from polling the file system in a tight loop.
+const minWatchInterval = 100 * time.Millisecond
+
+// Reload re-reads config.json and, if it is valid, makes it the active configuration. If it is not, the error
+// is returned and the current configuration stays active. When the service is not yet initialized, for
+// example because an earlier Initialize failed, Reload initializes it. Once initialized, a missing config.json
+// is reported as an error rather than replaced with the defaults. A file whose content matches the active
+// configuration, such as one just saved by the service, is not published again.
+func (s *Service) Reload(ctx context.Context) error {
+	const op errors.Op = "config.Service.Reload"
+	if err := ctx.Err(); err != nil {
+		return errors.New(op).Err(err)
+	}
+	if !s.isInitialized.Load() {
+		if err := s.Initialize(); err != nil {
+			return errors.New(op).Err(err)
+		}
+		return nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	cfg, ext, stored, err := s.readConfig(false)
+	if err != nil {
+		return errors.New(op).Err(err)
+	}
+	// Loading can take a while with TLS checks; don't publish if the caller has given up.
+	if err = ctx.Err(); err != nil {
+		return errors.New(op).Err(err)
+	}
+
+	// The file is rewritten by every change the service saves itself; reading it back is not a new version.
+	s.stored = stored
+	if contentETag(cfg, ext) == s.current().ETag {
+		return nil
+	}
+	s.publish(cfg, ext)
+	return nil
+}
+
+// Watch checks the configuration file every interval and reloads it when its modification time or size changes, so edits
+// made outside the application take effect without a restart. Reload errors, such as a file saved half-edited,
+// are passed to onError if it is not nil, and the current configuration stays active until the file is fixed.
+// Only one watcher may run at a time; Close stops it. A service using a Store other than a file cannot be watched.
+func (s *Service) Watch(interval time.Duration, onError func(error)) error {
+	const op errors.Op = "config.Service.Watch"
+	if !s.isInitialized.Load() {
+		return errors.New(op).Msg(errMsgNotInitialized)
+	}
+	file, ok := s.store().(fileStore)
+	if !ok {
+		return errors.New(op).Msg("only a configuration file can be watched")
+	}
+	if interval < minWatchInterval {
+		return errors.New(op).Msgf("watch interval must be at least %s", minWatchInterval)
+	}
+
+	s.watchMu.Lock()
+	defer s.watchMu.Unlock()
+	if s.watchStop != nil {
+		return errors.New(op).Msg("config file is already being watched")
+	}
+
+	stop, done := make(chan struct{}), make(chan struct{})
+	s.watchStop, s.watchDone = stop, done
+
+	path := file.path
+	last, _ := os.Stat(path)
+	go func() {
+		defer close(done)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+			}
+
+			info, err := os.Stat(path)
+			if err != nil || (last != nil && info.ModTime().Equal(last.ModTime()) && info.Size() == last.Size()) {
+				continue
+			}
+			last = info
+			if err = s.Reload(context.Background()); err != nil && onError != nil {
+				onError(err)
+			}
+		}
+	}()
+	return nil
+}
+
+// Close stops the watcher started by Watch, waiting for an in-progress reload to finish. The configuration
+// stays readable and Watch may be called again. Close is safe to call more than once.
+func (s *Service) Close() error {
+	s.watchMu.Lock()
+	stop, done := s.watchStop, s.watchDone
+	s.watchStop, s.watchDone = nil, nil
+	s.watchMu.Unlock()
+
+	if stop != nil {
+		close(stop)
+		<-done
+	}
+	return nil
+}
@@ -0,0 +1,206 @@
+package config
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestInitialize_retryAfterFailure(t *testing.T) {
+	workDir := t.TempDir()
+	cfgPath := filepath.Join(workDir, configFileName)
+	if err := os.WriteFile(cfgPath, []byte("{not json"), 0o640); err != nil {
+		t.Fatal(err)
+	}
+
+	svc := &Service{WorkingDir: workDir}
+	if err := svc.Initialize(); err == nil {
+		t.Fatalf("expected Initialize to fail on a malformed file")
+	}
+	if _, err := svc.LoggingConfig(); err == nil {
+		t.Errorf("expected getters to fail after a failed Initialize")
+	}
+
+	if err := os.Remove(cfgPath); err != nil {
+		t.Fatal(err)
+	}
+	if err := svc.Initialize(); err != nil {
+		t.Fatalf("expected retried Initialize to succeed, got %v", err)
+	}
+	if _, err := svc.LoggingConfig(); err != nil {
+		t.Errorf("LoggingConfig() error = %v", err)
+	}
+}
+
+func TestReload(t *testing.T) {
+	workDir := t.TempDir()
+	cfgPath := filepath.Join(workDir, configFileName)
+	svc := &Service{WorkingDir: workDir}
+	if err := svc.Initialize(); err != nil {
+		t.Fatalf("Initialize() error = %v", err)
+	}
+	original, err := os.ReadFile(cfgPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// An external edit is picked up.
+	edited := strings.Replace(string(original), `"level": "info"`, `"level": "debug"`, 1)
+	if edited == string(original) {
+		t.Fatalf("expected the default file to contain the info log level")
+	}
+	if err = os.WriteFile(cfgPath, []byte(edited), 0o640); err != nil {
+		t.Fatal(err)
+	}
+	if err = svc.Reload(context.Background()); err != nil {
+		t.Fatalf("Reload() error = %v", err)
+	}
+	if logCfg, _ := svc.LoggingConfig(); logCfg.Level != "debug" {
+		t.Errorf("expected reloaded level debug, got %q", logCfg.Level)
+	}
+	if snap, _ := svc.Snapshot(); snap.Version != 2 {
+		t.Errorf("expected Reload to publish version 2, got %d", snap.Version)
+	}
+
+	// Invalid and missing files are reported and the current configuration stays active.
+	for _, tc := range []struct {
+		name  string
+		write func() error
+	}{
+		{"malformed", func() error { return os.WriteFile(cfgPath, []byte("{not json"), 0o640) }},
+		{"invalid", func() error {
+			return os.WriteFile(cfgPath, []byte(strings.Replace(edited, `"level": "debug"`, `"level": ""`, 1)), 0o640)
+		}},
+		{"missing", func() error { return os.Remove(cfgPath) }},
+	} {
+		if err = tc.write(); err != nil {
+			t.Fatal(err)
+		}
+		if err = svc.Reload(context.Background()); err == nil {
+			t.Errorf("%s: expected Reload to fail", tc.name)
+		}
+		if logCfg, _ := svc.LoggingConfig(); logCfg.Level != "debug" {
+			t.Errorf("%s: expected the previous configuration to stay active, got level %q", tc.name, logCfg.Level)
+		}
+	}
+	if _, err = os.Stat(cfgPath); !os.IsNotExist(err) {
+		t.Errorf("expected Reload not to regenerate a missing file")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err = svc.Reload(ctx); err == nil {
+		t.Errorf("expected Reload to fail with a cancelled context")
+	}
+}
+
+func TestReload_initializesAfterFailure(t *testing.T) {
+	workDir := t.TempDir()
+	cfgPath := filepath.Join(workDir, configFileName)
+	if err := os.WriteFile(cfgPath, []byte("{not json"), 0o640); err != nil {
+		t.Fatal(err)
+	}
+
+	svc := &Service{WorkingDir: workDir}
+	if err := svc.Initialize(); err == nil {
+		t.Fatalf("expected Initialize to fail on a malformed file")
+	}
+	if err := os.Remove(cfgPath); err != nil {
+		t.Fatal(err)
+	}
+	if err := svc.Reload(context.Background()); err != nil {
+		t.Fatalf("Reload() error = %v", err)
+	}
+	if snap, err := svc.Snapshot(); err != nil || snap.Version != 1 {
+		t.Errorf("expected an initialized service at version 1, got %d, %v", snap.Version, err)
+	}
+}
+
+func TestWatch(t *testing.T) {
+	workDir := t.TempDir()
+	cfgPath := filepath.Join(workDir, configFileName)
+	svc := &Service{WorkingDir: workDir}
+	if err := svc.Watch(time.Second, nil); err == nil {
+		t.Errorf("expected Watch to fail before Initialize")
+	}
+	if err := svc.Initialize(); err != nil {
+		t.Fatalf("Initialize() error = %v", err)
+	}
+	defer func() { _ = svc.Close() }()
+
+	if err := svc.Watch(time.Millisecond, nil); err == nil {
+		t.Errorf("expected Watch to reject a very short interval")
+	}
+
+	var failures atomic.Int32
+	if err := svc.Watch(minWatchInterval, func(error) { failures.Add(1) }); err != nil {
+		t.Fatalf("Watch() error = %v", err)
+	}
+	if err := svc.Watch(minWatchInterval, nil); err == nil {
+		t.Errorf("expected a second Watch to fail")
+	}
+
+	original, err := os.ReadFile(cfgPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	edited := strings.Replace(string(original), `"level": "info"`, `"level": "warn"`, 1)
+	if err = os.WriteFile(cfgPath, []byte(edited), 0o640); err != nil {
+		t.Fatal(err)
+	}
+	waitFor(t, func() bool {
+		logCfg, _ := svc.LoggingConfig()
+		return logCfg.Level == "warn"
+	})
+
+	// The service's own saves are not reloaded as new versions.
+	before, _ := svc.Snapshot()
+	if err = svc.Set("logging_config.level", "error"); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+	time.Sleep(3 * minWatchInterval)
+	if after, _ := svc.Snapshot(); after.Version != before.Version+1 {
+		t.Errorf("expected one new version for a save, version went from %d to %d", before.Version, after.Version)
+	}
+
+	if err = os.WriteFile(cfgPath, []byte("{not json"), 0o640); err != nil {
+		t.Fatal(err)
+	}
+	waitFor(t, func() bool { return failures.Load() > 0 })
+	if logCfg, _ := svc.LoggingConfig(); logCfg.Level != "error" {
+		t.Errorf("expected the previous configuration to stay active, got level %q", logCfg.Level)
+	}
+
+	if err = svc.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	if err = svc.Close(); err != nil {
+		t.Errorf("expected a second Close to succeed, got %v", err)
+	}
+
+	// Once closed, changes are no longer picked up.
+	before, _ = svc.Snapshot()
+	if err = os.WriteFile(cfgPath, original, 0o640); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(3 * minWatchInterval)
+	if after, _ := svc.Snapshot(); after.Version != before.Version {
+		t.Errorf("expected no reloads after Close, version went from %d to %d", before.Version, after.Version)
+	}
+}
+
+// waitFor polls cond until it is true, failing the test after a few seconds.
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("condition not met before the deadline")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
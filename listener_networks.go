@@ -0,0 +1,246 @@
+package config
+
+import (
+	"fmt"
+	"net"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/Station-Manager/errors"
+	"github.com/Station-Manager/types"
+)
+
+const defaultMulticastTTL = 1 // Do not leave the local network segment
+
+// ListenerNetworkConfig holds the multicast and re-broadcast settings of a listener. It is linked to a
+// types.ListenerConfig by Name. This allows Station Manager to share WSJT-X traffic with other applications,
+// such as GridTracker and JTAlert, either by joining the same multicast group or by re-broadcasting every
+// received datagram.
+type ListenerNetworkConfig struct {
+	// Name is the name of the listener these settings apply to.
+	Name string `json:"name"`
+	// MulticastGroup is the group address to join, e.g. 224.0.0.1 or 239.255.0.1. Required when the
+	// listener's protocol is MULTICAST.
+	MulticastGroup string `json:"multicast_group,omitempty"`
+	// Interface is the name or IP address of the network interface used to join the group. Empty selects the
+	// system default.
+	Interface string `json:"interface,omitempty"`
+	// TTL is the multicast time-to-live for re-broadcast datagrams. Defaults to 1.
+	TTL int `json:"ttl,omitempty"`
+	// ForwardTo is a list of host:port destinations every received datagram is re-broadcast to.
+	ForwardTo []string `json:"forward_to,omitempty"`
+}
+
+// normalizeListenerNetworks returns a copy of networks with the default TTL applied and addresses trimmed.
+func normalizeListenerNetworks(networks []ListenerNetworkConfig) []ListenerNetworkConfig {
+	if networks == nil {
+		return nil
+	}
+	out := make([]ListenerNetworkConfig, len(networks))
+	for i, n := range networks {
+		if n.TTL == 0 {
+			n.TTL = defaultMulticastTTL
+		}
+		n.MulticastGroup = strings.TrimSpace(n.MulticastGroup)
+		n.Interface = strings.TrimSpace(n.Interface)
+		if n.ForwardTo != nil {
+			forwardTo := make([]string, len(n.ForwardTo))
+			for j, dest := range n.ForwardTo {
+				forwardTo[j] = strings.TrimSpace(dest)
+			}
+			n.ForwardTo = forwardTo
+		}
+		out[i] = n
+	}
+	return out
+}
+
+// reconcileListenerNetworks returns a copy of networks that follows the listener changes from prev to next, so
+// that renaming or removing a listener does not leave an entry that names no listener. An entry for a listener
+// that was renamed in place, at the same index, takes the new name; an entry for a listener that was removed is
+// dropped. Entries that did not name a listener in prev are kept as they are, for validation to report.
+func reconcileListenerNetworks(prev, next []types.ListenerConfig, networks []ListenerNetworkConfig) []ListenerNetworkConfig {
+	if len(networks) == 0 {
+		return networks
+	}
+	hasListener := func(listeners []types.ListenerConfig, name string) bool {
+		return slices.ContainsFunc(listeners, func(l types.ListenerConfig) bool { return l.Name == name })
+	}
+	hasNetwork := func(name string) bool {
+		return slices.ContainsFunc(networks, func(n ListenerNetworkConfig) bool { return n.Name == name })
+	}
+
+	out := make([]ListenerNetworkConfig, 0, len(networks))
+	for _, n := range networks {
+		i := slices.IndexFunc(prev, func(l types.ListenerConfig) bool { return l.Name == n.Name })
+		if i < 0 || hasListener(next, n.Name) {
+			out = append(out, n)
+			continue
+		}
+		if i < len(next) && !hasListener(prev, next[i].Name) && !hasNetwork(next[i].Name) {
+			n.Name = next[i].Name
+			out = append(out, n)
+		}
+	}
+	return out
+}
+
+// validateListenerNetworks checks the network settings, as normalizeListenerNetworks would leave them, against
+// the listeners they refer to. networks is not changed. All problems found are reported together.
+func validateListenerNetworks(networks []ListenerNetworkConfig, listeners []types.ListenerConfig) error {
+	const op errors.Op = "config.validateListenerNetworks"
+
+	networks = normalizeListenerNetworks(networks)
+	var problems []string
+	seen := make(map[string]bool, len(networks))
+
+	for i := range networks {
+		n := &networks[i]
+		id := fmt.Sprintf("listener_network_configs[%d] %q", i, n.Name)
+
+		if seen[n.Name] {
+			problems = append(problems, fmt.Sprintf("%s: duplicate entry for listener", id))
+			continue
+		}
+		seen[n.Name] = true
+
+		idx := slices.IndexFunc(listeners, func(l types.ListenerConfig) bool { return l.Name == n.Name })
+		if idx < 0 {
+			problems = append(problems, fmt.Sprintf("%s: no listener with this name", id))
+			continue
+		}
+		listener := listeners[idx]
+		protocol := strings.ToUpper(strings.TrimSpace(listener.Protocol))
+
+		if n.TTL < 1 || n.TTL > 255 {
+			problems = append(problems, fmt.Sprintf("%s: ttl %d must be between 1 and 255", id, n.TTL))
+		}
+
+		switch {
+		case protocol == ProtocolMulticast && n.MulticastGroup == "":
+			problems = append(problems, fmt.Sprintf("%s: multicast_group is required for a %s listener", id, ProtocolMulticast))
+		case protocol != ProtocolMulticast && n.MulticastGroup != "":
+			problems = append(problems, fmt.Sprintf("%s: multicast_group requires the listener protocol to be %s", id, ProtocolMulticast))
+		case n.MulticastGroup != "":
+			if ip := net.ParseIP(n.MulticastGroup); ip == nil || !ip.IsMulticast() {
+				problems = append(problems, fmt.Sprintf("%s: multicast_group %q is not a multicast address", id, n.MulticastGroup))
+			}
+		}
+
+		if n.Interface != "" && protocol != ProtocolMulticast {
+			problems = append(problems, fmt.Sprintf("%s: interface is only used by %s listeners", id, ProtocolMulticast))
+		}
+
+		if len(n.ForwardTo) > 0 && protocol == ProtocolTCP {
+			problems = append(problems, fmt.Sprintf("%s: forward_to is only supported for %s and %s listeners", id, ProtocolUDP, ProtocolMulticast))
+		}
+		targets := make(map[string]bool, len(n.ForwardTo))
+		for j, dest := range n.ForwardTo {
+			if err := validateForwardDestination(dest, listener); err != nil {
+				problems = append(problems, fmt.Sprintf("%s: forward_to[%d]: %s", id, j, err.Error()))
+				continue
+			}
+			if targets[dest] {
+				problems = append(problems, fmt.Sprintf("%s: forward_to[%d]: duplicate destination %q", id, j, dest))
+			}
+			targets[dest] = true
+		}
+	}
+
+	for i, l := range listeners {
+		if strings.EqualFold(strings.TrimSpace(l.Protocol), ProtocolMulticast) && !seen[l.Name] {
+			problems = append(problems, fmt.Sprintf("listener_configs[%d] %q: %s listener has no listener_network_configs entry with a multicast_group",
+				i, l.Name, ProtocolMulticast))
+		}
+	}
+
+	if len(problems) > 0 {
+		return errors.New(op).Msgf("invalid listener network configuration: %s", strings.Join(problems, "; "))
+	}
+
+	return nil
+}
+
+// validateForwardDestination checks a host:port destination, and that it does not point back at the listener.
+func validateForwardDestination(dest string, listener types.ListenerConfig) error {
+	const op errors.Op = "config.validateForwardDestination"
+
+	host, portStr, err := net.SplitHostPort(dest)
+	if err != nil {
+		return errors.New(op).Err(err).Msgf("%q is not a host:port address", dest)
+	}
+	if host == "" {
+		return errors.New(op).Msgf("%q has no host", dest)
+	}
+	port, err := strconv.Atoi(portStr)
+	if err != nil || port < 1 || port > 65535 {
+		return errors.New(op).Msgf("%q has an invalid port", dest)
+	}
+	if port == listener.Port && hostsOverlap(host, listener.Host) {
+		return errors.New(op).Msgf("%q would forward back to the listener itself", dest)
+	}
+	return nil
+}
+
+// ListenerNetworkConfig returns the multicast and re-broadcast settings of the named listener. A listener
+// without network settings returns a config with only Name and the default TTL set.
+func (s *Service) ListenerNetworkConfig(listenerName string) (ListenerNetworkConfig, error) {
+	const op errors.Op = "config.Service.ListenerNetworkConfig"
+	emptyRetVal := ListenerNetworkConfig{}
+	if !s.isInitialized.Load() {
+		return emptyRetVal, errors.New(op).Msg(errMsgNotInitialized)
+	}
+
+	snap := s.current()
+
+	listenerName = strings.TrimSpace(listenerName)
+	if listenerName == "" {
+		return emptyRetVal, errors.New(op).Msg("listener name cannot be empty")
+	}
+	if !slices.ContainsFunc(snap.AppConfig.ListenerConfigs, func(l types.ListenerConfig) bool { return l.Name == listenerName }) {
+		return emptyRetVal, errors.New(op).Msgf("listener config not found for: %s", listenerName)
+	}
+
+	for _, cfg := range snap.Extensions.ListenerNetworkConfigs {
+		if cfg.Name == listenerName {
+			return deepCopy(cfg), nil
+		}
+	}
+
+	return ListenerNetworkConfig{Name: listenerName, TTL: defaultMulticastTTL}, nil
+}
+
+// SetListenerNetworkConfig adds or replaces the network settings of the listener named in cfg, validates them
+// and persists the change.
+func (s *Service) SetListenerNetworkConfig(cfg ListenerNetworkConfig) error {
+	const op errors.Op = "config.Service.SetListenerNetworkConfig"
+	if !s.isInitialized.Load() {
+		return errors.New(op).Msg(errMsgNotInitialized)
+	}
+
+	cfg.Name = strings.TrimSpace(cfg.Name)
+	if cfg.Name == "" {
+		return errors.New(op).Msg("listener name cannot be empty")
+	}
+	cfg.ForwardTo = slices.Clone(cfg.ForwardTo)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	snap := s.current()
+
+	ext := snap.Extensions
+	ext.ListenerNetworkConfigs = slices.Clone(snap.Extensions.ListenerNetworkConfigs)
+	if idx := slices.IndexFunc(ext.ListenerNetworkConfigs, func(n ListenerNetworkConfig) bool { return n.Name == cfg.Name }); idx >= 0 {
+		ext.ListenerNetworkConfigs[idx] = cfg
+	} else {
+		ext.ListenerNetworkConfigs = append(ext.ListenerNetworkConfigs, cfg)
+	}
+
+	if err := s.saveConfig(snap.AppConfig, ext); err != nil {
+		return errors.New(op).Err(err)
+	}
+
+	return nil
+}
@@ -0,0 +1,107 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/Station-Manager/types"
+)
+
+func TestValidateListenerNetworks(t *testing.T) {
+	wsjtx, _ := ListenerTemplate(WsjtxListenerTemplate)
+	multicast := wsjtx
+	multicast.Name, multicast.Protocol = "WSJT-X multicast", ProtocolMulticast
+	listeners := []types.ListenerConfig{wsjtx, multicast}
+
+	networks := []ListenerNetworkConfig{
+		{Name: wsjtx.Name, ForwardTo: []string{"127.0.0.1:2238", " localhost:2239 "}},
+		{Name: multicast.Name, MulticastGroup: "224.0.0.1", Interface: "eth0"},
+	}
+	if err := validateListenerNetworks(networks, listeners); err != nil {
+		t.Fatalf("validateListenerNetworks() error = %v", err)
+	}
+	if networks[1].TTL != 0 || networks[0].ForwardTo[1] != " localhost:2239 " {
+		t.Errorf("expected the caller's settings to be left unchanged, got %+v", networks)
+	}
+	normalized := normalizeListenerNetworks(networks)
+	if normalized[1].TTL != defaultMulticastTTL {
+		t.Errorf("expected default TTL %d, got %d", defaultMulticastTTL, normalized[1].TTL)
+	}
+	if normalized[0].ForwardTo[1] != "localhost:2239" {
+		t.Errorf("expected forward_to to be trimmed, got %q", normalized[0].ForwardTo[1])
+	}
+
+	bad := [][]ListenerNetworkConfig{
+		{{Name: "missing"}, {Name: multicast.Name, MulticastGroup: "224.0.0.1"}},
+		{{Name: multicast.Name, MulticastGroup: "192.168.1.1"}},
+		{{Name: multicast.Name, MulticastGroup: "224.0.0.1", TTL: 300}},
+		{{Name: wsjtx.Name, MulticastGroup: "224.0.0.1"}, {Name: multicast.Name, MulticastGroup: "224.0.0.1"}},
+		{{Name: wsjtx.Name, ForwardTo: []string{"localhost:2237"}}, {Name: multicast.Name, MulticastGroup: "224.0.0.1"}},
+		{{Name: wsjtx.Name, ForwardTo: []string{"localhost"}}, {Name: multicast.Name, MulticastGroup: "224.0.0.1"}},
+		{}, // multicast listener without a group
+	}
+	for i, n := range bad {
+		if err := validateListenerNetworks(n, listeners); err == nil {
+			t.Errorf("case %d: expected error for %+v", i, n)
+		}
+	}
+}
+
+func TestSetListenerNetworkConfig(t *testing.T) {
+	workDir := t.TempDir()
+	svc := &Service{WorkingDir: workDir}
+	if err := svc.Initialize(); err != nil {
+		t.Fatalf("Initialize() error = %v", err)
+	}
+
+	if err := svc.SetListenerNetworkConfig(ListenerNetworkConfig{Name: "WSJT-X", ForwardTo: []string{"127.0.0.1:2238"}}); err != nil {
+		t.Fatalf("SetListenerNetworkConfig() error = %v", err)
+	}
+	if err := svc.SetListenerNetworkConfig(ListenerNetworkConfig{Name: "WSJT-X", ForwardTo: []string{"bad"}}); err == nil {
+		t.Errorf("expected error for invalid destination")
+	}
+
+	reloaded := &Service{WorkingDir: workDir}
+	if err := reloaded.Initialize(); err != nil {
+		t.Fatalf("Initialize() error = %v", err)
+	}
+	cfg, err := reloaded.ListenerNetworkConfig("WSJT-X")
+	if err != nil {
+		t.Fatalf("ListenerNetworkConfig() error = %v", err)
+	}
+	if len(cfg.ForwardTo) != 1 || cfg.ForwardTo[0] != "127.0.0.1:2238" {
+		t.Errorf("expected persisted forward_to, got %v", cfg.ForwardTo)
+	}
+}
+
+func TestListenerNetworks_followListenerChanges(t *testing.T) {
+	svc := &Service{WorkingDir: t.TempDir()}
+	if err := svc.Initialize(); err != nil {
+		t.Fatalf("Initialize() error = %v", err)
+	}
+	if err := svc.SetListenerNetworkConfig(ListenerNetworkConfig{Name: "WSJT-X", ForwardTo: []string{"127.0.0.1:2238"}}); err != nil {
+		t.Fatalf("SetListenerNetworkConfig() error = %v", err)
+	}
+
+	cfg, _ := svc.Snapshot()
+	cfg.AppConfig.ListenerConfigs[0].Name = "WSJT-X shack"
+	if err := svc.UpdateAppConfig(cfg.AppConfig); err != nil {
+		t.Fatalf("UpdateAppConfig() rename error = %v", err)
+	}
+	if n, err := svc.ListenerNetworkConfig("WSJT-X shack"); err != nil || len(n.ForwardTo) != 1 {
+		t.Errorf("expected the network settings to follow the rename, got %+v, %v", n, err)
+	}
+
+	cfg, _ = svc.Snapshot()
+	cfg.AppConfig.ListenerConfigs = nil
+	if err := svc.UpdateAppConfig(cfg.AppConfig); err != nil {
+		t.Fatalf("UpdateAppConfig() remove error = %v", err)
+	}
+	if snap, _ := svc.Snapshot(); len(snap.Extensions.ListenerNetworkConfigs) != 0 {
+		t.Errorf("expected the network settings to be removed with the listener, got %+v", snap.Extensions.ListenerNetworkConfigs)
+	}
+
+	// Settings for a listener that never existed are still rejected.
+	if err := svc.SetListenerNetworkConfig(ListenerNetworkConfig{Name: "missing"}); err == nil {
+		t.Errorf("expected error for settings without a listener")
+	}
+}
@@ -0,0 +1,309 @@
+package config
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/Station-Manager/errors"
+	"github.com/Station-Manager/types"
+)
+
+// Listener template names accepted by ListenerTemplate and Service.AddListenerFromTemplate.
+const (
+	WsjtxListenerTemplate   = "wsjtx"
+	Js8callListenerTemplate = "js8call"
+	FldigiListenerTemplate  = "fldigi"
+	N1mmListenerTemplate    = "n1mm"
+	Log4omListenerTemplate  = "log4om"
+	AdifUdpListenerTemplate = "adif-udp"
+	AdifTcpListenerTemplate = "adif-tcp"
+)
+
+// Listener protocols accepted in types.ListenerConfig.Protocol. Matching is case-insensitive; values are
+// normalized to upper case during validation.
+const (
+	ProtocolUDP       = "UDP"
+	ProtocolTCP       = "TCP"
+	ProtocolMulticast = "MULTICAST" // UDP multicast
+)
+
+type listenerTemplate struct {
+	listener types.ListenerConfig
+	handler  func() HandlerConfig
+}
+
+// listenerTemplates holds the built-in listener definitions. Ports are the defaults used by each application.
+var listenerTemplates = map[string]listenerTemplate{
+	WsjtxListenerTemplate: {
+		listener: types.ListenerConfig{Name: "WSJT-X", Host: "localhost", Port: 2237, Protocol: ProtocolUDP, BufferSize: 1024, Handler: WsjtxHandlerName},
+		handler:  func() HandlerConfig { return defaultWsjtxHandlerConfig() },
+	},
+	Js8callListenerTemplate: {
+		listener: types.ListenerConfig{Name: "JS8Call", Host: "localhost", Port: 2242, Protocol: ProtocolUDP, BufferSize: 4096, Handler: Js8callHandlerName},
+		handler:  func() HandlerConfig { return defaultJs8callHandlerConfig() },
+	},
+	FldigiListenerTemplate: {
+		// fldigi's XML-RPC server; the handler connects to it rather than binding the port.
+		listener: types.ListenerConfig{Name: "fldigi", Host: "localhost", Port: 7362, Protocol: ProtocolTCP, BufferSize: 4096, Handler: FldigiHandlerName},
+		handler:  func() HandlerConfig { return defaultFldigiHandlerConfig() },
+	},
+	N1mmListenerTemplate: {
+		listener: types.ListenerConfig{Name: "N1MM+", Host: "localhost", Port: 12060, Protocol: ProtocolUDP, BufferSize: 4096, Handler: N1mmHandlerName},
+		handler:  func() HandlerConfig { return defaultN1mmHandlerConfig() },
+	},
+	Log4omListenerTemplate: {
+		// Log4OM's UDP outbound ADIF connection; match the port configured in Log4OM.
+		listener: types.ListenerConfig{Name: "Log4OM", Host: "localhost", Port: 2236, Protocol: ProtocolUDP, BufferSize: 4096, Handler: AdifHandlerName},
+		handler:  func() HandlerConfig { return defaultAdifHandlerConfig() },
+	},
+	AdifUdpListenerTemplate: {
+		listener: types.ListenerConfig{Name: "ADIF UDP", Host: "localhost", Port: 2333, Protocol: ProtocolUDP, BufferSize: 4096, Handler: AdifHandlerName},
+		handler:  func() HandlerConfig { return defaultAdifHandlerConfig() },
+	},
+	AdifTcpListenerTemplate: {
+		listener: types.ListenerConfig{Name: "ADIF TCP", Host: "localhost", Port: 2334, Protocol: ProtocolTCP, BufferSize: 4096, Handler: AdifHandlerName},
+		handler:  func() HandlerConfig { return defaultAdifHandlerConfig() },
+	},
+}
+
+// ListenerTemplates returns the sorted names of the built-in listener templates.
+func ListenerTemplates() []string {
+	names := make([]string, 0, len(listenerTemplates))
+	for name := range listenerTemplates {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ListenerTemplate returns a new, enabled listener configuration for the named template, with the handler
+// configuration populated from the handler's typed defaults.
+func ListenerTemplate(templateName string) (types.ListenerConfig, error) {
+	const op errors.Op = "config.ListenerTemplate"
+
+	tmpl, ok := listenerTemplates[strings.ToLower(strings.TrimSpace(templateName))]
+	if !ok {
+		return types.ListenerConfig{}, errors.New(op).Msgf("unknown listener template %q (available: %s)",
+			templateName, strings.Join(ListenerTemplates(), ", "))
+	}
+
+	handlerCfg, err := handlerConfigMap(tmpl.handler())
+	if err != nil {
+		return types.ListenerConfig{}, errors.New(op).Err(err)
+	}
+
+	cfg := tmpl.listener
+	cfg.Enabled = true
+	cfg.HandlerConfig = handlerCfg
+
+	return cfg, nil
+}
+
+// Buffer size limits per protocol. A UDP datagram carries at most 65507 bytes of payload over IPv4; TCP
+// listeners read a stream, so the buffer only bounds a single read. A listener without a buffer size gets the
+// default.
+const (
+	defaultListenerBufferSize = 4096
+	maxUDPListenerBufferSize  = 65507
+	maxTCPListenerBufferSize  = 1 << 20
+)
+
+// normalizeListeners returns a copy of listeners with their protocols in upper case and the default buffer
+// size filled in where none is set.
+func normalizeListeners(listeners []types.ListenerConfig) []types.ListenerConfig {
+	if listeners == nil {
+		return nil
+	}
+	out := slices.Clone(listeners)
+	for i := range out {
+		out[i].Protocol = strings.ToUpper(strings.TrimSpace(out[i].Protocol))
+		if out[i].BufferSize == 0 {
+			out[i].BufferSize = defaultListenerBufferSize
+		}
+	}
+	return out
+}
+
+// socketFamily returns the socket type a listener protocol binds, as multicast listeners are UDP sockets.
+func socketFamily(protocol string) string {
+	if strings.EqualFold(protocol, ProtocolTCP) {
+		return ProtocolTCP
+	}
+	return ProtocolUDP
+}
+
+// canonicalHost maps equivalent bind hosts to a single form: wildcard addresses to "*" and loopback names and
+// addresses to "loopback".
+func canonicalHost(host string) string {
+	host = strings.ToLower(strings.TrimSpace(host))
+	switch host {
+	case "", "*", "0.0.0.0", "::", "[::]":
+		return "*"
+	case "localhost", "127.0.0.1", "::1", "[::1]":
+		return "loopback"
+	}
+	return host
+}
+
+// hostsOverlap reports whether two bind hosts would claim the same port.
+func hostsOverlap(a, b string) bool {
+	a, b = canonicalHost(a), canonicalHost(b)
+	return a == "*" || b == "*" || a == b
+}
+
+// bindsAddress reports whether a listener binds its address; listeners whose handler connects to the
+// application, such as fldigi, do not.
+func bindsAddress(l types.ListenerConfig) bool {
+	return !isClientHandler(l.Handler)
+}
+
+// listenersConflict reports whether two listeners would bind the same address.
+func listenersConflict(a, b types.ListenerConfig) bool {
+	return bindsAddress(a) && bindsAddress(b) &&
+		a.Port == b.Port && socketFamily(a.Protocol) == socketFamily(b.Protocol) && hostsOverlap(a.Host, b.Host)
+}
+
+// serverConflict reports whether a listener would bind the server's TCP address.
+func serverConflict(l types.ListenerConfig, server *types.ServerConfig) bool {
+	return server != nil && bindsAddress(l) &&
+		server.Port == l.Port && socketFamily(l.Protocol) == ProtocolTCP && hostsOverlap(server.Host, l.Host)
+}
+
+// validateListeners checks each listener's protocol, port, buffer size and handler config, and that no two
+// enabled listeners, or an enabled TCP listener and the server, bind the same address. Listeners with a client
+// handler connect to their address instead and are left out of the conflict checks. The listeners are checked
+// as normalizeListeners would leave them, without being changed. All problems found are reported together,
+// identified by their index and name.
+func validateListeners(listeners []types.ListenerConfig, server *types.ServerConfig) error {
+	const op errors.Op = "config.validateListeners"
+
+	listeners = normalizeListeners(listeners)
+	var problems []string
+	names := make(map[string]int, len(listeners))
+
+	for i := range listeners {
+		l := &listeners[i]
+		id := fmt.Sprintf("listener_configs[%d] %q", i, l.Name)
+
+		if strings.TrimSpace(l.Name) == "" {
+			problems = append(problems, fmt.Sprintf("listener_configs[%d]: name is required", i))
+		} else if j, dup := names[l.Name]; dup {
+			problems = append(problems, fmt.Sprintf("%s: name already used by listener_configs[%d]", id, j))
+		} else {
+			names[l.Name] = i
+		}
+
+		maxBuf := maxUDPListenerBufferSize
+		switch l.Protocol {
+		case ProtocolUDP, ProtocolMulticast:
+		case ProtocolTCP:
+			maxBuf = maxTCPListenerBufferSize
+		default:
+			problems = append(problems, fmt.Sprintf("%s: protocol %q must be one of %s, %s or %s",
+				id, l.Protocol, ProtocolUDP, ProtocolTCP, ProtocolMulticast))
+		}
+
+		if l.Port < 1 || l.Port > 65535 {
+			problems = append(problems, fmt.Sprintf("%s: port %d is out of range 1-65535", id, l.Port))
+		}
+		if l.BufferSize < 1 || l.BufferSize > maxBuf {
+			problems = append(problems, fmt.Sprintf("%s: buffer_size %d must be between 1 and %d for %s",
+				id, l.BufferSize, maxBuf, l.Protocol))
+		}
+
+		if err := validateListenerHandler(*l); err != nil {
+			problems = append(problems, err.Error())
+		}
+
+		if !l.Enabled {
+			continue
+		}
+		for j := 0; j < i; j++ {
+			other := listeners[j]
+			if other.Enabled && listenersConflict(*l, other) {
+				problems = append(problems, fmt.Sprintf("%s: %s %s:%d conflicts with listener_configs[%d] %q",
+					id, socketFamily(l.Protocol), l.Host, l.Port, j, other.Name))
+			}
+		}
+		if serverConflict(*l, server) {
+			problems = append(problems, fmt.Sprintf("%s: TCP %s:%d conflicts with server_config port", id, l.Host, l.Port))
+		}
+	}
+
+	if len(problems) > 0 {
+		return errors.New(op).Msgf("invalid listener configuration: %s", strings.Join(problems, "; "))
+	}
+
+	return nil
+}
+
+// listenerPortConflict returns the name of the listener in existing that would bind the same address as cfg,
+// or an empty string if there is none.
+func listenerPortConflict(existing []types.ListenerConfig, cfg types.ListenerConfig) string {
+	for _, l := range existing {
+		if listenersConflict(l, cfg) {
+			return l.Name
+		}
+	}
+	return ""
+}
+
+// AddListenerConfig appends a listener configuration and persists it. The listener name must be unique and,
+// unless its handler is a client handler, its address must not conflict with another listener, enabled or not,
+// or with the server.
+func (s *Service) AddListenerConfig(cfg types.ListenerConfig) error {
+	const op errors.Op = "config.Service.AddListenerConfig"
+	if !s.isInitialized.Load() {
+		return errors.New(op).Msg(errMsgNotInitialized)
+	}
+
+	cfg.Name = strings.TrimSpace(cfg.Name)
+	if cfg.Name == "" {
+		return errors.New(op).Msg("listener name cannot be empty")
+	}
+	cfg.Protocol = strings.ToUpper(strings.TrimSpace(cfg.Protocol))
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	snap := s.current()
+
+	for _, l := range snap.AppConfig.ListenerConfigs {
+		if l.Name == cfg.Name {
+			return errors.New(op).Msgf("listener %q already exists", cfg.Name)
+		}
+	}
+	if other := listenerPortConflict(snap.AppConfig.ListenerConfigs, cfg); other != "" {
+		return errors.New(op).Msgf("port %d/%s is already used by listener %q", cfg.Port, cfg.Protocol, other)
+	}
+	if serverConflict(cfg, snap.AppConfig.ServerConfig) {
+		return errors.New(op).Msgf("port %d/%s is already used by the server", cfg.Port, cfg.Protocol)
+	}
+
+	updated := snap.AppConfig
+	updated.ListenerConfigs = append(slices.Clone(snap.AppConfig.ListenerConfigs), cfg)
+
+	if err := s.saveConfig(updated, snap.Extensions); err != nil {
+		return errors.New(op).Err(err)
+	}
+
+	return nil
+}
+
+// AddListenerFromTemplate adds the named listener template with its default name and port. To change either,
+// fetch the template with ListenerTemplate, modify it and pass it to AddListenerConfig.
+func (s *Service) AddListenerFromTemplate(templateName string) (types.ListenerConfig, error) {
+	const op errors.Op = "config.Service.AddListenerFromTemplate"
+
+	cfg, err := ListenerTemplate(templateName)
+	if err != nil {
+		return types.ListenerConfig{}, errors.New(op).Err(err)
+	}
+	if err = s.AddListenerConfig(cfg); err != nil {
+		return types.ListenerConfig{}, errors.New(op).Err(err)
+	}
+
+	return cfg, nil
+}
@@ -0,0 +1,135 @@
+package config
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/Station-Manager/types"
+)
+
+// TestListenerTemplates_valid ensures every built-in template passes handler validation.
+func TestListenerTemplates_valid(t *testing.T) {
+	for _, name := range ListenerTemplates() {
+		cfg, err := ListenerTemplate(name)
+		if err != nil {
+			t.Fatalf("ListenerTemplate(%q) error = %v", name, err)
+		}
+		if err = validateListenerHandler(cfg); err != nil {
+			t.Errorf("template %q failed validation: %v", name, err)
+		}
+	}
+
+	if _, err := ListenerTemplate("nope"); err == nil {
+		t.Errorf("expected error for unknown template")
+	}
+}
+
+func TestAddListenerFromTemplate(t *testing.T) {
+	workDir := t.TempDir()
+	svc := &Service{WorkingDir: workDir}
+	if err := svc.Initialize(); err != nil {
+		t.Fatalf("Initialize() error = %v", err)
+	}
+
+	if _, err := svc.AddListenerFromTemplate(N1mmListenerTemplate); err != nil {
+		t.Fatalf("AddListenerFromTemplate() error = %v", err)
+	}
+
+	// The default config already has a WSJT-X listener on 2237/UDP.
+	if _, err := svc.AddListenerFromTemplate(WsjtxListenerTemplate); err == nil {
+		t.Errorf("expected error for duplicate listener")
+	}
+	cfg, _ := ListenerTemplate(AdifUdpListenerTemplate)
+	cfg.Port, cfg.Protocol = 2237, " udp "
+	if err := svc.AddListenerConfig(cfg); err == nil || !strings.Contains(ErrorMessage(err), "port 2237/UDP") {
+		t.Errorf("expected a port collision reported with the normalized protocol, got %v", err)
+	}
+
+	// Reload from disk and check the new listener was persisted.
+	reloaded := &Service{WorkingDir: workDir}
+	if err := reloaded.Initialize(); err != nil {
+		t.Fatalf("Initialize() error = %v", err)
+	}
+	var n1mm N1mmHandlerConfig
+	if err := reloaded.ListenerHandlerConfig("N1MM+", &n1mm); err != nil {
+		t.Fatalf("ListenerHandlerConfig() error = %v", err)
+	}
+	if len(n1mm.MessageTypes) != 3 {
+		t.Errorf("expected default N1MM+ message types, got %v", n1mm.MessageTypes)
+	}
+}
+
+func TestValidateListeners(t *testing.T) {
+	wsjtx, _ := ListenerTemplate(WsjtxListenerTemplate)
+	adif, _ := ListenerTemplate(AdifTcpListenerTemplate)
+
+	// Same port on different protocols and on distinct hosts is fine.
+	udpOnTcpPort := wsjtx
+	udpOnTcpPort.Name, udpOnTcpPort.Port = "other", adif.Port
+	otherHost := wsjtx
+	otherHost.Name, otherHost.Host = "lan", "192.168.1.10"
+	if err := validateListeners([]types.ListenerConfig{wsjtx, adif, udpOnTcpPort, otherHost}, nil); err != nil {
+		t.Fatalf("validateListeners() error = %v", err)
+	}
+
+	wildcard := wsjtx
+	wildcard.Name, wildcard.Host = "any", "0.0.0.0"
+	err := validateListeners([]types.ListenerConfig{wsjtx, wildcard}, nil)
+	if err == nil || !strings.Contains(err.Error(), `listener_configs[1] "any"`) || !strings.Contains(err.Error(), `listener_configs[0] "WSJT-X"`) {
+		t.Errorf("expected conflict naming both listeners, got %v", err)
+	}
+
+	// Disabled listeners do not bind and are ignored for conflicts.
+	wildcard.Enabled = false
+	if err = validateListeners([]types.ListenerConfig{wsjtx, wildcard}, nil); err != nil {
+		t.Errorf("expected disabled listener to be ignored, got %v", err)
+	}
+
+	server := &types.ServerConfig{Port: adif.Port}
+	if err = validateListeners([]types.ListenerConfig{adif}, server); err == nil {
+		t.Errorf("expected conflict with server port")
+	}
+
+	// The fldigi handler connects to fldigi's port rather than binding it.
+	fldigi, _ := ListenerTemplate(FldigiListenerTemplate)
+	onFldigiPort := adif
+	onFldigiPort.Port = fldigi.Port
+	if err = validateListeners([]types.ListenerConfig{fldigi, onFldigiPort}, &types.ServerConfig{Port: fldigi.Port}); err == nil ||
+		strings.Contains(err.Error(), `"fldigi"`) {
+		t.Errorf("expected only the ADIF listener to conflict with the server, got %v", err)
+	}
+
+	bad := wsjtx
+	bad.Protocol = "sctp"
+	if err = validateListeners([]types.ListenerConfig{bad}, nil); err == nil {
+		t.Errorf("expected error for unsupported protocol")
+	}
+	bad = wsjtx
+	bad.BufferSize = 70000
+	if err = validateListeners([]types.ListenerConfig{bad}, nil); err == nil {
+		t.Errorf("expected error for oversized UDP buffer")
+	}
+	bad.Protocol = "tcp"
+	listeners := []types.ListenerConfig{bad}
+	if err = validateListeners(listeners, nil); err != nil {
+		t.Errorf("expected large TCP buffer to be accepted, got %v", err)
+	}
+	if listeners[0].Protocol != "tcp" {
+		t.Errorf("expected the caller's listeners to be left unchanged, got protocol %q", listeners[0].Protocol)
+	}
+	bad.BufferSize = -1
+	if err = validateListeners([]types.ListenerConfig{bad}, nil); err == nil {
+		t.Errorf("expected error for negative buffer size")
+	}
+
+	// Small buffers from older configurations are kept; a missing one gets the default.
+	small, unset := wsjtx, adif
+	small.BufferSize, unset.BufferSize, unset.Protocol = 512, 0, " tcp"
+	if err = validateListeners([]types.ListenerConfig{small, unset}, nil); err != nil {
+		t.Errorf("expected small and unset buffer sizes to be accepted, got %v", err)
+	}
+	normalized := normalizeListeners([]types.ListenerConfig{small, unset})
+	if normalized[0].BufferSize != 512 || normalized[1].BufferSize != defaultListenerBufferSize || normalized[1].Protocol != ProtocolTCP {
+		t.Errorf("normalizeListeners() = %+v", normalized)
+	}
+}
@@ -0,0 +1,315 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/Station-Manager/errors"
+	"github.com/Station-Manager/types"
+)
+
+// lookupProvider describes a callsign lookup provider: its defaults and which fields must be configured
+// before it can be enabled.
+type lookupProvider struct {
+	defaults types.LookupConfig
+	// requiresCredentials providers need a Username and Password.
+	requiresCredentials bool
+	// local providers read a file from disk; URL holds its path rather than an HTTP URL.
+	local bool
+	// options are the default fallback chain position, caching, rate-limit and retry settings.
+	options LookupProviderOptions
+}
+
+// lookupProviders is the registry of supported providers. The default priorities put the full-record services
+// first and the offline prefix data last, as the fallback of last resort.
+var lookupProviders = map[string]lookupProvider{
+	types.QrzLookupServiceName: {
+		defaults:            qrzLookupDefaults,
+		requiresCredentials: true,
+		options:             LookupProviderOptions{Name: types.QrzLookupServiceName, Priority: 10, CacheTTLSec: 86400, MaxRetries: 1, RetryDelayMS: 500},
+	},
+	HamQthLookupServiceName: {
+		defaults:            hamqthLookupDefaults,
+		requiresCredentials: true,
+		options:             LookupProviderOptions{Name: HamQthLookupServiceName, Priority: 20, CacheTTLSec: 86400, MaxRetries: 1, RetryDelayMS: 500},
+	},
+	CallookLookupServiceName: {
+		defaults: callookLookupDefaults,
+		options:  LookupProviderOptions{Name: CallookLookupServiceName, Priority: 30, CacheTTLSec: 86400, RateLimitPerMinute: 60, MaxRetries: 1, RetryDelayMS: 1000},
+	},
+	types.HamNutLookupServiceName: {
+		defaults: hamnutLookupDefaults,
+		options:  LookupProviderOptions{Name: types.HamNutLookupServiceName, Priority: 40, CacheTTLSec: 86400, MaxRetries: 1, RetryDelayMS: 500},
+	},
+	CtyDatLookupServiceName: {
+		defaults: ctyDatLookupDefaults,
+		local:    true,
+		options:  LookupProviderOptions{Name: CtyDatLookupServiceName, Priority: 100},
+	},
+}
+
+// LookupProviders returns the sorted names of the supported lookup providers.
+func LookupProviders() []string {
+	names := make([]string, 0, len(lookupProviders))
+	for name := range lookupProviders {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// LookupProviderDefaults returns the default, disabled configuration for the named lookup provider.
+func LookupProviderDefaults(name string) (types.LookupConfig, error) {
+	const op errors.Op = "config.LookupProviderDefaults"
+	p, ok := lookupProviders[strings.TrimSpace(name)]
+	if !ok {
+		return types.LookupConfig{}, errors.New(op).Msgf("unknown lookup provider %q (available: %s)",
+			name, strings.Join(LookupProviders(), ", "))
+	}
+	return p.defaults, nil
+}
+
+// isPlaceholder reports whether a setting is empty or still holds the generated placeholder.
+func isPlaceholder(v string) bool {
+	v = strings.TrimSpace(v)
+	return v == "" || v == placeholderValue
+}
+
+// applyLookupDefaults fills zero-valued fields of cfg from the provider's defaults.
+func applyLookupDefaults(cfg *types.LookupConfig, p lookupProvider) {
+	if cfg.URL == "" {
+		cfg.URL = p.defaults.URL
+	}
+	if cfg.UserAgent == "" {
+		cfg.UserAgent = p.defaults.UserAgent
+	}
+	if cfg.HttpTimeoutSec == 0 {
+		cfg.HttpTimeoutSec = p.defaults.HttpTimeoutSec
+	}
+	if cfg.ViewUrl == "" {
+		cfg.ViewUrl = p.defaults.ViewUrl
+	}
+	if p.requiresCredentials {
+		if cfg.Username == "" {
+			cfg.Username = placeholderValue
+		}
+		if cfg.Password == "" {
+			cfg.Password = placeholderValue
+		}
+	}
+}
+
+// validateLookupConfig checks a single lookup configuration against its provider's rules. Disabled
+// providers may keep placeholder credentials.
+func validateLookupConfig(cfg types.LookupConfig) error {
+	const op errors.Op = "config.validateLookupConfig"
+
+	p, ok := lookupProviders[cfg.Name]
+	if !ok {
+		return errors.New(op).Msgf("unknown lookup provider %q", cfg.Name)
+	}
+	if cfg.HttpTimeoutSec < 0 {
+		return errors.New(op).Msgf("%s: timeout_sec cannot be negative", cfg.Name)
+	}
+	if !cfg.Enabled {
+		return nil
+	}
+
+	if isPlaceholder(cfg.URL) {
+		return errors.New(op).Msgf("%s is enabled but url is not configured", cfg.Name)
+	}
+	if !p.local {
+		u, err := url.Parse(cfg.URL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return errors.New(op).Msgf("%s: url %q must be an http or https URL", cfg.Name, cfg.URL)
+		}
+		if cfg.HttpTimeoutSec == 0 {
+			return errors.New(op).Msgf("%s: timeout_sec must be greater than zero", cfg.Name)
+		}
+	}
+	if p.requiresCredentials {
+		if isPlaceholder(cfg.Username) {
+			return errors.New(op).Msgf("%s is enabled but username is not configured", cfg.Name)
+		}
+		if isPlaceholder(cfg.Password) {
+			return errors.New(op).Msgf("%s is enabled but password is not configured", cfg.Name)
+		}
+	}
+
+	return nil
+}
+
+// validateLookupConfigs checks every lookup configuration and that provider names are unique. All problems
+// found are reported together.
+func validateLookupConfigs(cfgs []types.LookupConfig) error {
+	const op errors.Op = "config.validateLookupConfigs"
+
+	var problems []string
+	seen := make(map[string]int, len(cfgs))
+	for i, cfg := range cfgs {
+		if j, dup := seen[cfg.Name]; dup {
+			problems = append(problems, fmt.Sprintf("lookup_service_configs[%d]: %q already configured at lookup_service_configs[%d]", i, cfg.Name, j))
+			continue
+		}
+		seen[cfg.Name] = i
+		if err := validateLookupConfig(cfg); err != nil {
+			problems = append(problems, fmt.Sprintf("lookup_service_configs[%d]: %s", i, err.Error()))
+		}
+	}
+
+	if len(problems) > 0 {
+		return errors.New(op).Msgf("invalid lookup service configuration: %s", strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// LookupServiceConfigs returns all configured lookup providers, enabled or not.
+func (s *Service) LookupServiceConfigs() ([]types.LookupConfig, error) {
+	const op errors.Op = "config.Service.LookupServiceConfigs"
+	var emptyRetVal []types.LookupConfig
+	if !s.isInitialized.Load() {
+		return emptyRetVal, errors.New(op).Msg(errMsgNotInitialized)
+	}
+
+	snap := s.current()
+	return deepCopy(snap.AppConfig.LookupServiceConfigs), nil
+}
+
+// AddLookupServiceConfig adds a lookup provider and persists the change. The name must be a supported provider
+// that is not already configured; zero-valued fields are filled from the provider's defaults.
+func (s *Service) AddLookupServiceConfig(cfg types.LookupConfig) error {
+	const op errors.Op = "config.Service.AddLookupServiceConfig"
+	if !s.isInitialized.Load() {
+		return errors.New(op).Msg(errMsgNotInitialized)
+	}
+
+	cfg.Name = strings.TrimSpace(cfg.Name)
+	p, ok := lookupProviders[cfg.Name]
+	if !ok {
+		return errors.New(op).Msgf("unknown lookup provider %q (available: %s)", cfg.Name, strings.Join(LookupProviders(), ", "))
+	}
+	applyLookupDefaults(&cfg, p)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	snap := s.current()
+
+	if slices.ContainsFunc(snap.AppConfig.LookupServiceConfigs, func(c types.LookupConfig) bool { return c.Name == cfg.Name }) {
+		return errors.New(op).Msgf("lookup service %q is already configured", cfg.Name)
+	}
+
+	updated := snap.AppConfig
+	updated.LookupServiceConfigs = append(slices.Clone(snap.AppConfig.LookupServiceConfigs), cfg)
+	if err := s.saveConfig(updated, snap.Extensions); err != nil {
+		return errors.New(op).Err(err)
+	}
+	return nil
+}
+
+// UpdateLookupServiceConfig replaces the configuration of an existing lookup provider and persists the change.
+func (s *Service) UpdateLookupServiceConfig(cfg types.LookupConfig) error {
+	const op errors.Op = "config.Service.UpdateLookupServiceConfig"
+	if !s.isInitialized.Load() {
+		return errors.New(op).Msg(errMsgNotInitialized)
+	}
+
+	cfg.Name = strings.TrimSpace(cfg.Name)
+	err := s.modifyLookupServiceConfig(cfg.Name, func(c *types.LookupConfig) { *c = cfg })
+	if err != nil {
+		return errors.New(op).Err(err)
+	}
+	return nil
+}
+
+// EnableLookupService enables the named lookup provider and persists the change. It fails if the provider's
+// required settings, such as credentials, are not configured.
+func (s *Service) EnableLookupService(serviceName string) error {
+	const op errors.Op = "config.Service.EnableLookupService"
+	if !s.isInitialized.Load() {
+		return errors.New(op).Msg(errMsgNotInitialized)
+	}
+	if err := s.modifyLookupServiceConfig(strings.TrimSpace(serviceName), func(c *types.LookupConfig) { c.Enabled = true }); err != nil {
+		return errors.New(op).Err(err)
+	}
+	return nil
+}
+
+// DisableLookupService disables the named lookup provider and persists the change.
+func (s *Service) DisableLookupService(serviceName string) error {
+	const op errors.Op = "config.Service.DisableLookupService"
+	if !s.isInitialized.Load() {
+		return errors.New(op).Msg(errMsgNotInitialized)
+	}
+	if err := s.modifyLookupServiceConfig(strings.TrimSpace(serviceName), func(c *types.LookupConfig) { c.Enabled = false }); err != nil {
+		return errors.New(op).Err(err)
+	}
+	return nil
+}
+
+// DeleteLookupServiceConfig removes the named lookup provider and persists the change.
+func (s *Service) DeleteLookupServiceConfig(serviceName string) error {
+	const op errors.Op = "config.Service.DeleteLookupServiceConfig"
+	if !s.isInitialized.Load() {
+		return errors.New(op).Msg(errMsgNotInitialized)
+	}
+
+	serviceName = strings.TrimSpace(serviceName)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	snap := s.current()
+
+	idx := slices.IndexFunc(snap.AppConfig.LookupServiceConfigs, func(c types.LookupConfig) bool { return c.Name == serviceName })
+	if idx < 0 {
+		return errors.New(op).Msgf("service config not found for: %s", serviceName)
+	}
+
+	updated := snap.AppConfig
+	updated.LookupServiceConfigs = slices.Delete(slices.Clone(snap.AppConfig.LookupServiceConfigs), idx, idx+1)
+
+	// Drop the provider's options too, so they do not refer to a missing provider.
+	ext := snap.Extensions
+	ext.LookupOptions.Providers = slices.DeleteFunc(slices.Clone(snap.Extensions.LookupOptions.Providers),
+		func(o LookupProviderOptions) bool { return o.Name == serviceName })
+
+	if err := s.saveConfig(updated, ext); err != nil {
+		return errors.New(op).Err(err)
+	}
+	return nil
+}
+
+// modifyLookupServiceConfig applies fn to a copy of the named lookup configuration and saves the result.
+func (s *Service) modifyLookupServiceConfig(serviceName string, fn func(*types.LookupConfig)) error {
+	const op errors.Op = "config.Service.modifyLookupServiceConfig"
+
+	if serviceName == "" {
+		return errors.New(op).Msg("service name cannot be empty")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	snap := s.current()
+
+	idx := slices.IndexFunc(snap.AppConfig.LookupServiceConfigs, func(c types.LookupConfig) bool { return c.Name == serviceName })
+	if idx < 0 {
+		return errors.New(op).Msgf("service config not found for: %s", serviceName)
+	}
+
+	updated := snap.AppConfig
+	updated.LookupServiceConfigs = slices.Clone(snap.AppConfig.LookupServiceConfigs)
+	fn(&updated.LookupServiceConfigs[idx])
+	if updated.LookupServiceConfigs[idx].Name != serviceName {
+		return errors.New(op).Msg("lookup service name cannot be changed")
+	}
+
+	if err := s.saveConfig(updated, snap.Extensions); err != nil {
+		return errors.New(op).Err(err)
+	}
+	return nil
+}
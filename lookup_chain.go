@@ -0,0 +1,198 @@
+package config
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/Station-Manager/errors"
+	"github.com/Station-Manager/types"
+)
+
+// LookupOptions controls callsign lookup as a whole and the order in which providers are tried.
+type LookupOptions struct {
+	// CallsignLookupEnabled turns on callsign lookup. At least one lookup provider must be enabled when set.
+	CallsignLookupEnabled bool `json:"callsign_lookup_enabled"`
+	// Providers holds per-provider tuning. Providers without an entry use their built-in defaults.
+	Providers []LookupProviderOptions `json:"providers,omitempty"`
+}
+
+// LookupProviderOptions holds the fallback chain position, caching, rate-limit and retry settings of a lookup
+// provider. It is linked to a types.LookupConfig by Name.
+type LookupProviderOptions struct {
+	Name string `json:"name"`
+	// Priority orders the fallback chain; lower values are tried first.
+	Priority int `json:"priority"`
+	// CacheTTLSec is how long a result is cached. Zero disables caching.
+	CacheTTLSec int `json:"cache_ttl_sec"`
+	// RateLimitPerMinute caps the number of requests per minute. Zero means unlimited.
+	RateLimitPerMinute int `json:"rate_limit_per_minute"`
+	// MaxRetries is the number of retries after a failed request before falling back to the next provider.
+	MaxRetries int `json:"max_retries"`
+	// RetryDelayMS is the delay between retries. The unit is milliseconds.
+	RetryDelayMS int `json:"retry_delay_ms"`
+}
+
+// LookupChainEntry is an enabled lookup provider together with its effective options.
+type LookupChainEntry struct {
+	Config  types.LookupConfig
+	Options LookupProviderOptions
+}
+
+const maxLookupRetries = 10
+
+// lookupProviderOptions returns the effective options for the named provider: the configured entry, or the
+// provider's defaults if there is none.
+func lookupProviderOptions(opts LookupOptions, name string) LookupProviderOptions {
+	for _, o := range opts.Providers {
+		if o.Name == name {
+			return o
+		}
+	}
+	if p, ok := lookupProviders[name]; ok {
+		return p.options
+	}
+	return LookupProviderOptions{Name: name}
+}
+
+// buildLookupChain returns the enabled providers ordered by priority. Providers with equal priority keep
+// their order in cfgs.
+func buildLookupChain(cfgs []types.LookupConfig, opts LookupOptions) []LookupChainEntry {
+	chain := make([]LookupChainEntry, 0, len(cfgs))
+	for _, cfg := range cfgs {
+		if !cfg.Enabled {
+			continue
+		}
+		chain = append(chain, LookupChainEntry{Config: cfg, Options: lookupProviderOptions(opts, cfg.Name)})
+	}
+	sort.SliceStable(chain, func(i, j int) bool { return chain[i].Options.Priority < chain[j].Options.Priority })
+	return chain
+}
+
+// validateLookupOptions checks the provider options against the configured providers, and that the chain is
+// non-empty when callsign lookup is turned on. All problems found are reported together.
+func validateLookupOptions(opts LookupOptions, cfgs []types.LookupConfig) error {
+	const op errors.Op = "config.validateLookupOptions"
+
+	var problems []string
+	seen := make(map[string]bool, len(opts.Providers))
+	for i, o := range opts.Providers {
+		id := fmt.Sprintf("lookup_options.providers[%d] %q", i, o.Name)
+		if seen[o.Name] {
+			problems = append(problems, fmt.Sprintf("%s: duplicate entry for provider", id))
+			continue
+		}
+		seen[o.Name] = true
+
+		if !slices.ContainsFunc(cfgs, func(c types.LookupConfig) bool { return c.Name == o.Name }) {
+			problems = append(problems, fmt.Sprintf("%s: no lookup_service_configs entry with this name", id))
+		}
+		if o.Priority < 0 {
+			problems = append(problems, fmt.Sprintf("%s: priority cannot be negative", id))
+		}
+		if o.CacheTTLSec < 0 {
+			problems = append(problems, fmt.Sprintf("%s: cache_ttl_sec cannot be negative", id))
+		}
+		if o.RateLimitPerMinute < 0 {
+			problems = append(problems, fmt.Sprintf("%s: rate_limit_per_minute cannot be negative", id))
+		}
+		if o.MaxRetries < 0 || o.MaxRetries > maxLookupRetries {
+			problems = append(problems, fmt.Sprintf("%s: max_retries must be between 0 and %d", id, maxLookupRetries))
+		}
+		if o.RetryDelayMS < 0 || o.RetryDelayMS > 60000 {
+			problems = append(problems, fmt.Sprintf("%s: retry_delay_ms must be between 0 and 60000", id))
+		}
+	}
+
+	if opts.CallsignLookupEnabled && len(buildLookupChain(cfgs, opts)) == 0 {
+		problems = append(problems, "lookup_options.callsign_lookup_enabled is set but no lookup provider is enabled")
+	}
+
+	if len(problems) > 0 {
+		return errors.New(op).Msgf("invalid lookup options: %s", strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// LookupChain returns the enabled lookup providers in the order they should be tried, each with its
+// effective priority, cache, rate-limit and retry options. The chain is empty if callsign lookup is off.
+func (s *Service) LookupChain() ([]LookupChainEntry, error) {
+	const op errors.Op = "config.Service.LookupChain"
+	if !s.isInitialized.Load() {
+		return nil, errors.New(op).Msg(errMsgNotInitialized)
+	}
+
+	snap := s.current()
+	if !snap.Extensions.LookupOptions.CallsignLookupEnabled {
+		return []LookupChainEntry{}, nil
+	}
+	return deepCopy(buildLookupChain(snap.AppConfig.LookupServiceConfigs, snap.Extensions.LookupOptions)), nil
+}
+
+// LookupProviderOptions returns the effective options of the named lookup provider.
+func (s *Service) LookupProviderOptions(serviceName string) (LookupProviderOptions, error) {
+	const op errors.Op = "config.Service.LookupProviderOptions"
+	emptyRetVal := LookupProviderOptions{}
+	if !s.isInitialized.Load() {
+		return emptyRetVal, errors.New(op).Msg(errMsgNotInitialized)
+	}
+
+	snap := s.current()
+
+	serviceName = strings.TrimSpace(serviceName)
+	if !slices.ContainsFunc(snap.AppConfig.LookupServiceConfigs, func(c types.LookupConfig) bool { return c.Name == serviceName }) {
+		return emptyRetVal, errors.New(op).Msgf("service config not found for: %s", serviceName)
+	}
+	return deepCopy(lookupProviderOptions(snap.Extensions.LookupOptions, serviceName)), nil
+}
+
+// SetLookupProviderOptions adds or replaces the options of the lookup provider named in opts and persists the
+// change.
+func (s *Service) SetLookupProviderOptions(opts LookupProviderOptions) error {
+	const op errors.Op = "config.Service.SetLookupProviderOptions"
+	if !s.isInitialized.Load() {
+		return errors.New(op).Msg(errMsgNotInitialized)
+	}
+
+	opts.Name = strings.TrimSpace(opts.Name)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	snap := s.current()
+
+	ext := snap.Extensions
+	ext.LookupOptions.Providers = slices.Clone(snap.Extensions.LookupOptions.Providers)
+	if idx := slices.IndexFunc(ext.LookupOptions.Providers, func(o LookupProviderOptions) bool { return o.Name == opts.Name }); idx >= 0 {
+		ext.LookupOptions.Providers[idx] = opts
+	} else {
+		ext.LookupOptions.Providers = append(ext.LookupOptions.Providers, opts)
+	}
+
+	if err := s.saveConfig(snap.AppConfig, ext); err != nil {
+		return errors.New(op).Err(err)
+	}
+	return nil
+}
+
+// SetCallsignLookupEnabled turns callsign lookup on or off and persists the change. Turning it on fails if no
+// lookup provider is enabled.
+func (s *Service) SetCallsignLookupEnabled(enabled bool) error {
+	const op errors.Op = "config.Service.SetCallsignLookupEnabled"
+	if !s.isInitialized.Load() {
+		return errors.New(op).Msg(errMsgNotInitialized)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	snap := s.current()
+
+	ext := snap.Extensions
+	ext.LookupOptions.CallsignLookupEnabled = enabled
+	if err := s.saveConfig(snap.AppConfig, ext); err != nil {
+		return errors.New(op).Err(err)
+	}
+	return nil
+}
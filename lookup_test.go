@@ -0,0 +1,143 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/Station-Manager/types"
+)
+
+func TestValidateLookupConfig_placeholders(t *testing.T) {
+	qrz, err := LookupProviderDefaults(types.QrzLookupServiceName)
+	if err != nil {
+		t.Fatalf("LookupProviderDefaults() error = %v", err)
+	}
+	if err = validateLookupConfig(qrz); err != nil {
+		t.Errorf("disabled provider with placeholders should be valid, got %v", err)
+	}
+
+	qrz.Enabled = true
+	if err = validateLookupConfig(qrz); err == nil {
+		t.Errorf("expected error for enabled provider with placeholder credentials")
+	}
+
+	qrz.Username, qrz.Password = "g4abc", "secret"
+	if err = validateLookupConfig(qrz); err != nil {
+		t.Errorf("validateLookupConfig() error = %v", err)
+	}
+
+	qrz.URL = "ftp://example.com"
+	if err = validateLookupConfig(qrz); err == nil {
+		t.Errorf("expected error for non-http URL")
+	}
+
+	cty, _ := LookupProviderDefaults(CtyDatLookupServiceName)
+	cty.Enabled = true
+	if err = validateLookupConfig(cty); err != nil {
+		t.Errorf("local provider should accept a file path, got %v", err)
+	}
+
+	if err = validateLookupConfig(types.LookupConfig{Name: "nope"}); err == nil {
+		t.Errorf("expected error for unknown provider")
+	}
+}
+
+func TestLookupServiceCRUD(t *testing.T) {
+	workDir := t.TempDir()
+	svc := &Service{WorkingDir: workDir}
+	if err := svc.Initialize(); err != nil {
+		t.Fatalf("Initialize() error = %v", err)
+	}
+
+	if err := svc.EnableLookupService(types.QrzLookupServiceName); err == nil {
+		t.Fatalf("expected enabling QRZ with placeholder credentials to fail")
+	}
+
+	if err := svc.DeleteLookupServiceConfig(HamQthLookupServiceName); err != nil {
+		t.Fatalf("DeleteLookupServiceConfig() error = %v", err)
+	}
+	if err := svc.AddLookupServiceConfig(types.LookupConfig{Name: HamQthLookupServiceName, Username: "g4abc", Password: "secret"}); err != nil {
+		t.Fatalf("AddLookupServiceConfig() error = %v", err)
+	}
+	if err := svc.AddLookupServiceConfig(types.LookupConfig{Name: HamQthLookupServiceName}); err == nil {
+		t.Errorf("expected error adding a provider twice")
+	}
+	if err := svc.EnableLookupService(HamQthLookupServiceName); err != nil {
+		t.Fatalf("EnableLookupService() error = %v", err)
+	}
+
+	reloaded := &Service{WorkingDir: workDir}
+	if err := reloaded.Initialize(); err != nil {
+		t.Fatalf("Initialize() error = %v", err)
+	}
+	cfg, err := reloaded.LookupServiceConfig(HamQthLookupServiceName)
+	if err != nil {
+		t.Fatalf("LookupServiceConfig() error = %v", err)
+	}
+	if !cfg.Enabled || cfg.URL != hamqthLookupDefaults.URL {
+		t.Errorf("expected enabled provider with default URL, got %+v", cfg)
+	}
+
+	if err = reloaded.DisableLookupService(HamQthLookupServiceName); err != nil {
+		t.Fatalf("DisableLookupService() error = %v", err)
+	}
+	if err = reloaded.DeleteLookupServiceConfig("missing"); err == nil {
+		t.Errorf("expected error deleting an unknown provider")
+	}
+}
+
+func TestLookupChain(t *testing.T) {
+	workDir := t.TempDir()
+	svc := &Service{WorkingDir: workDir}
+	if err := svc.Initialize(); err != nil {
+		t.Fatalf("Initialize() error = %v", err)
+	}
+
+	if err := svc.SetCallsignLookupEnabled(true); err == nil {
+		t.Fatalf("expected error turning on lookup with no enabled provider")
+	}
+
+	for _, name := range []string{CtyDatLookupServiceName, CallookLookupServiceName} {
+		if err := svc.EnableLookupService(name); err != nil {
+			t.Fatalf("EnableLookupService(%q) error = %v", name, err)
+		}
+	}
+	if err := svc.SetCallsignLookupEnabled(true); err != nil {
+		t.Fatalf("SetCallsignLookupEnabled() error = %v", err)
+	}
+
+	chain, err := svc.LookupChain()
+	if err != nil {
+		t.Fatalf("LookupChain() error = %v", err)
+	}
+	if len(chain) != 2 || chain[0].Config.Name != CallookLookupServiceName || chain[1].Config.Name != CtyDatLookupServiceName {
+		t.Fatalf("unexpected chain order: %+v", chain)
+	}
+
+	// Promote cty.dat ahead of callook.info
+	opts, _ := svc.LookupProviderOptions(CtyDatLookupServiceName)
+	opts.Priority = 1
+	if err = svc.SetLookupProviderOptions(opts); err != nil {
+		t.Fatalf("SetLookupProviderOptions() error = %v", err)
+	}
+	opts.MaxRetries = -1
+	if err = svc.SetLookupProviderOptions(opts); err == nil {
+		t.Errorf("expected error for negative max_retries")
+	}
+
+	reloaded := &Service{WorkingDir: workDir}
+	if err = reloaded.Initialize(); err != nil {
+		t.Fatalf("Initialize() error = %v", err)
+	}
+	chain, _ = reloaded.LookupChain()
+	if len(chain) != 2 || chain[0].Config.Name != CtyDatLookupServiceName {
+		t.Errorf("expected cty.dat first after reload, got %+v", chain)
+	}
+
+	// Disabling the last providers while lookup is on must fail.
+	if err = reloaded.DisableLookupService(CtyDatLookupServiceName); err != nil {
+		t.Fatalf("DisableLookupService() error = %v", err)
+	}
+	if err = reloaded.DisableLookupService(CallookLookupServiceName); err == nil {
+		t.Errorf("expected error disabling the last enabled provider")
+	}
+}
@@ -0,0 +1,233 @@
+package config
+
+import (
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/Station-Manager/errors"
+	"github.com/Station-Manager/types"
+)
+
+// Option configures a Service created by New.
+type Option func(*Service) error
+
+// New returns an initialized Service configured by opts. Without options it behaves like a Service literal
+// with only WorkingDir unset: config.json is read from the resolved working directory and generated with the
+// defaults if it does not exist.
+func New(opts ...Option) (*Service, error) {
+	const op errors.Op = "config.New"
+
+	s := &Service{}
+	for _, opt := range opts {
+		if err := opt(s); err != nil {
+			return nil, errors.New(op).Err(err)
+		}
+	}
+	if err := s.Initialize(); err != nil {
+		return nil, errors.New(op).Err(err)
+	}
+	return s, nil
+}
+
+// WithWorkingDir sets the directory holding config.json. Relative TLS certificate paths are resolved against
+// it as well.
+func WithWorkingDir(dir string) Option {
+	return func(s *Service) error {
+		s.WorkingDir = dir
+		return nil
+	}
+}
+
+// WithFile reads and saves the configuration in the file at path instead of config.json in the working
+// directory.
+func WithFile(path string) Option {
+	return func(s *Service) error {
+		const op errors.Op = "config.WithFile"
+		if strings.TrimSpace(path) == "" {
+			return errors.New(op).Msg("config file path is empty")
+		}
+		s.Store = fileStore{path: path}
+		return nil
+	}
+}
+
+// WithStore reads and saves the configuration in store instead of config.json.
+func WithStore(store Store) Option {
+	return func(s *Service) error {
+		s.Store = store
+		return nil
+	}
+}
+
+// WithActor records actor and source, such as a user name and SourceUI, in the audit log entries of the changes
+// made through the service. An empty actor records the user running the process.
+func WithActor(actor string, source Source) Option {
+	return func(s *Service) error {
+		s.actor, s.source = actor, source
+		return nil
+	}
+}
+
+// WithHistoryLimit keeps the newest n revisions in the history of the configuration file. Older revisions are
+// removed and can no longer be restored with Rollback, but their audit entries stay in the log. Zero keeps
+// DefaultHistoryLimit revisions, as without the option, and UnlimitedHistory keeps every revision.
+func WithHistoryLimit(n int) Option {
+	return func(s *Service) error {
+		const op errors.Op = "config.WithHistoryLimit"
+		if n < UnlimitedHistory {
+			return errors.New(op).Msgf("invalid history limit %d: must not be negative, other than UnlimitedHistory", n)
+		}
+		s.historyLimit = n
+		return nil
+	}
+}
+
+// WithDefaults selects the profile, ProfileDesktop or ProfileServer, of the default configuration generated
+// when the file does not exist. It takes precedence over the SM_DEFAULT_DB environment variable.
+func WithDefaults(profile string) Option {
+	return func(s *Service) error {
+		const op errors.Op = "config.WithDefaults"
+		if profile != ProfileDesktop && profile != ProfileServer {
+			return errors.New(op).Msgf("unknown profile %q (available: %s, %s)", profile, ProfileDesktop, ProfileServer)
+		}
+		s.profile = profile
+		return nil
+	}
+}
+
+// WithoutFileCreation makes Initialize fail with ErrConfigNotFound if the configuration file does not exist,
+// instead of generating it with the defaults. Setting SM_CONFIG_NO_CREATE=true has the same effect.
+func WithoutFileCreation() Option {
+	return func(s *Service) error {
+		s.noCreate = true
+		return nil
+	}
+}
+
+// WithReadOnly never writes the configuration file, or anything else: Initialize fails with ErrConfigNotFound
+// if it does not exist, and every change fails with ErrReadOnly. Setting SM_CONFIG_READONLY=true has the same
+// effect.
+func WithReadOnly() Option {
+	return func(s *Service) error {
+		s.readOnly = true
+		s.noCreate = true
+		return nil
+	}
+}
+
+// WithOverrides replaces whole sections of the loaded configuration. Each value is a section of
+// types.AppConfig or Extensions, identified by its type: a types.LoggingConfig replaces logging_config and
+// an EmailOptions replaces email_options, for example. Pointer sections, such as server_config, accept the
+// value or a pointer. Overrides are applied every time the file is loaded, before validation, and again to
+// every saved change. They are never saved themselves: an overridden section is saved as it was loaded, unless
+// the change modifies it.
+func WithOverrides(sections ...any) Option {
+	return func(s *Service) error {
+		const op errors.Op = "config.WithOverrides"
+		var probe types.AppConfig
+		var probeExt Extensions
+		for _, section := range sections {
+			if err := applyOverride(&probe, &probeExt, section); err != nil {
+				return errors.New(op).Err(err)
+			}
+			s.overrides = append(s.overrides, deepCopy(section))
+		}
+		return nil
+	}
+}
+
+// applyEnvMode turns on read-only and no-create mode when requested by the environment. The environment can
+// only turn these modes on; false leaves the options in effect. An unparsable value is an error, rather than
+// silently falling back to writing the file.
+func (s *Service) applyEnvMode() error {
+	const op errors.Op = "config.Service.applyEnvMode"
+	for _, env := range []struct {
+		name string
+		set  func()
+	}{
+		{EnvSmConfigReadOnly, func() { s.readOnly, s.noCreate = true, true }},
+		{EnvSmConfigNoCreate, func() { s.noCreate = true }},
+	} {
+		v := strings.TrimSpace(os.Getenv(env.name))
+		if v == "" {
+			continue
+		}
+		on, err := strconv.ParseBool(v)
+		if err != nil {
+			return errors.New(op).Msgf("invalid %s value %q: must be true or false", env.name, v)
+		}
+		if on {
+			env.set()
+		}
+	}
+	return nil
+}
+
+// applyOverrides replaces the sections of cfg and ext named by the types of overrides.
+func applyOverrides(cfg *types.AppConfig, ext *Extensions, overrides []any) error {
+	for _, section := range overrides {
+		if err := applyOverride(cfg, ext, section); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// applyOverride sets the field of cfg or ext whose type matches section to a copy of section.
+func applyOverride(cfg *types.AppConfig, ext *Extensions, section any) error {
+	const op errors.Op = "config.applyOverride"
+	if section == nil {
+		return errors.New(op).Msg("override section is nil")
+	}
+
+	v := reflect.ValueOf(deepCopy(section))
+	f, ok := sectionField(cfg, ext, v.Type())
+	if !ok {
+		return errors.New(op).Msgf("%T is not a configuration section", section)
+	}
+	if f.Type() == v.Type() {
+		f.Set(v)
+		return nil
+	}
+	ptr := reflect.New(v.Type())
+	ptr.Elem().Set(v)
+	f.Set(ptr)
+	return nil
+}
+
+// sectionField returns the field of cfg or ext holding a section of type t, or a pointer to one.
+func sectionField(cfg *types.AppConfig, ext *Extensions, t reflect.Type) (reflect.Value, bool) {
+	for _, target := range []reflect.Value{reflect.ValueOf(cfg).Elem(), reflect.ValueOf(ext).Elem()} {
+		for i := 0; i < target.NumField(); i++ {
+			f := target.Field(i)
+			if f.Type() == t || (f.Kind() == reflect.Pointer && f.Type().Elem() == t) {
+				return f, true
+			}
+		}
+	}
+	return reflect.Value{}, false
+}
+
+// restoreOverridden sets the sections of cfg and ext replaced by overrides back to their values in stored, so
+// that overrides are never saved. If published is not nil, only the sections still equal to their published
+// value are restored: a section the caller has changed is saved with the change.
+func restoreOverridden(cfg *types.AppConfig, ext *Extensions, stored, published *Snapshot, overrides []any) {
+	for _, section := range overrides {
+		t := reflect.TypeOf(section)
+		f, ok := sectionField(cfg, ext, t)
+		if !ok {
+			continue
+		}
+		from, _ := sectionField(&stored.AppConfig, &stored.Extensions, t)
+		if published != nil {
+			pub, _ := sectionField(&published.AppConfig, &published.Extensions, t)
+			if !reflect.DeepEqual(f.Interface(), pub.Interface()) {
+				continue
+			}
+		}
+		f.Set(reflect.Zero(f.Type()))
+		copyValue(f, from)
+	}
+}
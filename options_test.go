@@ -0,0 +1,250 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	smerrors "github.com/Station-Manager/errors"
+	"github.com/Station-Manager/types"
+)
+
+func TestNew_options(t *testing.T) {
+	workDir := t.TempDir()
+	svc, err := New(WithWorkingDir(workDir), WithDefaults(ProfileServer))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	if profile, _ := svc.Profile(); profile != ProfileServer {
+		t.Errorf("expected the server defaults, got %q", profile)
+	}
+	if _, err = os.Stat(filepath.Join(workDir, configFileName)); err != nil {
+		t.Errorf("expected config.json to be generated: %v", err)
+	}
+
+	if _, err = New(WithWorkingDir(t.TempDir()), WithDefaults("laptop")); err == nil {
+		t.Errorf("expected an error for an unknown profile")
+	}
+	if _, err = New(WithFile(" ")); err == nil {
+		t.Errorf("expected an error for an empty file path")
+	}
+}
+
+func TestNew_withFile(t *testing.T) {
+	workDir := t.TempDir()
+	path := filepath.Join(workDir, "station.json")
+	svc, err := New(WithWorkingDir(workDir), WithFile(path))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	if _, err = os.Stat(path); err != nil {
+		t.Errorf("expected %s to be generated: %v", path, err)
+	}
+	if _, err = os.Stat(filepath.Join(workDir, configFileName)); !os.IsNotExist(err) {
+		t.Errorf("expected config.json not to be written")
+	}
+
+	if err = svc.SetCallsignLookupEnabled(false); err != nil {
+		t.Fatalf("SetCallsignLookupEnabled() error = %v", err)
+	}
+	reloaded, err := New(WithWorkingDir(workDir), WithFile(path), WithoutFileCreation())
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	before, _ := svc.Snapshot()
+	after, _ := reloaded.Snapshot()
+	if before.AppConfig.RequiredConfigs != after.AppConfig.RequiredConfigs {
+		t.Errorf("expected the change to be saved to %s", path)
+	}
+}
+
+func TestNew_withoutFileCreation(t *testing.T) {
+	workDir := t.TempDir()
+	if _, err := New(WithWorkingDir(workDir), WithoutFileCreation()); err == nil {
+		t.Fatalf("expected New to fail without a config file")
+	}
+	if _, err := os.Stat(filepath.Join(workDir, configFileName)); !os.IsNotExist(err) {
+		t.Errorf("expected no config file to be generated")
+	}
+}
+
+func TestNew_withReadOnly(t *testing.T) {
+	workDir := t.TempDir()
+	if _, err := New(WithWorkingDir(workDir), WithReadOnly()); err == nil {
+		t.Fatalf("expected New to fail without a config file")
+	}
+
+	if _, err := New(WithWorkingDir(workDir)); err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	cfgPath := filepath.Join(workDir, configFileName)
+	original, err := os.ReadFile(cfgPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	svc, err := New(WithWorkingDir(workDir), WithReadOnly())
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	if err = svc.SetCallsignLookupEnabled(false); err == nil {
+		t.Errorf("expected changes to be rejected")
+	}
+	if snap, _ := svc.Snapshot(); snap.Version != 1 {
+		t.Errorf("expected a rejected change not to publish, got version %d", snap.Version)
+	}
+	if current, _ := os.ReadFile(cfgPath); string(current) != string(original) {
+		t.Errorf("expected the config file to be unchanged")
+	}
+}
+
+func TestNew_withOverrides(t *testing.T) {
+	workDir := t.TempDir()
+	defaults, _ := DefaultConfig(ProfileDesktop)
+	logging := defaults.LoggingConfig
+	logging.Level = "debug"
+
+	svc, err := New(
+		WithWorkingDir(workDir),
+		WithOverrides(logging, EmailOptions{To: []string{"ops@example.com"}}),
+	)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	if got, _ := svc.LoggingConfig(); got.Level != "debug" {
+		t.Errorf("expected the logging override, got level %q", got.Level)
+	}
+	if got, _ := svc.EmailOptions(); len(got.To) != 1 || got.To[0] != "ops@example.com" {
+		t.Errorf("expected the email options override, got %+v", got)
+	}
+	// The file itself is not changed by an override.
+	plain, err := New(WithWorkingDir(workDir))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	if got, _ := plain.LoggingConfig(); got.Level == "debug" {
+		t.Errorf("expected the file to keep its own logging level")
+	}
+
+	// Saved changes keep the overrides out of the file and apply them again.
+	snap, _ := svc.Snapshot()
+	snap.AppConfig.LoggingStation.StationCallsign = "G4ABC"
+	if err = svc.UpdateAppConfig(snap.AppConfig); err != nil {
+		t.Fatalf("UpdateAppConfig() error = %v", err)
+	}
+	if got, _ := svc.LoggingConfig(); got.Level != "debug" {
+		t.Errorf("expected the logging override after a save, got level %q", got.Level)
+	}
+	saved, _, err := fileStore{path: filepath.Join(workDir, "config.json")}.Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if saved.LoggingConfig.Level == "debug" {
+		t.Errorf("expected the override not to be saved")
+	}
+	if saved.LoggingStation.StationCallsign != "G4ABC" {
+		t.Errorf("expected the change to be saved, got %q", saved.LoggingStation.StationCallsign)
+	}
+
+	// A change to an overridden section is saved, and the override still applies.
+	if err = svc.Set("logging_config.level", "warn"); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+	if got, _ := svc.LoggingConfig(); got.Level != "debug" {
+		t.Errorf("expected the logging override after a change to it, got level %q", got.Level)
+	}
+	if saved, _, _ = (fileStore{path: filepath.Join(workDir, "config.json")}).Load(); saved.LoggingConfig.Level != "warn" {
+		t.Errorf("expected the change to the overridden section to be saved, got level %q", saved.LoggingConfig.Level)
+	}
+
+	// Pointer sections accept a value.
+	serverCfg, _ := DefaultConfig(ProfileServer)
+	server := *serverCfg.ServerConfig
+	server.Port = 8443
+	svc, err = New(WithWorkingDir(t.TempDir()), WithDefaults(ProfileServer), WithOverrides(server))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	if got, _ := svc.ServerConfig(); got.Port != 8443 {
+		t.Errorf("expected the server override, got port %d", got.Port)
+	}
+
+	if _, err = New(WithWorkingDir(t.TempDir()), WithOverrides("logging")); err == nil {
+		t.Errorf("expected an error for a value that is not a section")
+	}
+	if _, err = New(WithWorkingDir(t.TempDir()), WithOverrides(types.LoggingConfig{})); err == nil {
+		t.Errorf("expected an invalid override to fail validation")
+	}
+}
+
+func TestReadOnlyMode_typedErrors(t *testing.T) {
+	workDir := t.TempDir()
+	_, err := New(WithWorkingDir(workDir), WithReadOnly())
+	if !errors.Is(err, ErrConfigNotFound) || !errors.Is(err, smerrors.ErrNotFound) {
+		t.Errorf("expected ErrConfigNotFound for a missing file, got %v", err)
+	}
+
+	svc, err := New(WithWorkingDir(workDir))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	readOnly, err := New(WithWorkingDir(workDir), WithReadOnly())
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	if err = readOnly.SetCallsignLookupEnabled(false); !errors.Is(err, ErrReadOnly) {
+		t.Errorf("expected ErrReadOnly, got %v", err)
+	}
+	snap, _ := svc.Snapshot()
+	if err = readOnly.UpdateAppConfig(snap.AppConfig); !errors.Is(err, ErrReadOnly) {
+		t.Errorf("expected ErrReadOnly from UpdateAppConfig, got %v", err)
+	}
+	// Reloading never writes, so it is still allowed.
+	if err = readOnly.Reload(context.Background()); err != nil {
+		t.Errorf("Reload() error = %v", err)
+	}
+}
+
+func TestReadOnlyMode_environment(t *testing.T) {
+	workDir := t.TempDir()
+
+	t.Setenv(EnvSmConfigNoCreate, "true")
+	svc := &Service{WorkingDir: workDir}
+	if err := svc.Initialize(); !errors.Is(err, ErrConfigNotFound) {
+		t.Errorf("expected ErrConfigNotFound with %s set, got %v", EnvSmConfigNoCreate, err)
+	}
+	if _, err := os.Stat(filepath.Join(workDir, configFileName)); !os.IsNotExist(err) {
+		t.Errorf("expected no config file to be generated")
+	}
+
+	t.Setenv(EnvSmConfigNoCreate, "")
+	if _, err := New(WithWorkingDir(workDir)); err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	t.Setenv(EnvSmConfigReadOnly, "1")
+	svc = &Service{WorkingDir: workDir}
+	if err := svc.Initialize(); err != nil {
+		t.Fatalf("Initialize() error = %v", err)
+	}
+	if err := svc.SetCallsignLookupEnabled(false); !errors.Is(err, ErrReadOnly) {
+		t.Errorf("expected ErrReadOnly with %s set, got %v", EnvSmConfigReadOnly, err)
+	}
+
+	// False cannot undo the option.
+	t.Setenv(EnvSmConfigReadOnly, "false")
+	svc, err := New(WithWorkingDir(workDir), WithReadOnly())
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	if err = svc.SetCallsignLookupEnabled(false); !errors.Is(err, ErrReadOnly) {
+		t.Errorf("expected ErrReadOnly, got %v", err)
+	}
+
+	t.Setenv(EnvSmConfigReadOnly, "maybe")
+	if _, err = New(WithWorkingDir(workDir)); err == nil {
+		t.Errorf("expected an error for an invalid %s value", EnvSmConfigReadOnly)
+	}
+}
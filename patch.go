@@ -0,0 +1,130 @@
+package config
+
+import (
+	"bytes"
+	"strings"
+
+	"github.com/Station-Manager/config/internal/jsonpatch"
+	"github.com/Station-Manager/errors"
+	"github.com/Station-Manager/types"
+	"github.com/goccy/go-json"
+)
+
+// PatchFormat is the format of a patch given to PatchAppConfig, named by its media type.
+type PatchFormat string
+
+const (
+	// MergePatch is a JSON Merge Patch (RFC 7396): an object whose members replace those of the configuration,
+	// recursively, and whose null members remove them.
+	MergePatch PatchFormat = "application/merge-patch+json"
+	// JSONPatch is a JSON Patch (RFC 6902): an array of add, remove, replace, move, copy and test operations
+	// addressed by JSON Pointers.
+	JSONPatch PatchFormat = "application/json-patch+json"
+)
+
+// PatchAppConfig applies patch to the configuration as it appears in config.json, including the sections held
+// in Extensions, and saves the result. Only the values named by the patch change, so clients editing different
+// sections do not overwrite each other's changes.
+//
+// etag is the Snapshot.ETag the patch was built against. If the configuration has changed since, the patch is
+// rejected with ErrETagMismatch and the client should read the configuration again. Quotes and a W/ prefix are
+// ignored, so the value of an HTTP If-Match header can be passed as is. An empty etag, or "*", applies the patch
+// to whatever configuration is active.
+//
+// The patched configuration is validated as a whole, and members that are not configuration fields, such as a
+// misspelled section, are rejected. On success the new snapshot, with its ETag, is returned.
+func (s *Service) PatchAppConfig(format PatchFormat, patch []byte, etag string) (Snapshot, error) {
+	const op errors.Op = "config.Service.PatchAppConfig"
+	if !s.isInitialized.Load() {
+		return Snapshot{}, errors.New(op).Msg(errMsgNotInitialized)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	snap := s.current()
+	if etag = normalizeETag(etag); etag != "" && etag != "*" && etag != snap.ETag {
+		return Snapshot{}, errors.New(op).Err(ErrETagMismatch).Msgf("%s: the patch was built against %q, the current configuration is %q",
+			ErrETagMismatch.Error(), etag, snap.ETag)
+	}
+
+	data, err := marshalConfigFile(snap.AppConfig, snap.Extensions)
+	if err != nil {
+		return Snapshot{}, errors.New(op).Err(err)
+	}
+	doc, err := decodeJSON(data)
+	if err != nil {
+		return Snapshot{}, errors.New(op).Err(err)
+	}
+
+	switch format {
+	case MergePatch:
+		p, err := decodeJSON(patch)
+		if err != nil {
+			return Snapshot{}, errors.New(op).Err(err).Msg("invalid merge patch")
+		}
+		doc = jsonpatch.Merge(doc, p)
+	case JSONPatch:
+		ops, err := jsonpatch.Decode(patch)
+		if err != nil {
+			return Snapshot{}, errors.New(op).Err(err).Msg("invalid JSON patch")
+		}
+		if doc, err = jsonpatch.Apply(doc, ops); err != nil {
+			return Snapshot{}, errors.New(op).Err(err).Msg("cannot apply the JSON patch")
+		}
+	default:
+		return Snapshot{}, errors.New(op).Msgf("unsupported patch format %q", format)
+	}
+	if _, ok := doc.(map[string]any); !ok {
+		return Snapshot{}, errors.New(op).Msg("the patched configuration must be an object")
+	}
+
+	if data, err = json.Marshal(doc); err != nil {
+		return Snapshot{}, errors.New(op).Err(err)
+	}
+	if err = checkKnownMembers(data); err != nil {
+		return Snapshot{}, errors.New(op).Err(err).Msg("the patched configuration has members that are not configuration fields")
+	}
+	var cfg types.AppConfig
+	var ext Extensions
+	if err = unmarshalConfigFile(data, &cfg, &ext); err != nil {
+		return Snapshot{}, errors.New(op).Err(err).Msg("the patched configuration is invalid")
+	}
+
+	if err = s.saveConfig(cfg, ext); err != nil {
+		return Snapshot{}, errors.New(op).Err(err)
+	}
+	return deepCopy(*s.current()), nil
+}
+
+// checkKnownMembers reports members of data, a configuration file document, that are not fields of the
+// configuration. Decoding ignores them, so a misspelled member would otherwise change nothing without an error.
+func checkKnownMembers(data []byte) error {
+	var known struct {
+		types.AppConfig
+		Extensions
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	return dec.Decode(&known)
+}
+
+// normalizeETag strips the quotes and weak prefix of an HTTP entity tag.
+func normalizeETag(etag string) string {
+	etag = strings.TrimPrefix(strings.TrimSpace(etag), "W/")
+	return strings.Trim(etag, `"`)
+}
+
+// decodeJSON decodes data into map[string]any and []any values, keeping numbers exact.
+func decodeJSON(data []byte) (any, error) {
+	if !json.Valid(data) {
+		return nil, errors.New("config.decodeJSON").Msg("not valid JSON")
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var v any
+	if err := dec.Decode(&v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
@@ -0,0 +1,134 @@
+package config
+
+import (
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestPatchAppConfig(t *testing.T) {
+	svc := &Service{WorkingDir: t.TempDir()}
+	if _, err := svc.PatchAppConfig(MergePatch, []byte(`{}`), ""); err == nil {
+		t.Fatalf("expected error before Initialize")
+	}
+	if err := svc.Initialize(); err != nil {
+		t.Fatalf("Initialize() error = %v", err)
+	}
+	snap, _ := svc.Snapshot()
+	if snap.ETag == "" {
+		t.Fatalf("expected the snapshot to have an ETag")
+	}
+
+	merged, err := svc.PatchAppConfig(MergePatch, []byte(`{"logging_config":{"level":"debug"},"station_options":{"allow_callsign_mismatch":true}}`), `"`+snap.ETag+`"`)
+	if err != nil {
+		t.Fatalf("PatchAppConfig(MergePatch) error = %v", err)
+	}
+	if merged.AppConfig.LoggingConfig.Level != "debug" || !merged.Extensions.StationOptions.AllowCallsignMismatch {
+		t.Errorf("merge patch not applied: level %q, mismatch %v", merged.AppConfig.LoggingConfig.Level, merged.Extensions.StationOptions.AllowCallsignMismatch)
+	}
+	if merged.AppConfig.LoggingConfig.WithTimestamp != snap.AppConfig.LoggingConfig.WithTimestamp || len(merged.AppConfig.RigConfigs) != len(snap.AppConfig.RigConfigs) {
+		t.Errorf("merge patch changed values it did not name")
+	}
+	if merged.ETag == snap.ETag {
+		t.Errorf("expected the ETag to change with the content")
+	}
+
+	patched, err := svc.PatchAppConfig(JSONPatch, []byte(`[
+		{"op":"test","path":"/rig_configs/0/ID","value":1},
+		{"op":"replace","path":"/rig_configs/0/SerialConfig/PortName","value":"COM3"},
+		{"op":"replace","path":"/rig_configs/0/SerialConfig/BaudRate","value":9600}
+	]`), merged.ETag)
+	if err != nil {
+		t.Fatalf("PatchAppConfig(JSONPatch) error = %v", err)
+	}
+	if rig := patched.AppConfig.RigConfigs[0]; rig.SerialConfig.PortName != "COM3" || rig.SerialConfig.BaudRate != 9600 {
+		t.Errorf("JSON patch not applied: %+v", rig.SerialConfig)
+	}
+
+	// A patch built against an older configuration is rejected.
+	_, err = svc.PatchAppConfig(MergePatch, []byte(`{"logging_config":{"level":"warn"}}`), merged.ETag)
+	if !errors.Is(err, ErrETagMismatch) {
+		t.Errorf("expected ErrETagMismatch, got %v", err)
+	}
+
+	rejected := []struct {
+		name   string
+		format PatchFormat
+		patch  string
+	}{
+		{"invalid value", MergePatch, `{"logging_config":{"level":null}}`},
+		{"wrong type", MergePatch, `{"logging_config":{"level":5}}`},
+		{"not an object", MergePatch, `["a"]`},
+		{"malformed", MergePatch, `{"logging_config":`},
+		{"failed test", JSONPatch, `[{"op":"test","path":"/logging_config/level","value":"error"},{"op":"replace","path":"/logging_config/level","value":"warn"}]`},
+		{"missing member", JSONPatch, `[{"op":"replace","path":"/bogus/level","value":"warn"}]`},
+		{"misspelled section", MergePatch, `{"server_confg":{"host":"example.com"}}`},
+		{"misspelled field", MergePatch, `{"logging_config":{"levle":"warn"}}`},
+		{"add to a misspelled path", JSONPatch, `[{"op":"add","path":"/logging_config/levle","value":"warn"}]`},
+		{"unsupported format", PatchFormat("application/json"), `{}`},
+	}
+	for _, tc := range rejected {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := svc.PatchAppConfig(tc.format, []byte(tc.patch), "*"); err == nil {
+				t.Errorf("expected error")
+			}
+		})
+	}
+	if after, _ := svc.Snapshot(); after.Version != patched.Version || after.ETag != patched.ETag {
+		t.Errorf("rejected patches were published")
+	}
+
+	// The ETag depends only on the content, so it survives a restart.
+	restarted := &Service{WorkingDir: svc.WorkingDir}
+	if err = restarted.Initialize(); err != nil {
+		t.Fatalf("Initialize() error = %v", err)
+	}
+	if again, _ := restarted.Snapshot(); again.ETag != patched.ETag {
+		t.Errorf("expected the same ETag after a restart, got %q and %q", again.ETag, patched.ETag)
+	}
+}
+
+func TestPatchAppConfig_concurrent(t *testing.T) {
+	svc := &Service{WorkingDir: t.TempDir()}
+	if err := svc.Initialize(); err != nil {
+		t.Fatalf("Initialize() error = %v", err)
+	}
+	snap, _ := svc.Snapshot()
+
+	// Two clients patch different sections against the same ETag: exactly one succeeds.
+	patches := []string{`{"logging_config":{"level":"debug"}}`, `{"optional_configs":{"qrz_view_url":"https://example.com/"}}`}
+	errs := make([]error, len(patches))
+	var wg sync.WaitGroup
+	for i, p := range patches {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, errs[i] = svc.PatchAppConfig(MergePatch, []byte(p), snap.ETag)
+		}()
+	}
+	wg.Wait()
+
+	var conflicts int
+	for _, err := range errs {
+		if errors.Is(err, ErrETagMismatch) {
+			conflicts++
+		} else if err != nil {
+			t.Errorf("PatchAppConfig() error = %v", err)
+		}
+	}
+	if conflicts != 1 {
+		t.Errorf("expected exactly one conflict, got %d", conflicts)
+	}
+
+	// Without an ETag, patches to different sections both apply.
+	for _, p := range patches {
+		if _, err := svc.PatchAppConfig(MergePatch, []byte(p), ""); err != nil {
+			t.Fatalf("PatchAppConfig() error = %v", err)
+		}
+	}
+	cfg, _ := svc.Snapshot()
+	if cfg.AppConfig.LoggingConfig.Level != "debug" || !strings.HasPrefix(cfg.AppConfig.OptionalConfigs.QrzViewUrl, "https://example.com") {
+		t.Errorf("expected both patches to apply: %q, %q", cfg.AppConfig.LoggingConfig.Level, cfg.AppConfig.OptionalConfigs.QrzViewUrl)
+	}
+}
@@ -0,0 +1,386 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/Station-Manager/config/internal/jsonpath"
+	"github.com/Station-Manager/errors"
+	"github.com/Station-Manager/types"
+	"github.com/goccy/go-json"
+)
+
+// Get returns a copy of the value at path in the active configuration, such as
+// rig_configs[id=1].serial_config.port_name. The path may name any value in config.json, including the sections
+// held in Extensions, and the value has the Go type of the field it names.
+//
+// A path is a dot-separated list of field names, each optionally followed by selectors in brackets: [0]
+// selects a list element by index and [name=QRZ] or [id=1] the element whose field has that value. Names are
+// the JSON field names, or the Go field names for types without JSON tags, and match ignoring case,
+// underscores and hyphens. Values containing dots, brackets or spaces can be quoted: [name="ADIF UDP"]. Keys of
+// maps, such as datastore_config.options.journal_mode, are matched in the same way.
+func (s *Service) Get(path string) (any, error) {
+	const op errors.Op = "config.Service.Get"
+	if !s.isInitialized.Load() {
+		return nil, errors.New(op).Msg(errMsgNotInitialized)
+	}
+
+	p, err := jsonpath.Parse(path)
+	if err != nil {
+		return nil, errors.New(op).Err(err)
+	}
+
+	snap := s.current()
+	v, err := pathRoot(&snap.AppConfig, &snap.Extensions, p[0])
+	if err != nil {
+		return nil, errors.New(op).Err(err)
+	}
+	for i, step := range p {
+		if v, err = pathChild(v, step); err != nil {
+			err = fmt.Errorf("%s: %w", p[:i+1], err)
+			return nil, errors.New(op).Err(err)
+		}
+	}
+
+	if (v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface) && v.IsNil() {
+		return nil, nil
+	}
+	return deepCopy(v.Interface()), nil
+}
+
+// Set replaces the value at path, using the syntax described by Get, and saves the configuration. value may
+// have the type of the field, or any value that decodes into it from JSON, such as a map for a struct. A
+// string is parsed as the field's type, so "38400" sets a number and "true" a boolean. A nil value clears the
+// field.
+//
+// The changed configuration is validated as a whole before it is saved, and errors name the path. Missing
+// optional sections along the path are created, except server_config and server_options, which turn a desktop
+// configuration into a server one and so can only be set as a whole. List elements and map entries other than
+// the last must exist.
+func (s *Service) Set(path string, value any) error {
+	const op errors.Op = "config.Service.Set"
+	if !s.isInitialized.Load() {
+		return errors.New(op).Msg(errMsgNotInitialized)
+	}
+
+	p, err := jsonpath.Parse(path)
+	if err != nil {
+		return errors.New(op).Err(err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	snap := s.current()
+	cfg, ext := deepCopy(snap.AppConfig), deepCopy(snap.Extensions)
+	root, err := pathRoot(&cfg, &ext, p[0])
+	if err != nil {
+		return errors.New(op).Err(err)
+	}
+	if err = setPath(root, p, 0, value); err != nil {
+		return errors.New(op).Err(err)
+	}
+
+	if err = s.saveConfig(cfg, ext); err != nil {
+		return errors.New(op).Err(err).Msgf("%s: %s", p, ErrorMessage(err))
+	}
+	return nil
+}
+
+// Unset removes the value at path, using the syntax described by Get, as if it were deleted from config.json,
+// and saves the configuration. A list element or map entry is removed; any other value becomes what a file
+// without it loads as, its default or zero value. Removing a value that is not set is not an error.
+func (s *Service) Unset(path string) error {
+	const op errors.Op = "config.Service.Unset"
+	if !s.isInitialized.Load() {
+		return errors.New(op).Msg(errMsgNotInitialized)
+	}
+
+	p, err := jsonpath.Parse(path)
+	if err != nil {
+		return errors.New(op).Err(err)
+	}
+	if _, err = pathRoot(&types.AppConfig{}, &Extensions{}, p[0]); err != nil {
+		return errors.New(op).Err(err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	snap := s.current()
+	data, err := marshalConfigFile(snap.AppConfig, snap.Extensions)
+	if err != nil {
+		return errors.New(op).Err(err)
+	}
+	doc, err := decodeJSON(data)
+	if err != nil {
+		return errors.New(op).Err(err)
+	}
+	if err = jsonpath.Delete(doc, p); err != nil {
+		return errors.New(op).Err(err)
+	}
+
+	// The document is loaded as the file would be, so that the defaults of the removed value apply.
+	if data, err = json.Marshal(doc); err != nil {
+		return errors.New(op).Err(err)
+	}
+	var cfg types.AppConfig
+	var ext Extensions
+	if err = unmarshalConfigFile(data, &cfg, &ext); err != nil {
+		return errors.New(op).Err(err)
+	}
+
+	if err = s.saveConfig(cfg, ext); err != nil {
+		return errors.New(op).Err(err).Msgf("%s: %s", p, ErrorMessage(err))
+	}
+	return nil
+}
+
+// pathRoot returns the struct holding the top-level field named by step: cfg, or ext for the sections added
+// by this package.
+func pathRoot(cfg *types.AppConfig, ext *Extensions, step jsonpath.Step) (reflect.Value, error) {
+	for _, root := range []reflect.Value{reflect.ValueOf(cfg).Elem(), reflect.ValueOf(ext).Elem()} {
+		if _, ok := structField(root, step.Key); ok {
+			return root, nil
+		}
+	}
+	return reflect.Value{}, fmt.Errorf("%s: %w", step, ErrPathNotFound)
+}
+
+// pathChild returns the value selected by step within v, following pointers and interfaces.
+func pathChild(v reflect.Value, step jsonpath.Step) (reflect.Value, error) {
+	for v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return reflect.Value{}, fmt.Errorf("parent is not set: %w", ErrPathNotFound)
+		}
+		v = v.Elem()
+	}
+
+	switch v.Kind() {
+	case reflect.Struct:
+		if step.Kind != jsonpath.KeyStep {
+			return reflect.Value{}, fmt.Errorf("selector %s applied to an object", step)
+		}
+		f, ok := structField(v, step.Key)
+		if !ok {
+			return reflect.Value{}, fmt.Errorf("no field %q: %w", step.Key, ErrPathNotFound)
+		}
+		return f, nil
+	case reflect.Slice, reflect.Array:
+		i, err := sliceIndex(v, step)
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		return v.Index(i), nil
+	case reflect.Map:
+		key, err := mapKey(v, step)
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		e := v.MapIndex(key)
+		if !e.IsValid() {
+			return reflect.Value{}, fmt.Errorf("no entry %q: %w", step.Key, ErrPathNotFound)
+		}
+		return e, nil
+	default:
+		return reflect.Value{}, fmt.Errorf("cannot select %s from a %s", step, v.Type())
+	}
+}
+
+// profileSections are the optional sections whose presence selects the server profile. setPath does not create
+// them to reach a value within.
+var profileSections = map[reflect.Type]bool{
+	reflect.TypeOf(types.ServerConfig{}): true,
+	reflect.TypeOf(ServerOptions{}):      true,
+}
+
+// setPath sets the value at path[i:] within v, which must be settable. Map entries are not addressable, so
+// they are copied, changed and stored again.
+func setPath(v reflect.Value, path jsonpath.Path, i int, value any) error {
+	if i == len(path) {
+		nv, err := coerceValue(value, v.Type())
+		if err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		v.Set(nv)
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.Pointer:
+		if v.IsNil() {
+			if profileSections[v.Type().Elem()] {
+				return fmt.Errorf("%s: not set, and can only be set as a whole: %w", path[:i], ErrPathNotFound)
+			}
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		return setPath(v.Elem(), path, i, value)
+	case reflect.Interface:
+		if v.IsNil() {
+			return fmt.Errorf("%s: parent is not set: %w", path[:i+1], ErrPathNotFound)
+		}
+		e := reflect.New(v.Elem().Type()).Elem()
+		e.Set(v.Elem())
+		if err := setPath(e, path, i, value); err != nil {
+			return err
+		}
+		v.Set(e)
+		return nil
+	case reflect.Map:
+		key, err := mapKey(v, path[i])
+		if err != nil {
+			return fmt.Errorf("%s: %w", path[:i+1], err)
+		}
+		e := reflect.New(v.Type().Elem()).Elem()
+		if cur := v.MapIndex(key); cur.IsValid() {
+			e.Set(cur)
+		} else if i < len(path)-1 {
+			return fmt.Errorf("%s: no entry %q: %w", path[:i+1], path[i].Key, ErrPathNotFound)
+		}
+		if err = setPath(e, path, i+1, value); err != nil {
+			return err
+		}
+		if v.IsNil() {
+			v.Set(reflect.MakeMap(v.Type()))
+		}
+		v.SetMapIndex(key, e)
+		return nil
+	default:
+		next, err := pathChild(v, path[i])
+		if err != nil {
+			return fmt.Errorf("%s: %w", path[:i+1], err)
+		}
+		return setPath(next, path, i+1, value)
+	}
+}
+
+// structField returns the exported field of v named key, by its JSON name or Go name.
+func structField(v reflect.Value, key string) (reflect.Value, bool) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		if jsonpath.KeyMatches(key, f.Name) || (name != "" && jsonpath.KeyMatches(key, name)) {
+			return v.Field(i), true
+		}
+	}
+	return reflect.Value{}, false
+}
+
+// sliceIndex returns the index of the element of v selected by step.
+func sliceIndex(v reflect.Value, step jsonpath.Step) (int, error) {
+	switch step.Kind {
+	case jsonpath.IndexStep:
+		if step.Index >= v.Len() {
+			return 0, fmt.Errorf("index %d of %d elements: %w", step.Index, v.Len(), ErrPathNotFound)
+		}
+		return step.Index, nil
+	case jsonpath.MatchStep:
+		for i := 0; i < v.Len(); i++ {
+			e := reflect.Indirect(v.Index(i))
+			if e.Kind() != reflect.Struct {
+				continue
+			}
+			if f, ok := structField(e, step.Field); ok && fmt.Sprint(f.Interface()) == step.Value {
+				return i, nil
+			}
+		}
+		return 0, fmt.Errorf("element %s: %w", step, ErrPathNotFound)
+	default:
+		return 0, fmt.Errorf("field %q applied to a list", step.Key)
+	}
+}
+
+// mapKey returns the key of the entry of v named by step: an existing key matching it, or the key itself.
+func mapKey(v reflect.Value, step jsonpath.Step) (reflect.Value, error) {
+	if step.Kind != jsonpath.KeyStep {
+		return reflect.Value{}, fmt.Errorf("selector %s applied to a map", step)
+	}
+	if v.Type().Key().Kind() != reflect.String {
+		return reflect.Value{}, fmt.Errorf("cannot select %s from a %s", step, v.Type())
+	}
+	key := reflect.ValueOf(step.Key).Convert(v.Type().Key())
+	if v.MapIndex(key).IsValid() {
+		return key, nil
+	}
+	iter := v.MapRange()
+	for iter.Next() {
+		if jsonpath.KeyMatches(step.Key, iter.Key().String()) {
+			return iter.Key(), nil
+		}
+	}
+	return key, nil
+}
+
+// coerceValue converts value to t. Strings are parsed as t; other values that are not assignable to t are
+// converted through JSON.
+func coerceValue(value any, t reflect.Type) (reflect.Value, error) {
+	if value == nil {
+		return reflect.Zero(t), nil
+	}
+	v := reflect.ValueOf(value)
+	if v.Type().AssignableTo(t) {
+		return deepCopyValue(v, t), nil
+	}
+
+	out := reflect.New(t).Elem()
+	if str, ok := value.(string); ok {
+		var err error
+		switch t.Kind() {
+		case reflect.String:
+			out.SetString(str)
+		case reflect.Bool:
+			var b bool
+			if b, err = strconv.ParseBool(strings.TrimSpace(str)); err == nil {
+				out.SetBool(b)
+			}
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			var n int64
+			if n, err = strconv.ParseInt(strings.TrimSpace(str), 10, t.Bits()); err == nil {
+				out.SetInt(n)
+			}
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			var n uint64
+			if n, err = strconv.ParseUint(strings.TrimSpace(str), 10, t.Bits()); err == nil {
+				out.SetUint(n)
+			}
+		case reflect.Float32, reflect.Float64:
+			var f float64
+			if f, err = strconv.ParseFloat(strings.TrimSpace(str), t.Bits()); err == nil {
+				out.SetFloat(f)
+			}
+		default:
+			err = json.Unmarshal([]byte(str), out.Addr().Interface())
+		}
+		if err != nil {
+			return reflect.Value{}, fmt.Errorf("%q is not a valid %s", str, t)
+		}
+		return out, nil
+	}
+
+	data, err := json.Marshal(value)
+	if err == nil {
+		err = json.Unmarshal(data, out.Addr().Interface())
+	}
+	if err != nil {
+		return reflect.Value{}, fmt.Errorf("cannot use %v (%T) as %s", value, value, t)
+	}
+	return out, nil
+}
+
+// deepCopyValue returns a copy of v, assignable to t, that shares nothing with the caller's value.
+func deepCopyValue(v reflect.Value, t reflect.Type) reflect.Value {
+	out := reflect.New(t).Elem()
+	copied := reflect.New(v.Type()).Elem()
+	copyValue(copied, v)
+	out.Set(copied)
+	return out
+}
@@ -0,0 +1,209 @@
+package config
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	smerrors "github.com/Station-Manager/errors"
+	"github.com/Station-Manager/types"
+)
+
+func TestGet(t *testing.T) {
+	svc := &Service{WorkingDir: t.TempDir()}
+	if _, err := svc.Get("logging_config.level"); err == nil {
+		t.Fatalf("expected error before Initialize")
+	}
+	if err := svc.Initialize(); err != nil {
+		t.Fatalf("Initialize() error = %v", err)
+	}
+
+	cases := []struct {
+		path string
+		want any
+	}{
+		{"logging_config.level", "info"},
+		{"LoggingConfig.Level", "info"},
+		{"datastore_config.options.journal_mode", "WAL"},
+		{"rig_configs[id=1].serial_config.port_name", "/dev/ttyUSB0"},
+		{"rig_configs[0].SerialConfig.BaudRate", 38400},
+		{"rig_configs[name=FTdx10].id", int64(1)},
+		{"lookup_service_configs[name=" + types.QrzLookupServiceName + "].enabled", false},
+		{"station_options.allow_callsign_mismatch", false},
+		{"server_config", nil},
+	}
+	for _, tc := range cases {
+		t.Run(tc.path, func(t *testing.T) {
+			got, err := svc.Get(tc.path)
+			if err != nil {
+				t.Fatalf("Get() error = %v", err)
+			}
+			if got != tc.want {
+				t.Errorf("Get() = %#v, want %#v", got, tc.want)
+			}
+		})
+	}
+
+	rig, err := svc.Get("rig_configs[id=1]")
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	rig.(types.RigConfig).CatCommands[0].Name = "changed"
+	if again, _ := svc.Get("rig_configs[id=1].cat_commands[0].name"); again == "changed" {
+		t.Errorf("Get() returned shared data")
+	}
+
+	for _, path := range []string{"bogus", "rig_configs[id=9]", "rig_configs[5]", "server_config.port", "logging_config[0]"} {
+		if _, err = svc.Get(path); err == nil {
+			t.Errorf("Get(%q): expected error", path)
+		}
+	}
+	if _, err = svc.Get("rig_configs[id=9]"); !errors.Is(err, ErrPathNotFound) || !errors.Is(err, smerrors.ErrNotFound) {
+		t.Errorf("expected ErrPathNotFound, got %v", err)
+	}
+}
+
+func TestSet(t *testing.T) {
+	svc := &Service{WorkingDir: t.TempDir()}
+	if err := svc.Set("logging_config.level", "debug"); err == nil {
+		t.Fatalf("expected error before Initialize")
+	}
+	if err := svc.Initialize(); err != nil {
+		t.Fatalf("Initialize() error = %v", err)
+	}
+
+	cases := []struct {
+		path  string
+		value any
+		want  any
+	}{
+		{"logging_config.level", "debug", "debug"},
+		{"rig_configs[id=1].serial_config.port_name", "/dev/ttyUSB3", "/dev/ttyUSB3"},
+		{"rig_configs[id=1].serial_config.baud_rate", "9600", 9600},
+		{"rig_configs[id=1].serial_config.baud_rate", float64(19200), 19200},
+		{"station_options.allow_callsign_mismatch", "true", true},
+		{"lookup_service_configs[name=" + types.QrzLookupServiceName + "].username", "g4abc", "g4abc"},
+	}
+	for _, tc := range cases {
+		if err := svc.Set(tc.path, tc.value); err != nil {
+			t.Fatalf("Set(%q, %v) error = %v", tc.path, tc.value, err)
+		}
+		if got, _ := svc.Get(tc.path); got != tc.want {
+			t.Errorf("after Set(%q, %v), Get() = %#v, want %#v", tc.path, tc.value, got, tc.want)
+		}
+	}
+
+	// Changes are saved: a fresh service sees them.
+	reloaded := &Service{WorkingDir: svc.WorkingDir}
+	if err := reloaded.Initialize(); err != nil {
+		t.Fatalf("Initialize() error = %v", err)
+	}
+	if got, _ := reloaded.Get("rig_configs[id=1].serial_config.baud_rate"); got != 19200 {
+		t.Errorf("expected the change to be saved, got %v", got)
+	}
+
+	snap, _ := svc.Snapshot()
+	rejected := []struct {
+		path  string
+		value any
+		want  string
+	}{
+		{"logging_config.level", "", "logging_config.level"},
+		{"rig_configs[id=1].serial_config.baud_rate", "fast", "not a valid"},
+		{"rig_configs[id=1].serial_config.baud_rate", "9600.5", "not a valid"},
+		{"rig_configs[id=9].name", "Spare", "Not found"},
+		{"rig_configs[id=1].bogus", "x", "Not found"},
+		{"logging_config.level[0]", "x", "cannot select"},
+		// A desktop configuration does not turn into a server one through a path.
+		{"server_config.host", "x", "can only be set as a whole"},
+		{"server_options.trusted_proxies", "[]", "can only be set as a whole"},
+	}
+	for _, tc := range rejected {
+		err := svc.Set(tc.path, tc.value)
+		if err == nil {
+			t.Errorf("Set(%q, %v): expected error", tc.path, tc.value)
+			continue
+		}
+		if msg := ErrorMessage(err); !strings.Contains(msg, tc.want) {
+			t.Errorf("Set(%q, %v) error = %q, want it to mention %q", tc.path, tc.value, msg, tc.want)
+		}
+	}
+	if after, _ := svc.Snapshot(); after.Version != snap.Version {
+		t.Errorf("rejected changes were published")
+	}
+	if err := svc.Set("server_config.host", "x"); !errors.Is(err, ErrPathNotFound) {
+		t.Errorf("expected ErrPathNotFound for a path into a missing server_config, got %v", err)
+	}
+}
+
+func TestSet_mapsAndStructs(t *testing.T) {
+	svc := &Service{WorkingDir: t.TempDir()}
+	if err := svc.Initialize(); err != nil {
+		t.Fatalf("Initialize() error = %v", err)
+	}
+
+	if err := svc.Set("datastore_config.options.cache_size", "-4000"); err != nil {
+		t.Fatalf("Set() map entry error = %v", err)
+	}
+	if got, _ := svc.Get("datastore_config.options.cache_size"); got != "-4000" {
+		t.Errorf("Get() map entry = %#v", got)
+	}
+
+	if err := svc.Set("rig_configs[id=1].serial_config", map[string]any{"PortName": "COM4", "BaudRate": 4800, "DataBits": 8}); err != nil {
+		t.Fatalf("Set() struct error = %v", err)
+	}
+	if got, _ := svc.Get("rig_configs[id=1].serial_config.port_name"); got != "COM4" {
+		t.Errorf("expected the struct to be replaced, got port %v", got)
+	}
+	if err := svc.Set("rig_configs[id=1].serial_config", `{"PortName":"COM5","BaudRate":4800,"DataBits":8}`); err != nil {
+		t.Fatalf("Set() struct from JSON error = %v", err)
+	}
+	if got, _ := svc.Get("rig_configs[id=1].serial_config.port_name"); got != "COM5" {
+		t.Errorf("expected the struct to be decoded from JSON, got port %v", got)
+	}
+}
+
+func TestUnset(t *testing.T) {
+	svc := &Service{WorkingDir: t.TempDir()}
+	if err := svc.Unset("logging_config.level"); err == nil {
+		t.Fatalf("expected error before Initialize")
+	}
+	if err := svc.Initialize(); err != nil {
+		t.Fatalf("Initialize() error = %v", err)
+	}
+
+	if err := svc.Set("datastore_config.options.cache_size", "-4000"); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+	if err := svc.Unset("datastore_config.options.cache_size"); err != nil {
+		t.Fatalf("Unset() map entry error = %v", err)
+	}
+	if _, err := svc.Get("datastore_config.options.cache_size"); !errors.Is(err, ErrPathNotFound) {
+		t.Errorf("expected the map entry to be removed, got %v", err)
+	}
+
+	if err := svc.Set("station_options.allow_callsign_mismatch", true); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+	if err := svc.Unset("station_options.allow_callsign_mismatch"); err != nil {
+		t.Fatalf("Unset() error = %v", err)
+	}
+	if got, _ := svc.Get("station_options.allow_callsign_mismatch"); got != false {
+		t.Errorf("expected the default after Unset, got %v", got)
+	}
+	// Removing a value that is not set is not an error.
+	if err := svc.Unset("datastore_config.options.cache_size"); err != nil {
+		t.Errorf("Unset() of a missing value error = %v", err)
+	}
+
+	err := svc.Unset("logging_config.level")
+	if err == nil || !strings.Contains(ErrorMessage(err), "logging_config.level") {
+		t.Errorf("expected removing a required value to be rejected naming the path, got %v", err)
+	}
+	if got, _ := svc.Get("logging_config.level"); got != "info" {
+		t.Errorf("expected a rejected Unset to leave the value, got %v", got)
+	}
+	if err = svc.Unset("no_such_section"); !errors.Is(err, ErrPathNotFound) {
+		t.Errorf("expected ErrPathNotFound, got %v", err)
+	}
+}
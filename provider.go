@@ -0,0 +1,102 @@
+package config
+
+import (
+	"context"
+	"time"
+
+	"github.com/Station-Manager/types"
+)
+
+// Reader is the read-only part of the configuration API. Every method returns a copy that the caller may
+// modify freely.
+type Reader interface {
+	Snapshot() (Snapshot, error)
+	Profile() (string, error)
+	Get(path string) (any, error)
+	DiffFile(path string) ([]Change, error)
+	History() ([]AuditEntry, error)
+
+	DatastoreConfig() (types.DatastoreConfig, error)
+	LoggingConfig() (types.LoggingConfig, error)
+	RequiredConfigs() (types.RequiredConfigs, error)
+	OptionalConfigs() (types.OptionalConfigs, error)
+	LoggingStationConfigs() (types.LoggingStation, error)
+
+	RigConfigByID(rigID int64) (types.RigConfig, error)
+	CatStateValues() (types.StateValues, error)
+
+	ListenerConfigs() ([]types.ListenerConfig, error)
+	ListenerHandlerConfig(listenerName string, dst HandlerConfig) error
+	ListenerNetworkConfig(listenerName string) (ListenerNetworkConfig, error)
+
+	LookupServiceConfig(serviceName string) (types.LookupConfig, error)
+	LookupServiceConfigs() ([]types.LookupConfig, error)
+	LookupProviderOptions(serviceName string) (LookupProviderOptions, error)
+	LookupChain() ([]LookupChainEntry, error)
+
+	ForwarderConfig(serviceName string) (types.ForwarderConfig, error)
+	ForwarderConfigs() ([]types.ForwarderConfig, error)
+	EnabledForwarders() ([]types.ForwarderConfig, error)
+	ForwarderOptions(serviceName string) (ForwarderOptions, error)
+	ForwarderSchedule(serviceName string) (EffectiveForwarderSchedule, error)
+
+	EmailConfig() (types.EmailConfig, error)
+	EmailOptions() (EmailOptions, error)
+	EmailRecipients() (EmailRecipients, error)
+	RenderEmail(name string, data EmailTemplateData) (string, string, error)
+
+	ServerConfig() (*types.ServerConfig, error)
+	ServerOptions() (ServerOptions, error)
+}
+
+// Writer is the part of the configuration API that changes the configuration. Every change is validated and
+// saved before it becomes active; a rejected change leaves the configuration as it was.
+type Writer interface {
+	UpdateAppConfig(cfg types.AppConfig) error
+	Set(path string, value any) error
+	Unset(path string) error
+	PatchAppConfig(format PatchFormat, patch []byte, etag string) (Snapshot, error)
+	Rollback(revision uint64) error
+
+	SetCallsignLookupEnabled(enabled bool) error
+
+	AddRigConfig(cfg types.RigConfig) (types.RigConfig, error)
+	AddRigFromModel(model, name, portName string) (types.RigConfig, error)
+
+	AddListenerConfig(cfg types.ListenerConfig) error
+	AddListenerFromTemplate(templateName string) (types.ListenerConfig, error)
+	SetListenerNetworkConfig(cfg ListenerNetworkConfig) error
+
+	AddLookupServiceConfig(cfg types.LookupConfig) error
+	UpdateLookupServiceConfig(cfg types.LookupConfig) error
+	EnableLookupService(serviceName string) error
+	DisableLookupService(serviceName string) error
+	DeleteLookupServiceConfig(serviceName string) error
+	SetLookupProviderOptions(opts LookupProviderOptions) error
+
+	AddForwarderConfig(cfg types.ForwarderConfig) error
+	UpdateForwarderConfig(cfg types.ForwarderConfig) error
+	EnableForwarder(serviceName string) error
+	DisableForwarder(serviceName string) error
+	DeleteForwarderConfig(serviceName string) error
+	SetForwarderOptions(opts ForwarderOptions) error
+
+	SetEmailOptions(opts EmailOptions) error
+	SetServerOptions(opts ServerOptions) error
+}
+
+// Provider is the full configuration API implemented by Service. Depend on Provider, or on Reader where only
+// reads are needed, rather than on *Service, so tests can substitute the in-memory fake from the configtest
+// package.
+type Provider interface {
+	Reader
+	Writer
+
+	Initialize() error
+	EnsureServerCertificate() error
+	Reload(ctx context.Context) error
+	Watch(interval time.Duration, onError func(error)) error
+	Close() error
+}
+
+var _ Provider = (*Service)(nil)
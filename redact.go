@@ -0,0 +1,91 @@
+package config
+
+import (
+	"reflect"
+	"strings"
+)
+
+// RedactedValue replaces secrets in redacted output.
+const RedactedValue = "********"
+
+// secretFieldSuffixes name the struct fields that hold passwords, API keys and tokens, matched against the end
+// of the Go field name.
+var secretFieldSuffixes = []string{"Password", "APIKey", "Secret", "Token"}
+
+// isSecretField reports whether the struct field named name holds a secret.
+func isSecretField(name string) bool {
+	for _, suffix := range secretFieldSuffixes {
+		if strings.HasSuffix(name, suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+// isSecretKey reports whether the map entry or JSON member named key holds a secret. Keys are matched like
+// field names, ignoring case, underscores and hyphens, so api_key and apiKey are both secrets.
+func isSecretKey(key string) bool {
+	key = strings.ToLower(strings.NewReplacer("_", "", "-", "").Replace(key))
+	for _, suffix := range secretFieldSuffixes {
+		if strings.HasSuffix(key, strings.ToLower(suffix)) {
+			return true
+		}
+	}
+	return false
+}
+
+// Redacted returns a copy of the snapshot with every password, API key, token and JWT secret replaced by
+// RedactedValue, so it can be shown or logged. Empty and placeholder values are left as they are, as they
+// show that a secret still has to be set.
+func (s Snapshot) Redacted() Snapshot {
+	cp := deepCopy(s)
+	redactValue(reflect.ValueOf(&cp).Elem())
+	return cp
+}
+
+// redactValue replaces the secret string fields reachable from v.
+func redactValue(v reflect.Value) {
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		if !v.IsNil() {
+			redactValue(v.Elem())
+		}
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			redactValue(v.Index(i))
+		}
+	case reflect.Map:
+		if v.Type().Key().Kind() != reflect.String {
+			return
+		}
+		iter := v.MapRange()
+		for iter.Next() {
+			e := reflect.New(iter.Value().Type()).Elem()
+			e.Set(iter.Value())
+			if s, ok := e.Interface().(string); ok {
+				if isSecretKey(iter.Key().String()) && !isPlaceholder(s) {
+					v.SetMapIndex(iter.Key(), reflect.ValueOf(RedactedValue).Convert(e.Type()))
+				}
+				continue
+			}
+			redactValue(e)
+			v.SetMapIndex(iter.Key(), e)
+		}
+	case reflect.Struct:
+		t := v.Type()
+		for i := 0; i < v.NumField(); i++ {
+			f := v.Field(i)
+			if !f.CanSet() {
+				continue
+			}
+			if f.Kind() == reflect.String && isSecretField(t.Field(i).Name) {
+				if !isPlaceholder(f.String()) {
+					f.SetString(RedactedValue)
+				}
+				continue
+			}
+			redactValue(f)
+		}
+	default:
+	}
+}
@@ -0,0 +1,109 @@
+package config
+
+import (
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/Station-Manager/errors"
+	"github.com/Station-Manager/types"
+)
+
+// Rig model names accepted by RigModel and Service.AddRigFromModel.
+const (
+	YaesuFtdx10RigModel = "ftdx10"
+)
+
+// rigModels holds the built-in rig definitions: serial settings and CAT commands and states for each model.
+var rigModels = map[string]types.RigConfig{
+	YaesuFtdx10RigModel: ftdx10RigConfigs,
+}
+
+// RigModels returns the sorted names of the built-in rig models.
+func RigModels() []string {
+	names := make([]string, 0, len(rigModels))
+	for name := range rigModels {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// RigModel returns a copy of the named built-in rig definition, without an ID.
+func RigModel(model string) (types.RigConfig, error) {
+	const op errors.Op = "config.RigModel"
+
+	rig, ok := rigModels[strings.ToLower(strings.TrimSpace(model))]
+	if !ok {
+		return types.RigConfig{}, errors.New(op).Msgf("unknown rig model %q (available: %s)",
+			model, strings.Join(RigModels(), ", "))
+	}
+	return deepCopy(rig), nil
+}
+
+// AddRigConfig appends a rig configuration and persists it. A zero ID is replaced by the next free ID. The ID
+// and name must be unique.
+func (s *Service) AddRigConfig(cfg types.RigConfig) (types.RigConfig, error) {
+	const op errors.Op = "config.Service.AddRigConfig"
+	if !s.isInitialized.Load() {
+		return types.RigConfig{}, errors.New(op).Msg(errMsgNotInitialized)
+	}
+
+	cfg.Name = strings.TrimSpace(cfg.Name)
+	if cfg.Name == "" {
+		return types.RigConfig{}, errors.New(op).Msg("rig name cannot be empty")
+	}
+	if cfg.ID < 0 {
+		return types.RigConfig{}, errors.New(op).Msgf("invalid rig ID: %d", cfg.ID)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	snap := s.current()
+
+	var maxID int64
+	for _, r := range snap.AppConfig.RigConfigs {
+		if r.ID == cfg.ID {
+			return types.RigConfig{}, errors.New(op).Msgf("rig ID %d is already used by %q", cfg.ID, r.Name)
+		}
+		if strings.EqualFold(r.Name, cfg.Name) {
+			return types.RigConfig{}, errors.New(op).Msgf("rig %q already exists", cfg.Name)
+		}
+		maxID = max(maxID, r.ID)
+	}
+	if cfg.ID == 0 {
+		cfg.ID = maxID + 1
+	}
+
+	updated := snap.AppConfig
+	updated.RigConfigs = append(slices.Clone(snap.AppConfig.RigConfigs), cfg)
+
+	if err := s.saveConfig(updated, snap.Extensions); err != nil {
+		return types.RigConfig{}, errors.New(op).Err(err)
+	}
+
+	return deepCopy(cfg), nil
+}
+
+// AddRigFromModel adds the named built-in rig model with the next free ID. name replaces the model's default
+// name if it is not empty, and portName its serial port.
+func (s *Service) AddRigFromModel(model, name, portName string) (types.RigConfig, error) {
+	const op errors.Op = "config.Service.AddRigFromModel"
+
+	cfg, err := RigModel(model)
+	if err != nil {
+		return types.RigConfig{}, errors.New(op).Err(err)
+	}
+	if name != "" {
+		cfg.Name = name
+	}
+	if portName != "" {
+		cfg.SerialConfig.PortName = portName
+	}
+	if cfg, err = s.AddRigConfig(cfg); err != nil {
+		return types.RigConfig{}, errors.New(op).Err(err)
+	}
+
+	return cfg, nil
+}
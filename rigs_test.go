@@ -0,0 +1,73 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/Station-Manager/types"
+)
+
+func TestRigModel(t *testing.T) {
+	if models := RigModels(); len(models) == 0 || models[0] != YaesuFtdx10RigModel {
+		t.Fatalf("RigModels() = %v", models)
+	}
+	rig, err := RigModel(YaesuFtdx10RigModel)
+	if err != nil {
+		t.Fatalf("RigModel() error = %v", err)
+	}
+	if rig.ID != 0 || len(rig.CatCommands) == 0 {
+		t.Errorf("expected a CAT definition without an ID, got ID %d with %d commands", rig.ID, len(rig.CatCommands))
+	}
+	rig.CatCommands[0].Name = "changed"
+	if again, _ := RigModel(YaesuFtdx10RigModel); again.CatCommands[0].Name == "changed" {
+		t.Errorf("RigModel() returned shared data")
+	}
+	if _, err = RigModel("ic7300"); err == nil {
+		t.Errorf("expected error for an unknown model")
+	}
+}
+
+func TestAddRigConfig(t *testing.T) {
+	svc := &Service{WorkingDir: t.TempDir()}
+	if _, err := svc.AddRigConfig(types.RigConfig{Name: "Spare"}); err == nil {
+		t.Fatalf("expected error before Initialize")
+	}
+	if err := svc.Initialize(); err != nil {
+		t.Fatalf("Initialize() error = %v", err)
+	}
+
+	rig, err := svc.AddRigFromModel(YaesuFtdx10RigModel, "Shack", "/dev/ttyUSB1")
+	if err != nil {
+		t.Fatalf("AddRigFromModel() error = %v", err)
+	}
+	if rig.ID != 2 || rig.Name != "Shack" || rig.SerialConfig.PortName != "/dev/ttyUSB1" {
+		t.Errorf("unexpected rig: ID %d, name %q, port %q", rig.ID, rig.Name, rig.SerialConfig.PortName)
+	}
+	if got, err := svc.RigConfigByID(2); err != nil || got.Name != "Shack" {
+		t.Errorf("RigConfigByID(2) = %q, %v", got.Name, err)
+	}
+
+	cases := []struct {
+		name string
+		cfg  types.RigConfig
+	}{
+		{"empty name", types.RigConfig{Name: "  "}},
+		{"negative ID", types.RigConfig{ID: -1, Name: "Spare"}},
+		{"duplicate ID", types.RigConfig{ID: 1, Name: "Spare"}},
+		{"duplicate name", types.RigConfig{Name: "shack"}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := svc.AddRigConfig(tc.cfg); err == nil {
+				t.Errorf("expected error")
+			}
+		})
+	}
+
+	if _, err = svc.AddRigFromModel("ic7300", "", ""); err == nil {
+		t.Errorf("expected error for an unknown model")
+	}
+	snap, _ := svc.Snapshot()
+	if len(snap.AppConfig.RigConfigs) != 2 {
+		t.Errorf("expected only the successful addition to be saved, got %d rigs", len(snap.AppConfig.RigConfigs))
+	}
+}
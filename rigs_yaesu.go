@@ -2,13 +2,9 @@ package config
 
 import "github.com/Station-Manager/types"
 
-var ftdx10RigConfigs = types.RigConfig{
-	ID:           0,
-	Name:         "",
-	Model:        "",
-	Terminator:   "",
-	CatCommands:  nil,
-	CatStates:    nil,
-	SerialConfig: types.SerialConfig{},
-	CatConfig:    types.CatConfig{},
-}
+// ftdx10RigConfigs is the Yaesu FTdx10 CAT definition used by the default desktop configuration, without an ID.
+var ftdx10RigConfigs = func() types.RigConfig {
+	rig := defaultRigConfigs[0]
+	rig.ID = 0
+	return rig
+}()
@@ -0,0 +1,119 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Station-Manager/errors"
+	"github.com/Station-Manager/types"
+)
+
+// Configuration profiles. The server profile is selected by the presence of server_config or a PostgreSQL
+// datastore.
+const (
+	// ProfileDesktop is the single-user profile backed by SQLite.
+	ProfileDesktop = "desktop"
+	// ProfileServer is the multi-user profile backed by PostgreSQL and serving the HTTP API.
+	ProfileServer = "server"
+)
+
+// Server limits enforced by validateServerConfig. The minimum port matches the validation tag on
+// types.ServerConfig.
+const (
+	minServerPort      = 3000
+	maxServerPort      = 65535
+	maxServerTimeout   = 3600      // Seconds
+	maxServerBodyLimit = 100 << 20 // 100MB
+)
+
+// profileOf returns the profile of cfg: ProfileServer if it has a server section or uses PostgreSQL.
+func profileOf(cfg *types.AppConfig) string {
+	if cfg.ServerConfig != nil || cfg.DatastoreConfig.Driver == types.PostgresDriverName {
+		return ProfileServer
+	}
+	return ProfileDesktop
+}
+
+// serverProfileBase returns the sections of the server profile defaults that a server-mode document is decoded
+// over, so that fields the document leaves out take their default while values it sets, including zero values,
+// are kept. The datastore defaults only apply to a PostgreSQL datastore, or one without a driver.
+func serverProfileBase(driver string) types.AppConfig {
+	def := deepCopy(defaultServerConfig)
+	base := types.AppConfig{
+		LoggingConfig:   def.LoggingConfig,
+		RequiredConfigs: def.RequiredConfigs,
+		ServerConfig:    def.ServerConfig,
+		EmailConfigs:    def.EmailConfigs,
+	}
+	if driver == "" || driver == types.PostgresDriverName {
+		base.DatastoreConfig = def.DatastoreConfig
+	}
+	return base
+}
+
+// validateServerProfile checks that the server section and the datastore driver agree on the profile.
+func validateServerProfile(cfg *types.AppConfig) error {
+	const op errors.Op = "config.validateServerProfile"
+	switch driver := cfg.DatastoreConfig.Driver; {
+	case cfg.ServerConfig != nil && driver == types.SqliteDriverName:
+		return errors.New(op).Msgf("server_config requires the %s datastore driver, not %s", types.PostgresDriverName, driver)
+	case cfg.ServerConfig == nil && driver == types.PostgresDriverName:
+		return errors.New(op).Msgf("the %s datastore driver is only used in server mode, which requires server_config", driver)
+	}
+	return nil
+}
+
+// validateServerConfig checks the listen address, port, timeouts and body limit. The read timeout may not
+// exceed the write timeout, which may not exceed the idle timeout. All problems found are reported together.
+func validateServerConfig(server *types.ServerConfig) error {
+	const op errors.Op = "config.validateServerConfig"
+
+	var problems []string
+	if strings.TrimSpace(server.Name) == "" {
+		problems = append(problems, "name is required")
+	}
+	if !isValidHost(server.Host) {
+		problems = append(problems, fmt.Sprintf("host %q must be a hostname or IP address", server.Host))
+	}
+	if server.Port < minServerPort || server.Port > maxServerPort {
+		problems = append(problems, fmt.Sprintf("port %d must be between %d and %d", server.Port, minServerPort, maxServerPort))
+	}
+	for _, t := range []struct {
+		name  string
+		value int
+	}{
+		{"read_timeout", server.ReadTimeout},
+		{"write_timeout", server.WriteTimeout},
+		{"idle_timeout", server.IdleTimeout},
+	} {
+		if t.value < 1 || t.value > maxServerTimeout {
+			problems = append(problems, fmt.Sprintf("%s %d must be between 1 and %d seconds", t.name, t.value, maxServerTimeout))
+		}
+	}
+	if server.WriteTimeout < server.ReadTimeout {
+		problems = append(problems, fmt.Sprintf("write_timeout %d cannot be less than read_timeout %d", server.WriteTimeout, server.ReadTimeout))
+	}
+	if server.IdleTimeout < server.WriteTimeout {
+		problems = append(problems, fmt.Sprintf("idle_timeout %d cannot be less than write_timeout %d", server.IdleTimeout, server.WriteTimeout))
+	}
+	if server.BodyLimit < 1 || server.BodyLimit > maxServerBodyLimit {
+		problems = append(problems, fmt.Sprintf("body_limit %d must be between 1 and %d bytes", server.BodyLimit, maxServerBodyLimit))
+	}
+
+	if len(problems) > 0 {
+		return errors.New(op).Msgf("invalid server config: %s", strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// Profile returns ProfileServer if the configuration has a server section or uses PostgreSQL, otherwise
+// ProfileDesktop.
+func (s *Service) Profile() (string, error) {
+	const op errors.Op = "config.Service.Profile"
+	if !s.isInitialized.Load() {
+		return "", errors.New(op).Msg(errMsgNotInitialized)
+	}
+
+	snap := s.current()
+	return profileOf(&snap.AppConfig), nil
+}
@@ -0,0 +1,453 @@
+package config
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/Station-Manager/errors"
+	"github.com/Station-Manager/types"
+	"github.com/Station-Manager/utils"
+)
+
+// Server API authentication modes.
+const (
+	// ServerAuthNone accepts every request. Only suitable for a trusted LAN.
+	ServerAuthNone = "none"
+	// ServerAuthToken requires one of the configured API tokens as a bearer token.
+	ServerAuthToken = "token"
+	// ServerAuthJWT requires a JWT signed with the configured secret.
+	ServerAuthJWT = "jwt"
+)
+
+const (
+	minAPITokenLength       = 32
+	minJWTSecretLength      = 32
+	defaultJWTAlgorithm     = "HS256"
+	defaultJWTTTLSec        = 3600
+	maxJWTTTLSec            = 30 * 24 * 3600
+	defaultTLSMinVersion    = "1.2"
+	defaultSelfSignedDays   = 365
+	maxSelfSignedValidDays  = 3650
+	maxCORSMaxAgeSec        = 86400
+	selfSignedOrganization  = "Station Manager (self-signed)"
+	serverKeyFilePermission = 0o600
+)
+
+var (
+	jwtAlgorithms  = []string{"HS256", "HS384", "HS512"}
+	corsMethods    = []string{"GET", "HEAD", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"}
+	tlsMinVersions = map[string]uint16{"1.2": tls.VersionTLS12, "1.3": tls.VersionTLS13}
+)
+
+// ServerOptions extends types.ServerConfig with TLS, CORS, authentication and proxy settings. It is only
+// used in server mode, when ServerConfig is present.
+type ServerOptions struct {
+	TLS  ServerTLSOptions  `json:"tls"`
+	CORS CORSOptions       `json:"cors"`
+	Auth ServerAuthOptions `json:"auth"`
+	// TrustedProxies lists the IP addresses or CIDR ranges of reverse proxies whose X-Forwarded-For headers
+	// are trusted.
+	TrustedProxies []string `json:"trusted_proxies,omitempty"`
+}
+
+// ServerTLSOptions complements ServerConfig.TLSEnabled, TLSCertFile and TLSKeyFile. Relative file paths are
+// resolved against the working directory.
+type ServerTLSOptions struct {
+	// SelfSigned generates a self-signed certificate and key at TLSCertFile and TLSKeyFile when neither file
+	// exists. Intended for LAN use; clients will have to trust the certificate explicitly.
+	SelfSigned bool `json:"self_signed"`
+	// SelfSignedHosts are DNS names or IP addresses added to the generated certificate, in addition to Host
+	// and localhost.
+	SelfSignedHosts []string `json:"self_signed_hosts,omitempty"`
+	// SelfSignedValidDays is the validity of a generated certificate. Defaults to 365.
+	SelfSignedValidDays int `json:"self_signed_valid_days,omitempty"`
+	// MinVersion is the minimum TLS version, "1.2" or "1.3". Defaults to "1.2".
+	MinVersion string `json:"min_version,omitempty"`
+}
+
+// CORSOptions controls cross-origin requests to the server API. No origins means cross-origin requests are
+// refused.
+type CORSOptions struct {
+	// AllowedOrigins holds origins such as https://log.example.com, or "*" for any origin.
+	AllowedOrigins   []string `json:"allowed_origins,omitempty"`
+	AllowedMethods   []string `json:"allowed_methods,omitempty"`
+	AllowedHeaders   []string `json:"allowed_headers,omitempty"`
+	AllowCredentials bool     `json:"allow_credentials"`
+	// MaxAgeSec is how long browsers may cache a preflight response.
+	MaxAgeSec int `json:"max_age_sec"`
+}
+
+// ServerAuthOptions selects how API requests are authenticated.
+type ServerAuthOptions struct {
+	// Mode is one of ServerAuthNone, ServerAuthToken or ServerAuthJWT. Defaults to ServerAuthNone.
+	Mode string `json:"mode"`
+	// APITokens are accepted when Mode is ServerAuthToken.
+	APITokens []APIToken `json:"api_tokens,omitempty"`
+	// JWT is used when Mode is ServerAuthJWT.
+	JWT JWTOptions `json:"jwt"`
+}
+
+// APIToken is a named static bearer token. The name identifies the client in logs.
+type APIToken struct {
+	Name  string `json:"name"`
+	Token string `json:"token"`
+}
+
+// JWTOptions configures JWT issuing and verification.
+type JWTOptions struct {
+	// Secret is the HMAC signing key, at least 32 characters.
+	Secret string `json:"secret"`
+	// Algorithm is HS256, HS384 or HS512. Defaults to HS256.
+	Algorithm string `json:"algorithm"`
+	Issuer    string `json:"issuer,omitempty"`
+	Audience  string `json:"audience,omitempty"`
+	// TTLSec is the lifetime of issued tokens. Defaults to 3600.
+	TTLSec int `json:"ttl_sec"`
+}
+
+// TLSMinVersion returns the crypto/tls constant for MinVersion.
+func (o ServerTLSOptions) TLSMinVersion() uint16 {
+	if v, ok := tlsMinVersions[o.MinVersion]; ok {
+		return v
+	}
+	return tls.VersionTLS12
+}
+
+// applyServerOptionsDefaults fills zero-valued fields with their defaults and normalizes case.
+func applyServerOptionsDefaults(opts *ServerOptions) {
+	if opts.TLS.MinVersion == "" {
+		opts.TLS.MinVersion = defaultTLSMinVersion
+	}
+	if opts.TLS.SelfSignedValidDays == 0 {
+		opts.TLS.SelfSignedValidDays = defaultSelfSignedDays
+	}
+	opts.CORS.AllowedMethods = slices.Clone(opts.CORS.AllowedMethods)
+	for i, m := range opts.CORS.AllowedMethods {
+		opts.CORS.AllowedMethods[i] = strings.ToUpper(strings.TrimSpace(m))
+	}
+	opts.Auth.Mode = strings.ToLower(strings.TrimSpace(opts.Auth.Mode))
+	if opts.Auth.Mode == "" {
+		opts.Auth.Mode = ServerAuthNone
+	}
+	opts.Auth.JWT.Algorithm = strings.ToUpper(strings.TrimSpace(opts.Auth.JWT.Algorithm))
+	if opts.Auth.JWT.Algorithm == "" {
+		opts.Auth.JWT.Algorithm = defaultJWTAlgorithm
+	}
+	if opts.Auth.JWT.TTLSec == 0 {
+		opts.Auth.JWT.TTLSec = defaultJWTTTLSec
+	}
+}
+
+// validateServerOptions checks the server options that do not depend on the filesystem. All problems found are
+// reported together.
+func validateServerOptions(server *types.ServerConfig, opts ServerOptions) error {
+	const op errors.Op = "config.validateServerOptions"
+
+	var problems []string
+	if server.TLSEnabled {
+		if strings.TrimSpace(server.TLSCertFile) == "" {
+			problems = append(problems, "server_config.tls_cert_file is required when tls_enabled is set")
+		}
+		if strings.TrimSpace(server.TLSKeyFile) == "" {
+			problems = append(problems, "server_config.tls_key_file is required when tls_enabled is set")
+		}
+	}
+	if _, ok := tlsMinVersions[opts.TLS.MinVersion]; !ok {
+		problems = append(problems, fmt.Sprintf("tls.min_version %q must be 1.2 or 1.3", opts.TLS.MinVersion))
+	}
+	if v := opts.TLS.SelfSignedValidDays; v < 1 || v > maxSelfSignedValidDays {
+		problems = append(problems, fmt.Sprintf("tls.self_signed_valid_days %d must be between 1 and %d", v, maxSelfSignedValidDays))
+	}
+	for i, h := range opts.TLS.SelfSignedHosts {
+		if !isValidHost(h) {
+			problems = append(problems, fmt.Sprintf("tls.self_signed_hosts[%d] %q is not a hostname or IP address", i, h))
+		}
+	}
+
+	for i, origin := range opts.CORS.AllowedOrigins {
+		if origin == "*" {
+			if opts.CORS.AllowCredentials {
+				problems = append(problems, "cors.allowed_origins cannot contain \"*\" when allow_credentials is set")
+			}
+			continue
+		}
+		u, err := url.Parse(origin)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" || (u.Path != "" && u.Path != "/") || u.RawQuery != "" {
+			problems = append(problems, fmt.Sprintf("cors.allowed_origins[%d] %q must be \"*\" or a scheme and host, such as https://log.example.com", i, origin))
+		}
+	}
+	for i, m := range opts.CORS.AllowedMethods {
+		if !slices.Contains(corsMethods, m) {
+			problems = append(problems, fmt.Sprintf("cors.allowed_methods[%d] %q is not a supported HTTP method", i, m))
+		}
+	}
+	if v := opts.CORS.MaxAgeSec; v < 0 || v > maxCORSMaxAgeSec {
+		problems = append(problems, fmt.Sprintf("cors.max_age_sec %d must be between 0 and %d", v, maxCORSMaxAgeSec))
+	}
+
+	switch opts.Auth.Mode {
+	case ServerAuthNone:
+	case ServerAuthToken:
+		if len(opts.Auth.APITokens) == 0 {
+			problems = append(problems, "auth.api_tokens must contain at least one token when mode is token")
+		}
+		names := make(map[string]bool, len(opts.Auth.APITokens))
+		for i, t := range opts.Auth.APITokens {
+			if strings.TrimSpace(t.Name) == "" || names[t.Name] {
+				problems = append(problems, fmt.Sprintf("auth.api_tokens[%d]: name must be set and unique", i))
+			}
+			names[t.Name] = true
+			if isPlaceholder(t.Token) || len(t.Token) < minAPITokenLength {
+				problems = append(problems, fmt.Sprintf("auth.api_tokens[%d] %q: token must be at least %d characters", i, t.Name, minAPITokenLength))
+			}
+		}
+	case ServerAuthJWT:
+		if isPlaceholder(opts.Auth.JWT.Secret) || len(opts.Auth.JWT.Secret) < minJWTSecretLength {
+			problems = append(problems, fmt.Sprintf("auth.jwt.secret must be at least %d characters", minJWTSecretLength))
+		}
+		if !slices.Contains(jwtAlgorithms, opts.Auth.JWT.Algorithm) {
+			problems = append(problems, fmt.Sprintf("auth.jwt.algorithm %q must be one of %s", opts.Auth.JWT.Algorithm, strings.Join(jwtAlgorithms, ", ")))
+		}
+		if v := opts.Auth.JWT.TTLSec; v < 60 || v > maxJWTTTLSec {
+			problems = append(problems, fmt.Sprintf("auth.jwt.ttl_sec %d must be between 60 and %d", v, maxJWTTTLSec))
+		}
+	default:
+		problems = append(problems, fmt.Sprintf("auth.mode %q must be one of %s, %s or %s", opts.Auth.Mode, ServerAuthNone, ServerAuthToken, ServerAuthJWT))
+	}
+
+	for i, p := range opts.TrustedProxies {
+		if net.ParseIP(p) == nil {
+			if _, _, err := net.ParseCIDR(p); err != nil {
+				problems = append(problems, fmt.Sprintf("trusted_proxies[%d] %q must be an IP address or CIDR range", i, p))
+			}
+		}
+	}
+
+	if len(problems) > 0 {
+		return errors.New(op).Msgf("invalid server options: %s", strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// resolvePath returns p, resolved against workingDir if it is relative.
+func resolvePath(workingDir, p string) string {
+	if p == "" || filepath.IsAbs(p) {
+		return p
+	}
+	return filepath.Join(workingDir, p)
+}
+
+// selfSignedCertificatePending reports whether server_options requests a self-signed certificate that has
+// not been generated yet: neither the certificate nor the key file exists.
+func selfSignedCertificatePending(certFile, keyFile string, opts *ServerOptions) (bool, error) {
+	const op errors.Op = "config.selfSignedCertificatePending"
+	if opts == nil || !opts.TLS.SelfSigned {
+		return false, nil
+	}
+	certExists, err := utils.PathExists(certFile)
+	if err != nil {
+		return false, errors.New(op).Err(err)
+	}
+	keyExists, err := utils.PathExists(keyFile)
+	if err != nil {
+		return false, errors.New(op).Err(err)
+	}
+	return !certExists && !keyExists, nil
+}
+
+// checkServerTLS checks that the certificate and key can be loaded, match each other and that the certificate
+// is currently valid. A self-signed certificate that has not been generated yet is not checked; see
+// Service.EnsureServerCertificate. It does nothing unless the server is configured with TLS enabled, and never
+// writes anything.
+func checkServerTLS(workingDir string, server *types.ServerConfig, opts *ServerOptions) error {
+	const op errors.Op = "config.checkServerTLS"
+	if server == nil || !server.TLSEnabled {
+		return nil
+	}
+
+	certFile := resolvePath(workingDir, server.TLSCertFile)
+	keyFile := resolvePath(workingDir, server.TLSKeyFile)
+
+	pending, err := selfSignedCertificatePending(certFile, keyFile, opts)
+	if err != nil {
+		return errors.New(op).Err(err)
+	}
+	if pending {
+		return nil
+	}
+
+	pair, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return errors.New(op).Err(err).Msg("invalid server TLS certificate or key")
+	}
+	leaf, err := x509.ParseCertificate(pair.Certificate[0])
+	if err != nil {
+		return errors.New(op).Err(err).Msg("invalid server TLS certificate")
+	}
+	now := time.Now()
+	if now.After(leaf.NotAfter) {
+		return errors.New(op).Msgf("server TLS certificate %s expired on %s", server.TLSCertFile, leaf.NotAfter.UTC().Format(time.DateOnly))
+	}
+	if now.Before(leaf.NotBefore) {
+		return errors.New(op).Msgf("server TLS certificate %s is not valid until %s", server.TLSCertFile, leaf.NotBefore.UTC().Format(time.DateOnly))
+	}
+	return nil
+}
+
+// EnsureServerCertificate generates the self-signed server certificate and key if server_options requests one
+// and neither file exists, then checks the pair as loading the configuration does. Call it before starting the
+// server; loading and saving the configuration never write certificates. It does nothing unless the server is
+// configured with TLS enabled. In read-only mode, a certificate that would have to be generated is an error
+// wrapping ErrReadOnly.
+func (s *Service) EnsureServerCertificate() error {
+	const op errors.Op = "config.Service.EnsureServerCertificate"
+	if !s.isInitialized.Load() {
+		return errors.New(op).Msg(errMsgNotInitialized)
+	}
+
+	// Holding the lock keeps two callers from generating a certificate at the same time.
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	snap := s.current()
+	server, opts := snap.AppConfig.ServerConfig, snap.Extensions.ServerOptions
+	if server == nil || !server.TLSEnabled {
+		return nil
+	}
+
+	certFile := resolvePath(s.WorkingDir, server.TLSCertFile)
+	keyFile := resolvePath(s.WorkingDir, server.TLSKeyFile)
+	pending, err := selfSignedCertificatePending(certFile, keyFile, opts)
+	if err != nil {
+		return errors.New(op).Err(err)
+	}
+	if pending {
+		if s.readOnly {
+			return errors.New(op).Err(ErrReadOnly).Msgf("self-signed certificate %s is missing and cannot be generated: %s",
+				server.TLSCertFile, ErrReadOnly)
+		}
+		if err = generateSelfSignedCertificate(certFile, keyFile, server.Host, opts.TLS); err != nil {
+			return errors.New(op).Err(err).Msgf("generating self-signed certificate: %s", ErrorMessage(err))
+		}
+	}
+
+	if err = checkServerTLS(s.WorkingDir, server, opts); err != nil {
+		return errors.New(op).Err(err)
+	}
+	return nil
+}
+
+// generateSelfSignedCertificate writes a self-signed ECDSA P-256 certificate and its key in PEM form. The
+// certificate covers host, localhost, the loopback addresses and opts.SelfSignedHosts.
+func generateSelfSignedCertificate(certFile, keyFile, host string, opts ServerTLSOptions) error {
+	const op errors.Op = "config.generateSelfSignedCertificate"
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return errors.New(op).Err(err)
+	}
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return errors.New(op).Err(err)
+	}
+
+	now := time.Now()
+	tmpl := &x509.Certificate{
+		SerialNumber:          serial,
+		Subject:               pkix.Name{Organization: []string{selfSignedOrganization}, CommonName: "localhost"},
+		NotBefore:             now.Add(-time.Hour),
+		NotAfter:              now.AddDate(0, 0, opts.SelfSignedValidDays),
+		KeyUsage:              x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+		DNSNames:              []string{"localhost"},
+		IPAddresses:           []net.IP{net.IPv4(127, 0, 0, 1), net.IPv6loopback},
+	}
+	for _, h := range append([]string{host}, opts.SelfSignedHosts...) {
+		h = strings.TrimSpace(h)
+		if ip := net.ParseIP(h); ip != nil {
+			if !ip.IsUnspecified() {
+				tmpl.IPAddresses = append(tmpl.IPAddresses, ip)
+			}
+		} else if h != "" && !slices.Contains(tmpl.DNSNames, h) {
+			tmpl.DNSNames = append(tmpl.DNSNames, h)
+		}
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		return errors.New(op).Err(err)
+	}
+	keyDer, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return errors.New(op).Err(err)
+	}
+
+	for _, dir := range []string{filepath.Dir(certFile), filepath.Dir(keyFile)} {
+		if err = os.MkdirAll(dir, 0o750); err != nil {
+			return errors.New(op).Err(err)
+		}
+	}
+	if err = writeDataToFile(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), certFile, configFilePermission); err != nil {
+		return errors.New(op).Err(err)
+	}
+	// A certificate without its key would fail every later load, so it does not outlive a failed key write.
+	if err = writeDataToFile(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyDer}), keyFile, serverKeyFilePermission); err != nil {
+		_ = os.Remove(certFile)
+		return errors.New(op).Err(err)
+	}
+	return nil
+}
+
+// ServerOptions returns the TLS, CORS, authentication and proxy settings of the server. It fails if the
+// configuration has no server section.
+func (s *Service) ServerOptions() (ServerOptions, error) {
+	const op errors.Op = "config.Service.ServerOptions"
+	if !s.isInitialized.Load() {
+		return ServerOptions{}, errors.New(op).Msg(errMsgNotInitialized)
+	}
+
+	snap := s.current()
+	if snap.AppConfig.ServerConfig == nil || snap.Extensions.ServerOptions == nil {
+		return ServerOptions{}, errors.New(op).Err(ErrServerNotConfigured).Msg("the configuration has no server section")
+	}
+	return deepCopy(*snap.Extensions.ServerOptions), nil
+}
+
+// SetServerOptions replaces the server options and persists the change. If TLS is enabled, the certificate is
+// checked before the change is saved; a self-signed certificate is generated by EnsureServerCertificate.
+func (s *Service) SetServerOptions(opts ServerOptions) error {
+	const op errors.Op = "config.Service.SetServerOptions"
+	if !s.isInitialized.Load() {
+		return errors.New(op).Msg(errMsgNotInitialized)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	snap := s.current()
+	if snap.AppConfig.ServerConfig == nil {
+		return errors.New(op).Err(ErrServerNotConfigured).Msg("the configuration has no server section")
+	}
+
+	ext := snap.Extensions
+	ext.ServerOptions = &opts
+	if err := s.saveConfig(snap.AppConfig, ext); err != nil {
+		return errors.New(op).Err(err)
+	}
+	return nil
+}
@@ -0,0 +1,261 @@
+package config
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"io/fs"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Station-Manager/types"
+)
+
+func TestValidateServerOptions(t *testing.T) {
+	server := *defaultServerConfig.ServerConfig
+	opts := defaultServerOptions
+	applyServerOptionsDefaults(&opts)
+	if err := validateServerOptions(&server, opts); err != nil {
+		t.Fatalf("defaults should be valid, got %v", err)
+	}
+
+	secret := strings.Repeat("s", minJWTSecretLength)
+	tests := []struct {
+		name   string
+		modify func(s *types.ServerConfig, o *ServerOptions)
+		want   string
+	}{
+		{"tls without files", func(s *types.ServerConfig, o *ServerOptions) { s.TLSEnabled = true }, "tls_cert_file"},
+		{"tls version", func(s *types.ServerConfig, o *ServerOptions) { o.TLS.MinVersion = "1.0" }, "min_version"},
+		{"wildcard with credentials", func(s *types.ServerConfig, o *ServerOptions) {
+			o.CORS.AllowedOrigins, o.CORS.AllowCredentials = []string{"*"}, true
+		}, "allow_credentials"},
+		{"origin with path", func(s *types.ServerConfig, o *ServerOptions) {
+			o.CORS.AllowedOrigins = []string{"https://log.example.com/app"}
+		}, "allowed_origins[0]"},
+		{"method", func(s *types.ServerConfig, o *ServerOptions) { o.CORS.AllowedMethods = []string{"FETCH"} }, "allowed_methods[0]"},
+		{"token mode without tokens", func(s *types.ServerConfig, o *ServerOptions) { o.Auth.Mode = ServerAuthToken }, "api_tokens"},
+		{"short token", func(s *types.ServerConfig, o *ServerOptions) {
+			o.Auth.Mode, o.Auth.APITokens = ServerAuthToken, []APIToken{{Name: "logger", Token: "short"}}
+		}, "at least 32"},
+		{"short jwt secret", func(s *types.ServerConfig, o *ServerOptions) {
+			o.Auth.Mode, o.Auth.JWT.Secret = ServerAuthJWT, "secret"
+		}, "jwt.secret"},
+		{"jwt algorithm", func(s *types.ServerConfig, o *ServerOptions) {
+			o.Auth.Mode, o.Auth.JWT.Secret, o.Auth.JWT.Algorithm = ServerAuthJWT, secret, "RS256"
+		}, "jwt.algorithm"},
+		{"trusted proxy", func(s *types.ServerConfig, o *ServerOptions) { o.TrustedProxies = []string{"proxy.local"} }, "trusted_proxies[0]"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, o := server, opts
+			tt.modify(&s, &o)
+			err := validateServerOptions(&s, o)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("expected error mentioning %q, got %v", tt.want, err)
+			}
+		})
+	}
+
+	valid := opts
+	valid.Auth = ServerAuthOptions{Mode: ServerAuthJWT, JWT: JWTOptions{Secret: secret, Algorithm: "HS512", TTLSec: 600}}
+	valid.CORS.AllowedOrigins = []string{"https://log.example.com", "http://192.168.1.10:8080"}
+	valid.TrustedProxies = []string{"10.0.0.1", "192.168.0.0/16"}
+	if err := validateServerOptions(&server, valid); err != nil {
+		t.Errorf("validateServerOptions() error = %v", err)
+	}
+}
+
+// writeTestCertificate writes a self-signed certificate valid until notAfter, and its key.
+func writeTestCertificate(t *testing.T, certFile, keyFile string, notAfter time.Time) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{SerialNumber: big.NewInt(1), NotBefore: notAfter.AddDate(-1, 0, 0), NotAfter: notAfter}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDer, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err = os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyDer}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCheckServerTLS(t *testing.T) {
+	dir := t.TempDir()
+	server := &types.ServerConfig{Host: "station.local", TLSEnabled: true, TLSCertFile: "tls/cert.pem", TLSKeyFile: "tls/key.pem"}
+	opts := defaultServerOptions
+
+	if err := checkServerTLS(dir, server, &opts); err == nil {
+		t.Errorf("expected error for missing certificate")
+	}
+
+	// A self-signed certificate that is still to be generated is accepted, and nothing is written.
+	opts.TLS.SelfSigned = true
+	if err := checkServerTLS(dir, server, &opts); err != nil {
+		t.Errorf("checkServerTLS() error = %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "tls")); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("expected checkServerTLS not to write anything, got %v", err)
+	}
+
+	other := t.TempDir()
+	writeTestCertificate(t, filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem"), time.Now().AddDate(1, 0, 0))
+	writeTestCertificate(t, filepath.Join(other, "cert.pem"), filepath.Join(other, "key.pem"), time.Now().AddDate(1, 0, 0))
+	valid := &types.ServerConfig{TLSEnabled: true, TLSCertFile: "cert.pem", TLSKeyFile: "key.pem"}
+	if err := checkServerTLS(dir, valid, nil); err != nil {
+		t.Errorf("checkServerTLS() error = %v", err)
+	}
+	mismatched := &types.ServerConfig{TLSEnabled: true, TLSCertFile: filepath.Join(dir, "cert.pem"), TLSKeyFile: filepath.Join(other, "key.pem")}
+	if err := checkServerTLS(dir, mismatched, nil); err == nil {
+		t.Errorf("expected error for a key that does not match the certificate")
+	}
+
+	writeTestCertificate(t, filepath.Join(other, "cert.pem"), filepath.Join(other, "key.pem"), time.Now().AddDate(0, 0, -1))
+	expired := &types.ServerConfig{TLSEnabled: true, TLSCertFile: filepath.Join(other, "cert.pem"), TLSKeyFile: filepath.Join(other, "key.pem")}
+	if err := checkServerTLS(dir, expired, nil); err == nil || !strings.Contains(err.Error(), "expired") {
+		t.Errorf("expected error for an expired certificate, got %v", err)
+	}
+}
+
+func TestEnsureServerCertificate(t *testing.T) {
+	dir := t.TempDir()
+	svc, err := New(WithWorkingDir(dir), WithDefaults(ProfileServer))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	opts, _ := svc.ServerOptions()
+	opts.TLS.SelfSigned = true
+	opts.TLS.SelfSignedHosts = []string{"192.168.1.10"}
+	if err = svc.SetServerOptions(opts); err != nil {
+		t.Fatalf("SetServerOptions() error = %v", err)
+	}
+	snap, _ := svc.Snapshot()
+	cfg := snap.AppConfig
+	cfg.ServerConfig.Host, cfg.ServerConfig.TLSEnabled = "station.local", true
+	cfg.ServerConfig.TLSCertFile, cfg.ServerConfig.TLSKeyFile = "tls/cert.pem", "tls/key.pem"
+	if err = svc.UpdateAppConfig(cfg); err != nil {
+		t.Fatalf("UpdateAppConfig() error = %v", err)
+	}
+	certFile := filepath.Join(dir, "tls", "cert.pem")
+	if _, err = os.Stat(certFile); !errors.Is(err, fs.ErrNotExist) {
+		t.Fatalf("expected saving the configuration not to generate a certificate, got %v", err)
+	}
+
+	// A read-only service over the same file may not generate the certificate.
+	readOnly, err := New(WithWorkingDir(dir), WithReadOnly())
+	if err != nil {
+		t.Fatalf("New() read-only error = %v", err)
+	}
+	if err = readOnly.EnsureServerCertificate(); !errors.Is(err, ErrReadOnly) {
+		t.Errorf("expected ErrReadOnly when generation is not allowed, got %v", err)
+	}
+
+	if err = svc.EnsureServerCertificate(); err != nil {
+		t.Fatalf("EnsureServerCertificate() error = %v", err)
+	}
+	data, err := os.ReadFile(certFile)
+	if err != nil {
+		t.Fatalf("expected generated certificate: %v", err)
+	}
+	block, _ := pem.Decode(data)
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = cert.VerifyHostname("station.local"); err != nil {
+		t.Errorf("generated certificate does not cover Host: %v", err)
+	}
+	if err = cert.VerifyHostname("192.168.1.10"); err != nil {
+		t.Errorf("generated certificate does not cover self_signed_hosts: %v", err)
+	}
+
+	if info, err := os.Stat(filepath.Join(dir, "tls", "key.pem")); err != nil || info.Mode().Perm() != 0o600 {
+		t.Errorf("expected the key to be readable by the owner only, got %v, %v", info, err)
+	}
+
+	// An existing certificate is never replaced.
+	if err = svc.EnsureServerCertificate(); err != nil {
+		t.Fatalf("EnsureServerCertificate() error = %v", err)
+	}
+	if again, _ := os.ReadFile(certFile); string(again) != string(data) {
+		t.Errorf("existing certificate was regenerated")
+	}
+}
+
+func TestGenerateSelfSignedCertificate_keyFailure(t *testing.T) {
+	dir := t.TempDir()
+	certFile := filepath.Join(dir, "cert.pem")
+	// The key cannot replace a directory that is not empty.
+	keyFile := filepath.Join(dir, "key.pem")
+	if err := os.MkdirAll(filepath.Join(keyFile, "busy"), 0o750); err != nil {
+		t.Fatal(err)
+	}
+	if err := generateSelfSignedCertificate(certFile, keyFile, "station.local", ServerTLSOptions{}); err == nil {
+		t.Fatalf("expected an error when the key cannot be written")
+	}
+	if _, err := os.Stat(certFile); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("expected the certificate to be removed with its key, got %v", err)
+	}
+}
+
+func TestServerOptions_service(t *testing.T) {
+	t.Setenv(EnvSmDefaultDB, "postgres")
+	workDir := t.TempDir()
+	svc := &Service{WorkingDir: workDir}
+	if err := svc.Initialize(); err != nil {
+		t.Fatalf("Initialize() error = %v", err)
+	}
+
+	opts, err := svc.ServerOptions()
+	if err != nil {
+		t.Fatalf("ServerOptions() error = %v", err)
+	}
+	if opts.Auth.Mode != ServerAuthNone {
+		t.Errorf("expected authentication to be off by default, got %q", opts.Auth.Mode)
+	}
+
+	opts.Auth = ServerAuthOptions{Mode: ServerAuthToken, APITokens: []APIToken{{Name: "logger", Token: strings.Repeat("t", minAPITokenLength)}}}
+	opts.TrustedProxies = []string{"127.0.0.1"}
+	if err = svc.SetServerOptions(opts); err != nil {
+		t.Fatalf("SetServerOptions() error = %v", err)
+	}
+	opts.Auth.APITokens = nil
+	if err = svc.SetServerOptions(opts); err == nil {
+		t.Errorf("expected error for token mode without tokens")
+	}
+
+	reloaded := &Service{WorkingDir: workDir}
+	if err = reloaded.Initialize(); err != nil {
+		t.Fatalf("Initialize() error = %v", err)
+	}
+	got, _ := reloaded.ServerOptions()
+	if got.Auth.Mode != ServerAuthToken || len(got.Auth.APITokens) != 1 || len(got.TrustedProxies) != 1 {
+		t.Errorf("server options not persisted: %+v", got)
+	}
+
+	desktop := &Service{WorkingDir: t.TempDir()}
+	t.Setenv(EnvSmDefaultDB, "")
+	if err = desktop.Initialize(); err != nil {
+		t.Fatalf("Initialize() error = %v", err)
+	}
+	if _, err = desktop.ServerOptions(); err == nil {
+		t.Errorf("expected error for server options in desktop mode")
+	}
+}
@@ -0,0 +1,141 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Station-Manager/types"
+)
+
+func TestValidateServerConfig(t *testing.T) {
+	if err := validateServerConfig(defaultServerConfig.ServerConfig); err != nil {
+		t.Fatalf("defaults should be valid, got %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		modify func(s *types.ServerConfig)
+		want   string
+	}{
+		{"port too low", func(s *types.ServerConfig) { s.Port = 80 }, "port"},
+		{"port too high", func(s *types.ServerConfig) { s.Port = 70000 }, "port"},
+		{"host", func(s *types.ServerConfig) { s.Host = "http://example.com" }, "host"},
+		{"write before read", func(s *types.ServerConfig) { s.ReadTimeout, s.WriteTimeout = 30, 10 }, "write_timeout"},
+		{"idle before write", func(s *types.ServerConfig) { s.IdleTimeout = 5 }, "idle_timeout"},
+		{"body limit", func(s *types.ServerConfig) { s.BodyLimit = -1 }, "body_limit"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := *defaultServerConfig.ServerConfig
+			tt.modify(&s)
+			err := validateServerConfig(&s)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("expected error mentioning %q, got %v", tt.want, err)
+			}
+		})
+	}
+}
+
+func TestInitialize_serverProfileMergesMissingSections(t *testing.T) {
+	workDir := t.TempDir()
+	// A server-mode file as written by older releases: no required_configs values, and a partial server_config.
+	legacy := `{
+  "datastore_config": {"driver": "postgres", "host": "db.example.com"},
+  "logging_config": {"level": "warn", "log_file_max_backups": 0},
+  "required_configs": {},
+  "server_config": {"port": 8080}
+}`
+	if err := os.WriteFile(filepath.Join(workDir, configFileName), []byte(legacy), 0o640); err != nil {
+		t.Fatal(err)
+	}
+
+	svc := &Service{WorkingDir: workDir}
+	if err := svc.Initialize(); err != nil {
+		t.Fatalf("Initialize() error = %v", err)
+	}
+
+	if profile, _ := svc.Profile(); profile != ProfileServer {
+		t.Errorf("Profile() = %q, want %q", profile, ProfileServer)
+	}
+	req, _ := svc.RequiredConfigs()
+	if req.DefaultRigID != 1 || req.PagingationPageSize != defaultRequiredConfigs.PagingationPageSize || req.DefaultMode == "" {
+		t.Errorf("expected required config defaults to be merged, got %+v", req)
+	}
+	db, _ := svc.DatastoreConfig()
+	if db.Host != "db.example.com" || db.Port != postgresConfig.Port {
+		t.Errorf("expected configured host to be kept and port defaulted, got %+v", db)
+	}
+	logCfg, _ := svc.LoggingConfig()
+	if logCfg.Level != "warn" || logCfg.RelLogFileDir != "logs" || !logCfg.FileLogging {
+		t.Errorf("expected logging level to be kept and defaults merged, got %+v", logCfg)
+	}
+	if logCfg.LogFileMaxBackups != 0 {
+		t.Errorf("expected log_file_max_backups set to 0 to be kept, got %d", logCfg.LogFileMaxBackups)
+	}
+
+	server, err := svc.ServerConfig()
+	if err != nil {
+		t.Fatalf("ServerConfig() error = %v", err)
+	}
+	if server.Port != 8080 || server.ReadTimeout != 5 || server.Host == "" {
+		t.Errorf("expected configured port and default timeouts, got %+v", server)
+	}
+
+	// The returned value is a copy.
+	server.Port = 9999
+	if again, _ := svc.ServerConfig(); again.Port != 8080 {
+		t.Errorf("mutating the returned ServerConfig changed the service state")
+	}
+}
+
+func TestServerConfig_desktop(t *testing.T) {
+	svc := &Service{WorkingDir: t.TempDir()}
+	if err := svc.Initialize(); err != nil {
+		t.Fatalf("Initialize() error = %v", err)
+	}
+	if profile, _ := svc.Profile(); profile != ProfileDesktop {
+		t.Errorf("Profile() = %q, want %q", profile, ProfileDesktop)
+	}
+	if server, err := svc.ServerConfig(); server != nil || err != nil {
+		t.Errorf("ServerConfig() = %+v, %v, want nil, nil", server, err)
+	}
+}
+
+func TestInitialize_profileFromDriver(t *testing.T) {
+	// A PostgreSQL datastore without a server section is a server-mode file with the section left out.
+	workDir := t.TempDir()
+	postgresOnly := `{
+  "datastore_config": {"driver": "postgres", "host": "db.example.com"},
+  "logging_config": {"level": "info"}
+}`
+	if err := os.WriteFile(filepath.Join(workDir, configFileName), []byte(postgresOnly), 0o640); err != nil {
+		t.Fatal(err)
+	}
+	svc := &Service{WorkingDir: workDir}
+	if err := svc.Initialize(); err != nil {
+		t.Fatalf("Initialize() error = %v", err)
+	}
+	if profile, _ := svc.Profile(); profile != ProfileServer {
+		t.Errorf("Profile() = %q, want %q", profile, ProfileServer)
+	}
+	if server, _ := svc.ServerConfig(); server == nil || server.Port != defaultServerConfig.ServerConfig.Port {
+		t.Errorf("expected the default server section, got %+v", server)
+	}
+
+	// A server section on SQLite is rejected.
+	workDir = t.TempDir()
+	serverOnSqlite := `{
+  "datastore_config": {"driver": "sqlite", "path": "db/data.db"},
+  "logging_config": {"level": "info"},
+  "server_config": {"port": 8080}
+}`
+	if err := os.WriteFile(filepath.Join(workDir, configFileName), []byte(serverOnSqlite), 0o640); err != nil {
+		t.Fatal(err)
+	}
+	svc = &Service{WorkingDir: workDir}
+	if err := svc.Initialize(); err == nil || !strings.Contains(ErrorMessage(err), "server_config requires") {
+		t.Errorf("expected error for server_config on sqlite, got %v", err)
+	}
+}
@@ -1,7 +1,6 @@
 package config
 
 import (
-	"path/filepath"
 	"strings"
 	"sync"
 	"sync/atomic"
@@ -9,17 +8,43 @@ import (
 	"github.com/Station-Manager/errors"
 	"github.com/Station-Manager/types"
 	"github.com/Station-Manager/utils"
-	"github.com/goccy/go-json"
 )
 
 type Service struct {
-	WorkingDir    string `di.inject:"workingdir"`
+	WorkingDir string `di.inject:"workingdir"`
+	// Store, if set, replaces config.json in WorkingDir as the place the configuration is loaded from and saved
+	// to. WorkingDir is still used to resolve relative TLS certificate paths.
+	Store Store
+	// AppConfig may be pre-seeded with a LoggingConfig, with a non-empty Level, before Initialize; it then
+	// overrides the logging section of the file. It is not updated by the service, so read the configuration
+	// through the getters or Snapshot.
+	//
+	// Deprecated: Use New with WithOverrides to override the logging section.
 	AppConfig     types.AppConfig
 	isInitialized atomic.Bool
-	initOnce      sync.Once
+	// active holds the current configuration. It is replaced, never modified, when the configuration changes.
+	active atomic.Pointer[Snapshot]
+	// stored is the active configuration as it is saved, without overrides. It is guarded by mu.
+	stored *Snapshot
+	// mu serializes initialization, reloads and changes that are persisted to config.json.
+	mu sync.Mutex
+	// watchMu guards watchStop and watchDone, which control the goroutine started by Watch.
+	watchMu   sync.Mutex
+	watchStop chan struct{}
+	watchDone chan struct{}
+
+	// Set by the options passed to New; noCreate and readOnly may also be set by the environment.
+	profile      string // Profile of a generated default file; empty selects it from SM_DEFAULT_DB
+	noCreate     bool   // Fail rather than generate a missing file
+	readOnly     bool   // Reject every change
+	overrides    []any  // Sections replaced after every load; see WithOverrides
+	actor        string // Recorded in the audit log; empty records the user running the process
+	source       Source // Recorded in the audit log; empty records SourceAPI
+	historyLimit int    // Revisions kept in the history; 0 keeps DefaultHistoryLimit, UnlimitedHistory all of them
 }
 
-// Initialize initializes the config service.
+// Initialize initializes the config service. If it fails, for example because config.json is malformed, it
+// may be called again once the problem has been fixed.
 func (s *Service) Initialize() error {
 	const op errors.Op = "config.Service.Initialize"
 
@@ -27,42 +52,79 @@ func (s *Service) Initialize() error {
 		return nil // Exit gracefully
 	}
 
-	var initErr error
-	s.initOnce.Do(func() {
-		var err error
+	s.mu.Lock()
+	defer s.mu.Unlock()
 
-		// This is for situation where the service is not built with an IOCDI container.
-		if s.WorkingDir == "" {
-			if s.WorkingDir, err = utils.WorkingDir(s.WorkingDir); err != nil {
-				initErr = errors.New(op).Err(err).Msg(errMsgWorkingDir)
-				return
-			}
+	if s.isInitialized.Load() {
+		return nil // Initialized concurrently
+	}
+
+	// This is for situation where the service is not built with an IOCDI container.
+	if s.WorkingDir == "" {
+		wd, err := utils.WorkingDir(s.WorkingDir)
+		if err != nil {
+			return errors.New(op).Err(err).Msg(errMsgWorkingDir)
 		}
+		s.WorkingDir = wd
+	}
 
-		// If a LoggingConfig has been pre-seeded (common in tests), preserve it
-		// while still loading the remaining configuration from disk.
-		preseedLogCfg := s.AppConfig.LoggingConfig
+	if err := s.applyEnvMode(); err != nil {
+		return errors.New(op).Err(err)
+	}
 
-		if err = s.loadConfigFile(); err != nil {
-			initErr = errors.New(op).Err(err)
-			return
-		}
+	cfg, ext, stored, err := s.readConfig(!s.noCreate)
+	if err != nil {
+		return errors.New(op).Err(err)
+	}
 
-		// Restore pre-seeded LoggingConfig if it was provided (Level is our sentinel)
-		if preseedLogCfg.Level != "" {
-			s.AppConfig.LoggingConfig = preseedLogCfg
-		}
+	s.stored = stored
+	s.publish(cfg, ext)
+	s.isInitialized.Store(true)
+	return nil
+}
 
-		// Early validation of loaded configuration
-		if err = validateAppConfig(&s.AppConfig); err != nil {
-			initErr = errors.New(op).Err(err)
-			return
-		}
+// readConfig loads, validates and prepares the configuration on disk without making it active. The default
+// file is generated if it is missing and create is set. It returns the configuration with the overrides applied,
+// and as it is saved, without them. The caller must hold s.mu.
+func (s *Service) readConfig(create bool) (types.AppConfig, Extensions, *Snapshot, error) {
+	const op errors.Op = "config.Service.readConfig"
 
-		s.isInitialized.Store(true)
-	})
+	cfg, ext, err := s.loadConfigFile(create)
+	if err != nil {
+		return cfg, ext, nil, errors.New(op).Err(err)
+	}
+	loaded := &Snapshot{AppConfig: deepCopy(cfg), Extensions: deepCopy(ext)}
+
+	overrides := s.sectionOverrides()
+	if err = applyOverrides(&cfg, &ext, overrides); err != nil {
+		return cfg, ext, nil, errors.New(op).Err(err)
+	}
 
-	return initErr
+	// Early validation of loaded configuration
+	if err = validateAppConfig(&cfg, &ext); err != nil {
+		return cfg, ext, nil, errors.New(op).Err(err)
+	}
+	if err = checkServerTLS(s.WorkingDir, cfg.ServerConfig, ext.ServerOptions); err != nil {
+		return cfg, ext, nil, errors.New(op).Err(err)
+	}
+	return cfg, ext, storedSnapshot(cfg, ext, loaded, nil, overrides), nil
+}
+
+// sectionOverrides returns the sections set by WithOverrides, preceded by the deprecated pre-seeded
+// AppConfig.LoggingConfig if its Level is set.
+func (s *Service) sectionOverrides() []any {
+	if s.AppConfig.LoggingConfig.Level == "" {
+		return s.overrides
+	}
+	return append([]any{s.AppConfig.LoggingConfig}, s.overrides...)
+}
+
+// storedSnapshot returns the validated cfg and ext as they are saved: with the sections replaced by overrides
+// restored from stored, as described by restoreOverridden.
+func storedSnapshot(cfg types.AppConfig, ext Extensions, stored, published *Snapshot, overrides []any) *Snapshot {
+	cfg, ext = deepCopy(cfg), deepCopy(ext)
+	restoreOverridden(&cfg, &ext, stored, published, overrides)
+	return &Snapshot{ETag: contentETag(cfg, ext), AppConfig: cfg, Extensions: ext}
 }
 
 // DatastoreConfig returns the datastore configuration.
@@ -74,7 +136,9 @@ func (s *Service) DatastoreConfig() (types.DatastoreConfig, error) {
 		return emptyRetVal, errors.New(op).Msg(errMsgNotInitialized)
 	}
 
-	return s.AppConfig.DatastoreConfig, nil
+	snap := s.current()
+
+	return deepCopy(snap.AppConfig.DatastoreConfig), nil
 }
 
 // LoggingConfig returns the logging configuration.
@@ -86,10 +150,13 @@ func (s *Service) LoggingConfig() (types.LoggingConfig, error) {
 		return emptyRetVal, errors.New(op).Msg(errMsgNotInitialized)
 	}
 
-	return s.AppConfig.LoggingConfig, nil
+	snap := s.current()
+
+	return deepCopy(snap.AppConfig.LoggingConfig), nil
 }
 
-// ServerConfig returns the server configuration from the application configuration. It requires the service to be initialized.
+// ServerConfig returns a copy of the server configuration, or nil in desktop mode, where there is no server
+// section.
 func (s *Service) ServerConfig() (*types.ServerConfig, error) {
 	const op errors.Op = "config.Service.ServerConfig"
 
@@ -97,7 +164,9 @@ func (s *Service) ServerConfig() (*types.ServerConfig, error) {
 		return nil, errors.New(op).Msg(errMsgNotInitialized)
 	}
 
-	return s.AppConfig.ServerConfig, nil
+	snap := s.current()
+
+	return deepCopy(snap.AppConfig.ServerConfig), nil
 }
 
 // RequiredConfigs retrieves the required configurations for the application. Returns an error if the service is uninitialized.
@@ -107,7 +176,9 @@ func (s *Service) RequiredConfigs() (types.RequiredConfigs, error) {
 	if !s.isInitialized.Load() {
 		return types.RequiredConfigs{}, errors.New(op).Msg(errMsgNotInitialized)
 	}
-	return s.AppConfig.RequiredConfigs, nil
+
+	snap := s.current()
+	return deepCopy(snap.AppConfig.RequiredConfigs), nil
 }
 
 // RigConfigByID retrieves the RigConfig for the given rig ID from the service's AppConfig. Returns an error if unavailable.
@@ -118,13 +189,15 @@ func (s *Service) RigConfigByID(rigID int64) (types.RigConfig, error) {
 	if !s.isInitialized.Load() {
 		return emptyRetVal, errors.New(op).Msg(errMsgNotInitialized)
 	}
+
+	snap := s.current()
 	if rigID == 0 {
 		return emptyRetVal, errors.New(op).Errorf("Invalid rig ID: %d", rigID)
 	}
 
-	for _, rig := range s.AppConfig.RigConfigs {
+	for _, rig := range snap.AppConfig.RigConfigs {
 		if rig.ID == rigID {
-			return rig, nil
+			return deepCopy(rig), nil
 		}
 	}
 
@@ -140,12 +213,22 @@ func (s *Service) CatStateValues() (types.StateValues, error) {
 		return nil, errors.New(op).Msg(errMsgNotInitialized)
 	}
 
-	stateValues := make(types.StateValues)
-	rigConfig, err := s.RigConfigByID(s.AppConfig.RequiredConfigs.DefaultRigID)
-	if err != nil {
-		return nil, errors.New(op).Err(err)
+	snap := s.current()
+
+	// Read the rig from the same snapshot as the default rig ID.
+	rigID := snap.AppConfig.RequiredConfigs.DefaultRigID
+	if rigID == 0 {
+		return nil, errors.New(op).Errorf("Invalid rig ID: %d", rigID)
+	}
+	var rigConfig types.RigConfig
+	for _, rig := range snap.AppConfig.RigConfigs {
+		if rig.ID == rigID {
+			rigConfig = rig
+			break
+		}
 	}
 
+	stateValues := make(types.StateValues)
 	for _, state := range rigConfig.CatStates {
 		for _, marker := range state.Markers {
 			if len(marker.ValueMappings) == 0 {
@@ -170,7 +253,9 @@ func (s *Service) LoggingStationConfigs() (types.LoggingStation, error) {
 		return emptyRetVal, errors.New(op).Msg(errMsgNotInitialized)
 	}
 
-	return s.AppConfig.LoggingStation, nil
+	snap := s.current()
+
+	return deepCopy(snap.AppConfig.LoggingStation), nil
 }
 
 // LookupServiceConfig fetches the configuration for a given service by its name from the loaded application settings.
@@ -181,14 +266,16 @@ func (s *Service) LookupServiceConfig(serviceName string) (types.LookupConfig, e
 		return emptyRetVal, errors.New(op).Msg(errMsgNotInitialized)
 	}
 
+	snap := s.current()
+
 	serviceName = strings.TrimSpace(serviceName)
 	if serviceName == "" {
 		return emptyRetVal, errors.New(op).Msg("service name cannot be empty")
 	}
 
-	for _, cfg := range s.AppConfig.LookupServiceConfigs {
+	for _, cfg := range snap.AppConfig.LookupServiceConfigs {
 		if cfg.Name == serviceName {
-			return cfg, nil
+			return deepCopy(cfg), nil
 		}
 	}
 
@@ -196,7 +283,9 @@ func (s *Service) LookupServiceConfig(serviceName string) (types.LookupConfig, e
 }
 
 // ForwarderConfig retrieves the forwarder configuration for the specified service name.
-// Returns a ForwarderConfig object and nil error if found, otherwise returns an empty object and an appropriate error.
+// If no forwarder with that name is configured, the error wraps ErrForwarderNotFound. If the forwarder is
+// configured but disabled, its configuration is returned together with an error wrapping ErrForwarderDisabled,
+// so settings screens can still show it while upload code can simply check for a nil error.
 func (s *Service) ForwarderConfig(serviceName string) (types.ForwarderConfig, error) {
 	const op errors.Op = "config.Service.ForwarderConfig"
 	emptyRetVal := types.ForwarderConfig{}
@@ -204,28 +293,54 @@ func (s *Service) ForwarderConfig(serviceName string) (types.ForwarderConfig, er
 		return emptyRetVal, errors.New(op).Msg(errMsgNotInitialized)
 	}
 
+	snap := s.current()
+
 	serviceName = strings.TrimSpace(serviceName)
 	if serviceName == "" {
 		return emptyRetVal, errors.New(op).Msg("service name cannot be empty")
 	}
 
-	for _, cfg := range s.AppConfig.ForwardingConfigs {
+	for _, cfg := range snap.AppConfig.ForwardingConfigs {
 		if cfg.Name == serviceName {
-			return cfg, nil
+			if !cfg.Enabled {
+				return deepCopy(cfg), errors.New(op).Err(ErrForwarderDisabled).Msgf("forwarder is disabled: %s", serviceName)
+			}
+			return deepCopy(cfg), nil
 		}
 	}
 
-	return emptyRetVal, errors.New(op).Msgf("service config not found for: %s", serviceName)
+	return emptyRetVal, errors.New(op).Err(ErrForwarderNotFound).Msgf("service config not found for: %s", serviceName)
 }
 
-// ForwarderConfigs retrieves the list of forwarder configurations from the application configuration.
+// ForwarderConfigs retrieves the list of forwarder configurations from the application configuration, including
+// disabled forwarders. Use EnabledForwarders for the forwarders that should receive uploads.
 func (s *Service) ForwarderConfigs() ([]types.ForwarderConfig, error) {
 	const op errors.Op = "config.Service.ForwarderConfigs"
 	var emptyRetVal []types.ForwarderConfig
 	if !s.isInitialized.Load() {
 		return emptyRetVal, errors.New(op).Msg(errMsgNotInitialized)
 	}
-	return s.AppConfig.ForwardingConfigs, nil
+
+	snap := s.current()
+	return deepCopy(snap.AppConfig.ForwardingConfigs), nil
+}
+
+// EnabledForwarders retrieves the enabled forwarder configurations, in configuration order.
+func (s *Service) EnabledForwarders() ([]types.ForwarderConfig, error) {
+	const op errors.Op = "config.Service.EnabledForwarders"
+	if !s.isInitialized.Load() {
+		return nil, errors.New(op).Msg(errMsgNotInitialized)
+	}
+
+	snap := s.current()
+
+	enabled := make([]types.ForwarderConfig, 0, len(snap.AppConfig.ForwardingConfigs))
+	for _, cfg := range snap.AppConfig.ForwardingConfigs {
+		if cfg.Enabled {
+			enabled = append(enabled, cfg)
+		}
+	}
+	return deepCopy(enabled), nil
 }
 
 // EmailConfig retrieves the email configuration from the application configuration. Returns an error if uninitialized.
@@ -235,7 +350,9 @@ func (s *Service) EmailConfig() (types.EmailConfig, error) {
 	if !s.isInitialized.Load() {
 		return emptyRetVal, errors.New(op).Msg(errMsgNotInitialized)
 	}
-	return s.AppConfig.EmailConfigs, nil
+
+	snap := s.current()
+	return deepCopy(snap.AppConfig.EmailConfigs), nil
 }
 
 // OptionalConfigs retrieves optional configuration settings from the service if it has been properly initialized.
@@ -245,7 +362,9 @@ func (s *Service) OptionalConfigs() (types.OptionalConfigs, error) {
 	if !s.isInitialized.Load() {
 		return emptyRetVal, errors.New(op).Msg(errMsgNotInitialized)
 	}
-	return s.AppConfig.OptionalConfigs, nil
+
+	snap := s.current()
+	return deepCopy(snap.AppConfig.OptionalConfigs), nil
 }
 
 // ListenerConfigs retrieves the listener configuration from the application configuration.
@@ -256,21 +375,59 @@ func (s *Service) ListenerConfigs() ([]types.ListenerConfig, error) {
 	if !s.isInitialized.Load() {
 		return emptyRetVal, errors.New(op).Msg(errMsgNotInitialized)
 	}
-	return s.AppConfig.ListenerConfigs, nil
+
+	snap := s.current()
+	return deepCopy(snap.AppConfig.ListenerConfigs), nil
 }
 
-// UpdateAppConfig updates the application configuration and writes it to the configuration file.
+// ListenerHandlerConfig decodes the handler configuration of the named listener into dst, which must be a pointer
+// to the config type registered for the listener's handler (e.g. *WsjtxHandlerConfig for "wsjtx"). Registered
+// defaults are applied for missing fields and the result is validated.
+func (s *Service) ListenerHandlerConfig(listenerName string, dst HandlerConfig) error {
+	const op errors.Op = "config.Service.ListenerHandlerConfig"
+	if !s.isInitialized.Load() {
+		return errors.New(op).Msg(errMsgNotInitialized)
+	}
+
+	snap := s.current()
+
+	listenerName = strings.TrimSpace(listenerName)
+	if listenerName == "" {
+		return errors.New(op).Msg("listener name cannot be empty")
+	}
+	if dst == nil {
+		return errors.New(op).Msg("destination cannot be nil")
+	}
+
+	for _, cfg := range snap.AppConfig.ListenerConfigs {
+		if cfg.Name != listenerName {
+			continue
+		}
+		if cfg.Handler == "" {
+			return errors.New(op).Msgf("listener %q has no handler", listenerName)
+		}
+		if err := decodeHandlerConfig(cfg.Handler, cfg.HandlerConfig, dst); err != nil {
+			return errors.New(op).Err(err)
+		}
+		return nil
+	}
+
+	return errors.New(op).Msgf("listener config not found for: %s", listenerName)
+}
+
+// UpdateAppConfig validates cfg, writes it to the configuration file and makes it the active configuration.
+// The config package's own sections are kept as they are.
 func (s *Service) UpdateAppConfig(cfg types.AppConfig) error {
 	const op errors.Op = "config.Service.UpdateAppConfig"
 	if !s.isInitialized.Load() {
 		return errors.New(op).Msg(errMsgNotInitialized)
 	}
 
-	// Pretty-print selected configuration for readability
-	data, err := json.MarshalIndent(cfg, "", "  ")
-	if err != nil {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.saveConfig(cfg, s.current().Extensions); err != nil {
 		return errors.New(op).Err(err)
 	}
-
-	return writeDataToFile(data, filepath.Join(s.WorkingDir, configFileName))
+	return nil
 }
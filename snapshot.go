@@ -0,0 +1,56 @@
+package config
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+
+	"github.com/Station-Manager/errors"
+	"github.com/Station-Manager/types"
+)
+
+// Snapshot is a consistent view of the whole configuration at one version.
+type Snapshot struct {
+	// Version is 1 after Initialize and increases by one with every change that is saved.
+	Version uint64
+	// ETag identifies the content of the configuration: it changes whenever the content does and, unlike
+	// Version, is the same across restarts and processes for the same content. It is an opaque string, to be
+	// quoted for use in an HTTP ETag header.
+	ETag       string
+	AppConfig  types.AppConfig
+	Extensions Extensions
+}
+
+// current returns the active snapshot. The snapshot is shared and must not be modified.
+func (s *Service) current() *Snapshot {
+	return s.active.Load()
+}
+
+// publish makes cfg and ext the active configuration, as the next version. The caller must hold s.mu, or be
+// Initialize, and must not modify cfg or ext afterwards.
+func (s *Service) publish(cfg types.AppConfig, ext Extensions) {
+	var version uint64 = 1
+	if prev := s.active.Load(); prev != nil {
+		version = prev.Version + 1
+	}
+	s.active.Store(&Snapshot{Version: version, ETag: contentETag(cfg, ext), AppConfig: cfg, Extensions: ext})
+}
+
+// contentETag returns a hash of the configuration as it is written to the file.
+func contentETag(cfg types.AppConfig, ext Extensions) string {
+	data, err := marshalConfigFile(cfg, ext)
+	if err != nil {
+		return ""
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:16])
+}
+
+// Snapshot returns a deep copy of the active configuration. All sections are taken from the same version,
+// even while the configuration is being changed concurrently.
+func (s *Service) Snapshot() (Snapshot, error) {
+	const op errors.Op = "config.Service.Snapshot"
+	if !s.isInitialized.Load() {
+		return Snapshot{}, errors.New(op).Msg(errMsgNotInitialized)
+	}
+	return deepCopy(*s.current()), nil
+}
@@ -0,0 +1,176 @@
+package config
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+)
+
+func TestSnapshot_versions(t *testing.T) {
+	svc := &Service{WorkingDir: t.TempDir()}
+	if _, err := svc.Snapshot(); err == nil {
+		t.Errorf("expected error before Initialize")
+	}
+	if err := svc.Initialize(); err != nil {
+		t.Fatalf("Initialize() error = %v", err)
+	}
+
+	first, err := svc.Snapshot()
+	if err != nil {
+		t.Fatalf("Snapshot() error = %v", err)
+	}
+	if first.Version != 1 {
+		t.Errorf("expected version 1 after Initialize, got %d", first.Version)
+	}
+
+	if err = svc.SetCallsignLookupEnabled(false); err != nil {
+		t.Fatalf("SetCallsignLookupEnabled() error = %v", err)
+	}
+	if err = svc.EnableForwarder("missing"); err == nil {
+		t.Fatalf("expected error enabling an unknown forwarder")
+	}
+	second, _ := svc.Snapshot()
+	if second.Version != 2 {
+		t.Errorf("expected only the successful change to bump the version, got %d", second.Version)
+	}
+
+	// Earlier snapshots are unaffected by later changes.
+	if err = svc.SetEmailOptions(EmailOptions{To: []string{"ops@example.com"}}); err != nil {
+		t.Fatalf("SetEmailOptions() error = %v", err)
+	}
+	if len(second.Extensions.EmailOptions.To) != 0 {
+		t.Errorf("an earlier snapshot changed: %+v", second.Extensions.EmailOptions)
+	}
+}
+
+func TestUpdateAppConfig_publishes(t *testing.T) {
+	workDir := t.TempDir()
+	svc := &Service{WorkingDir: workDir}
+	if err := svc.Initialize(); err != nil {
+		t.Fatalf("Initialize() error = %v", err)
+	}
+
+	snap, _ := svc.Snapshot()
+	cfg := snap.AppConfig
+	cfg.LoggingConfig.Level = "debug"
+	if err := svc.UpdateAppConfig(cfg); err != nil {
+		t.Fatalf("UpdateAppConfig() error = %v", err)
+	}
+	if logCfg, _ := svc.LoggingConfig(); logCfg.Level != "debug" {
+		t.Errorf("expected updated level to be active, got %q", logCfg.Level)
+	}
+
+	cfg.LoggingConfig.Level = ""
+	if err := svc.UpdateAppConfig(cfg); err == nil {
+		t.Errorf("expected validation error")
+	}
+
+	reloaded := &Service{WorkingDir: workDir}
+	if err := reloaded.Initialize(); err != nil {
+		t.Fatalf("Initialize() error = %v", err)
+	}
+	if logCfg, _ := reloaded.LoggingConfig(); logCfg.Level != "debug" {
+		t.Errorf("expected updated level to be persisted, got %q", logCfg.Level)
+	}
+}
+
+// TestSnapshot_concurrentReadsDuringUpdates is meant to be run with -race.
+func TestSnapshot_concurrentReadsDuringUpdates(t *testing.T) {
+	svc := &Service{WorkingDir: t.TempDir()}
+	if err := svc.Initialize(); err != nil {
+		t.Fatalf("Initialize() error = %v", err)
+	}
+
+	const writers, readers, iterations = 2, 8, 25
+	var wg sync.WaitGroup
+	errs := make(chan error, writers*iterations+readers*iterations)
+
+	for w := 0; w < writers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; i < iterations; i++ {
+				opts := EmailOptions{To: []string{fmt.Sprintf("w%d-%d@example.com", w, i)}}
+				if err := svc.SetEmailOptions(opts); err != nil {
+					errs <- err
+				}
+				if err := svc.SetCallsignLookupEnabled(false); err != nil {
+					errs <- err
+				}
+			}
+		}(w)
+	}
+
+	for r := 0; r < readers; r++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			var last uint64
+			for i := 0; i < iterations; i++ {
+				snap, err := svc.Snapshot()
+				if err != nil {
+					errs <- err
+					continue
+				}
+				if snap.Version < last {
+					errs <- fmt.Errorf("version went backwards: %d after %d", snap.Version, last)
+				}
+				last = snap.Version
+				// Modifying a snapshot must not race with the service or other readers.
+				snap.Extensions.EmailOptions.To = append(snap.Extensions.EmailOptions.To, "reader@example.com")
+
+				if _, err = svc.EmailOptions(); err != nil {
+					errs <- err
+				}
+				if _, err = svc.ForwarderConfigs(); err != nil {
+					errs <- err
+				}
+				if _, err = svc.LookupChain(); err != nil {
+					errs <- err
+				}
+			}
+		}()
+	}
+
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Error(err)
+	}
+
+	final, _ := svc.Snapshot()
+	if want := uint64(1 + 2*writers*iterations); final.Version != want {
+		t.Errorf("expected version %d after all updates, got %d", want, final.Version)
+	}
+}
+
+func TestSnapshot_redacted(t *testing.T) {
+	svc := &Service{WorkingDir: t.TempDir()}
+	if err := svc.Initialize(); err != nil {
+		t.Fatalf("Initialize() error = %v", err)
+	}
+	if err := svc.SetEmailOptions(EmailOptions{To: []string{"ops@example.com"}}); err != nil {
+		t.Fatalf("SetEmailOptions() error = %v", err)
+	}
+	snap, _ := svc.Snapshot()
+	snap.AppConfig.EmailConfigs.Password = "hunter2"
+	snap.AppConfig.LookupServiceConfigs[0].Password = ""
+	snap.AppConfig.DatastoreConfig.Options["auth_token"] = "abc123"
+
+	red := snap.Redacted()
+	if red.AppConfig.EmailConfigs.Password != RedactedValue {
+		t.Errorf("expected the email password to be redacted, got %q", red.AppConfig.EmailConfigs.Password)
+	}
+	if red.AppConfig.LookupServiceConfigs[0].Password != "" {
+		t.Errorf("expected an empty password to be left as it is, got %q", red.AppConfig.LookupServiceConfigs[0].Password)
+	}
+	if red.AppConfig.DatastoreConfig.Options["auth_token"] != RedactedValue || red.AppConfig.DatastoreConfig.Options["mode"] != "rwc" {
+		t.Errorf("expected only the secret map entry to be redacted, got %v", red.AppConfig.DatastoreConfig.Options)
+	}
+	if red.AppConfig.EmailConfigs.Username != snap.AppConfig.EmailConfigs.Username {
+		t.Errorf("expected non-secret fields to be kept")
+	}
+	if snap.AppConfig.EmailConfigs.Password != "hunter2" {
+		t.Errorf("Redacted() modified the original snapshot")
+	}
+}
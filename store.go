@@ -0,0 +1,57 @@
+package config
+
+import (
+	"os"
+
+	"github.com/Station-Manager/errors"
+	"github.com/Station-Manager/types"
+)
+
+// configFilePermission gives the owner read and write access to the configuration file, and its group read
+// access.
+const configFilePermission = 0o640
+
+// Store loads and saves the configuration on behalf of Service. The default store is config.json in the
+// working directory; tests can substitute an in-memory store, such as the one used by the configtest package.
+type Store interface {
+	// Load returns the stored configuration. If nothing has been stored yet, the error wraps fs.ErrNotExist.
+	Load() (types.AppConfig, Extensions, error)
+	// Save replaces the stored configuration. cfg and ext have already been validated.
+	Save(cfg types.AppConfig, ext Extensions) error
+}
+
+// fileStore keeps the configuration in a JSON file.
+type fileStore struct {
+	path string
+}
+
+// Load reads and decodes the file.
+func (f fileStore) Load() (types.AppConfig, Extensions, error) {
+	const op errors.Op = "config.fileStore.Load"
+	var cfg types.AppConfig
+	var ext Extensions
+
+	data, err := os.ReadFile(f.path)
+	if err != nil {
+		return cfg, ext, errors.New(op).Err(err)
+	}
+	if err = unmarshalConfigFile(data, &cfg, &ext); err != nil {
+		return cfg, ext, errors.New(op).Err(err)
+	}
+	return cfg, ext, nil
+}
+
+// Save encodes cfg and ext and writes them to the file.
+func (f fileStore) Save(cfg types.AppConfig, ext Extensions) error {
+	const op errors.Op = "config.fileStore.Save"
+
+	// Pretty-print the configuration for readability
+	data, err := marshalConfigFile(cfg, ext)
+	if err != nil {
+		return errors.New(op).Err(err)
+	}
+	if err = writeDataToFile(data, f.path, configFilePermission); err != nil {
+		return errors.New(op).Err(err)
+	}
+	return nil
+}
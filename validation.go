@@ -9,11 +9,25 @@ import (
 
 // validateAppConfig performs minimal validation to avoid obviously bad configs while remaining permissive.
 // It also applies sensible defaults for missing or zero-valued fields.
-func validateAppConfig(cfg *types.AppConfig) error {
+func validateAppConfig(cfg *types.AppConfig, ext *Extensions) error {
 	const op errors.Op = "config.validateAppConfig"
 	if cfg == nil {
 		return errors.New(op).Msg("AppConfig is nil")
 	}
+	if ext == nil {
+		ext = &Extensions{}
+	}
+
+	// Server mode is detected by the presence of server_config or a PostgreSQL datastore; the two must agree.
+	// Anything a file leaves out has already been completed from the server profile defaults when it was decoded.
+	if err := validateServerProfile(cfg); err != nil {
+		return errors.New(op).Err(err)
+	}
+	if cfg.ServerConfig != nil {
+		if err := validateServerConfig(cfg.ServerConfig); err != nil {
+			return errors.New(op).Err(err)
+		}
+	}
 
 	db := cfg.DatastoreConfig
 	switch db.Driver {
@@ -34,9 +48,57 @@ func validateAppConfig(cfg *types.AppConfig) error {
 		return errors.New(op).Msg("logging level must be set")
 	}
 
+	// Callsigns are normalized in place; typos here would otherwise propagate into every logged QSO.
+	if err := validateLoggingStation(&cfg.LoggingStation, ext.StationOptions); err != nil {
+		return errors.New(op).Err(err)
+	}
+
+	if err := validateLookupConfigs(cfg.LookupServiceConfigs); err != nil {
+		return errors.New(op).Err(err)
+	}
+	if err := validateLookupOptions(ext.LookupOptions, cfg.LookupServiceConfigs); err != nil {
+		return errors.New(op).Err(err)
+	}
+
+	cfg.ListenerConfigs = normalizeListeners(cfg.ListenerConfigs)
+	if err := validateListeners(cfg.ListenerConfigs, cfg.ServerConfig); err != nil {
+		return errors.New(op).Err(err)
+	}
+	ext.ListenerNetworkConfigs = normalizeListenerNetworks(ext.ListenerNetworkConfigs)
+	if err := validateListenerNetworks(ext.ListenerNetworkConfigs, cfg.ListenerConfigs); err != nil {
+		return errors.New(op).Err(err)
+	}
+
 	// Apply defaults for forwarding config if not set (prevents panics from zero values)
 	applyForwardingDefaults(&cfg.RequiredConfigs)
 
+	// Per-forwarder schedules are validated against the global forwarding defaults applied above.
+	if err := validateForwarders(cfg.ForwardingConfigs, ext.ForwarderOptions, cfg.RequiredConfigs); err != nil {
+		return errors.New(op).Err(err)
+	}
+
+	applyEmailDefaults(&ext.EmailOptions, &cfg.EmailConfigs)
+	if err := validateEmailConfig(cfg.EmailConfigs, ext.EmailOptions); err != nil {
+		return errors.New(op).Err(err)
+	}
+	if err := validateEmailOptions(ext.EmailOptions, cfg.EmailConfigs); err != nil {
+		return errors.New(op).Err(err)
+	}
+
+	// Server options only apply in server mode. They are copied before defaults are applied, as the pointer
+	// may be shared with the active configuration.
+	if cfg.ServerConfig != nil {
+		var opts ServerOptions
+		if ext.ServerOptions != nil {
+			opts = *ext.ServerOptions
+		}
+		applyServerOptionsDefaults(&opts)
+		ext.ServerOptions = &opts
+		if err := validateServerOptions(cfg.ServerConfig, opts); err != nil {
+			return errors.New(op).Err(err)
+		}
+	}
+
 	return nil
 }
 